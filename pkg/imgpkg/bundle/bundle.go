@@ -8,6 +8,7 @@ import (
 	"io"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	goui "github.com/cppforlife/go-cli-ui/ui"
 	regname "github.com/google/go-containerregistry/pkg/name"
@@ -18,6 +19,7 @@ import (
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	plainimg "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -45,6 +47,13 @@ type Bundle struct {
 	// discovered as part of reading the bundle.
 	// Includes refs only directly referenced by the bundle.
 	cachedImageRefs map[string]ImageRef
+
+	// closureLock when provided is used to know which images are bundles
+	// and where the nested bundles are located without reaching out to the registry
+	closureLock *lockconfig.BundleClosureLock
+
+	// maxDepth is the maximum number of levels of nested bundles that are read, 0 means no limit
+	maxDepth int
 }
 
 func NewBundle(ref string, imagesMetadata ImagesMetadata) *Bundle {
@@ -61,6 +70,13 @@ func NewBundleWithReader(ref string, imagesMetadata ImagesMetadata, imagesLockRe
 		imgRetriever: imagesMetadata, imagesLockReader: imagesLockReader}
 }
 
+// WithMaxDepth Limits the levels of nested bundles that are read when pulling or copying the bundle,
+// 0 means no limit
+func (o *Bundle) WithMaxDepth(maxDepth int) *Bundle {
+	o.maxDepth = maxDepth
+	return o
+}
+
 func (o *Bundle) DigestRef() string { return o.plainImg.DigestRef() }
 func (o *Bundle) Repo() string      { return o.plainImg.Repo() }
 func (o *Bundle) Tag() string       { return o.plainImg.Tag() }
@@ -129,8 +145,8 @@ func (o *Bundle) NoteCopy(processedImages *imageset.ProcessedImages, reg ImagesM
 	return NewLocations(ui).Save(reg, destinationRef, locationsCfg, goui.NewNoopUI())
 }
 
-func (o *Bundle) Pull(outputPath string, ui goui.UI, pullNestedBundles bool) error {
-	isRootBundleRelocated, err := o.pull(outputPath, ui, pullNestedBundles, "", map[string]bool{}, 0)
+func (o *Bundle) Pull(outputPath string, ui goui.UI, pullNestedBundles bool, concurrency int) error {
+	isRootBundleRelocated, err := o.pull(outputPath, ui, pullNestedBundles, concurrency)
 	if err != nil {
 		return err
 	}
@@ -144,109 +160,217 @@ func (o *Bundle) Pull(outputPath string, ui goui.UI, pullNestedBundles bool) err
 	return nil
 }
 
-func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool, bundlePath string, imagesProcessed map[string]bool, numSubBundles int) (bool, error) {
-	img, err := o.checkedImage()
+// pulledBundle keeps track of a bundle extracted as part of a Pull.
+// Output is buffered so that bundles pulled concurrently are reported to the user in a deterministic order
+type pulledBundle struct {
+	bundle     *Bundle
+	bundlePath string
+	output     *util.BufferedUI
+	// depth is the level of nesting of the bundle, 0 for the root bundle
+	depth int
+	// done and printed are guarded by the lock of the pullProgress
+	done    bool
+	printed bool
+
+	// nestedBundles contains the images lock entries that are bundles, in the order they appear in the images lock
+	nestedBundles       []ImageRef
+	isRelocatedToBundle bool
+}
+
+func (o *Bundle) pull(baseOutputPath string, ui goui.UI, pullNestedBundles bool, concurrency int) (bool, error) {
+	throttleReq := util.NewThrottle(concurrency)
+	imagesProcessed := &pulledImages{isBundle: map[string]bool{}}
+	if o.closureLock != nil {
+		for _, closureBundle := range o.closureLock.Bundles {
+			for _, ref := range closureBundle.Images {
+				_, isBundle, _ := o.closureLock.Find(ref)
+				imagesProcessed.isBundle[ref] = isBundle
+			}
+		}
+	}
+
+	rootBundle := &pulledBundle{bundle: o, bundlePath: ""}
+	progress := newPullProgress(ui, rootBundle)
+	graph := newBundleGraph(o.maxDepth)
+
+	// Bundles are pulled one level of nesting at a time, so that when the same bundle is
+	// referenced more than once the one closest to the root is always the one that gets pulled
+	bundlesInLevel := []*pulledBundle{rootBundle}
+	for len(bundlesInLevel) > 0 {
+		err := o.pullConcurrently(bundlesInLevel, baseOutputPath, pullNestedBundles, &throttleReq, imagesProcessed, progress)
+		if err != nil {
+			progress.FlushOnError()
+			return false, err
+		}
+
+		// Nested bundles are only pulled after checking that they do not form
+		// a cycle and are not nested deeper than allowed
+		for _, pulled := range bundlesInLevel {
+			for _, bundleImgRef := range pulled.nestedBundles {
+				graph.Add(pulled.bundle.DigestRef(), bundleImgRef.Image)
+			}
+		}
+		err = graph.Validate(o.DigestRef())
+		if err != nil {
+			progress.FlushOnError()
+			return false, err
+		}
+
+		var nextLevel []*pulledBundle
+		for _, pulled := range bundlesInLevel {
+			for _, bundleImgRef := range pulled.nestedBundles {
+				if progress.Contains(bundleImgRef.Image) {
+					continue
+				}
+
+				bundleDigest, err := regname.NewDigest(bundleImgRef.Image)
+				if err != nil {
+					progress.FlushOnError()
+					return false, err
+				}
+
+				nestedBundleLocation := bundleImgRef.PrimaryLocation()
+				if o.closureLock != nil {
+					if location, _, found := o.closureLock.Find(bundleImgRef.Image); found {
+						nestedBundleLocation = location
+					}
+				}
+
+				nestedBundle := &pulledBundle{
+					bundle:     NewBundle(nestedBundleLocation, o.imgRetriever),
+					bundlePath: o.subBundlePath(bundleDigest),
+					depth:      pulled.depth + 1,
+				}
+				progress.Add(bundleImgRef.Image, nestedBundle)
+				nextLevel = append(nextLevel, nestedBundle)
+			}
+		}
+		bundlesInLevel = nextLevel
+	}
+
+	return rootBundle.isRelocatedToBundle, nil
+}
+
+func (o *Bundle) pullConcurrently(bundles []*pulledBundle, baseOutputPath string, pullNestedBundles bool, throttleReq *util.Throttle, imagesProcessed *pulledImages, progress *pullProgress) error {
+	// Bundles with the same digest are extracted into the same directory, so they are pulled one after the other
+	var bundlePaths []string
+	bundlesByPath := map[string][]*pulledBundle{}
+	for _, pulled := range bundles {
+		if _, found := bundlesByPath[pulled.bundlePath]; !found {
+			bundlePaths = append(bundlePaths, pulled.bundlePath)
+		}
+		bundlesByPath[pulled.bundlePath] = append(bundlesByPath[pulled.bundlePath], pulled)
+	}
+
+	var wg errgroup.Group
+
+	for _, bundlePath := range bundlePaths {
+		bundlesInPath := bundlesByPath[bundlePath]
+		wg.Go(func() error {
+			for _, pulled := range bundlesInPath {
+				err := pulled.bundle.extract(pulled, baseOutputPath, pullNestedBundles, throttleReq, imagesProcessed)
+				if err != nil {
+					return err
+				}
+				progress.Done(pulled)
+			}
+			return nil
+		})
+	}
+
+	return wg.Wait()
+}
+
+func (o *Bundle) extract(pulled *pulledBundle, baseOutputPath string, pullNestedBundles bool, throttleReq *util.Throttle, imagesProcessed *pulledImages) error {
+	throttleReq.Take()
+	bundleImageRefs, err := o.extractAndLocalize(pulled, baseOutputPath)
+	throttleReq.Done()
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	if o.rootBundle(bundlePath) {
-		ui.BeginLinef("Pulling bundle '%s'\n", o.DigestRef())
-	} else {
-		ui.BeginLinef("Pulling nested bundle '%s'\n", o.DigestRef())
+	if pullNestedBundles {
+		imageRefs := bundleImageRefs.ImageRefs()
+		isBundle := make([]bool, len(imageRefs))
+
+		var wg errgroup.Group
+		for i, bundleImgRef := range imageRefs {
+			i, bundleImgRef := i, bundleImgRef // copy
+			wg.Go(func() error {
+				var err error
+				isBundle[i], err = imagesProcessed.IsBundle(bundleImgRef, o.imgRetriever, throttleReq)
+				return err
+			})
+		}
+		err := wg.Wait()
+		if err != nil {
+			return err
+		}
+
+		for i, bundleImgRef := range imageRefs {
+			if isBundle[i] {
+				pulled.nestedBundles = append(pulled.nestedBundles, bundleImgRef)
+			}
+		}
 	}
 
+	return nil
+}
+
+// extractAndLocalize writes the bundle contents to disk and rewrites the images lock file
+// when every image is present in the bundle repository
+func (o *Bundle) extractAndLocalize(pulled *pulledBundle, baseOutputPath string) (ImageRefs, error) {
+	img, err := o.checkedImage()
+	if err != nil {
+		return ImageRefs{}, err
+	}
+
+	// Output is only recorded once the bundle is fetched, since printing it requires the bundle digest
+	pulled.output = util.NewBufferedUI()
+
 	bundleDigestRef, err := regname.NewDigest(o.plainImg.DigestRef())
 	if err != nil {
-		return false, err
+		return ImageRefs{}, err
 	}
 
-	err = ctlimg.NewDirImage(filepath.Join(baseOutputPath, bundlePath), img, goui.NewIndentingUI(ui)).AsDirectory()
+	err = ctlimg.NewDirImage(filepath.Join(baseOutputPath, pulled.bundlePath), img, pulled.output).AsDirectory()
 	if err != nil {
-		return false, fmt.Errorf("Extracting bundle into directory: %s", err)
+		return ImageRefs{}, fmt.Errorf("Extracting bundle into directory: %s", err)
 	}
 
-	imagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(baseOutputPath, bundlePath, ImgpkgDir, ImagesLockFile))
+	imagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(baseOutputPath, pulled.bundlePath, ImgpkgDir, ImagesLockFile))
 	if err != nil {
-		return false, err
+		return ImageRefs{}, err
 	}
 
 	bundleImageRefs, err := NewImageRefsFromImagesLock(imagesLock, LocationsConfig{
-		ui:              util.NewUILevelLogger(util.LogWarn, ui),
+		ui:              util.NewUILevelLogger(util.LogWarn, pulled.output),
 		imgRetriever:    o.imgRetriever,
 		bundleDigestRef: bundleDigestRef,
 	})
 	if err != nil {
-		return false, err
+		return ImageRefs{}, err
 	}
 
-	isRelocatedToBundle, err := bundleImageRefs.UpdateRelativeToRepo(o.imgRetriever, o.Repo())
+	pulled.isRelocatedToBundle, err = bundleImageRefs.UpdateRelativeToRepo(o.imgRetriever, o.Repo())
 	if err != nil {
-		return false, err
-	}
-
-	if pullNestedBundles {
-		for _, bundleImgRef := range bundleImageRefs.ImageRefs() {
-			if isBundle, alreadyProcessedImage := imagesProcessed[bundleImgRef.Image]; alreadyProcessedImage {
-				if isBundle {
-					goui.NewIndentingUI(ui).BeginLinef("Pulling nested bundle '%s'\n", bundleImgRef.Image)
-					goui.NewIndentingUI(ui).BeginLinef("Skipped, already downloaded\n")
-				}
-				continue
-			}
-
-			subBundle := NewBundle(bundleImgRef.PrimaryLocation(), o.imgRetriever)
-
-			var isBundle bool
-			if bundleImgRef.IsBundle != nil {
-				isBundle = *bundleImgRef.IsBundle
-			} else {
-				isBundle, err = subBundle.IsBundle()
-				if err != nil {
-					return false, err
-				}
-			}
-
-			imagesProcessed[bundleImgRef.Image] = isBundle
-
-			if !isBundle {
-				continue
-			}
-
-			numSubBundles++
-
-			if o.shouldPrintNestedBundlesHeader(bundlePath, numSubBundles) {
-				ui.BeginLinef("\nNested bundles\n")
-			}
-			bundleDigest, err := regname.NewDigest(bundleImgRef.Image)
-			if err != nil {
-				return false, err
-			}
-			_, err = subBundle.pull(baseOutputPath, goui.NewIndentingUI(ui), pullNestedBundles, o.subBundlePath(bundleDigest), imagesProcessed, numSubBundles)
-			if err != nil {
-				return false, err
-			}
-		}
+		return ImageRefs{}, err
 	}
 
-	if isRelocatedToBundle {
-		err := bundleImageRefs.ImagesLock().WriteToPath(filepath.Join(baseOutputPath, bundlePath, ImgpkgDir, ImagesLockFile))
+	if pulled.isRelocatedToBundle {
+		err := bundleImageRefs.ImagesLock().WriteToPath(filepath.Join(baseOutputPath, pulled.bundlePath, ImgpkgDir, ImagesLockFile))
 		if err != nil {
-			return false, fmt.Errorf("Rewriting image lock file: %s", err)
+			return ImageRefs{}, fmt.Errorf("Rewriting image lock file: %s", err)
 		}
 	}
 
-	return isRelocatedToBundle, nil
+	return bundleImageRefs, nil
 }
 
 func (*Bundle) subBundlePath(bundleDigest regname.Digest) string {
 	return filepath.Join(ImgpkgDir, BundlesDir, strings.ReplaceAll(bundleDigest.DigestStr(), "sha256:", "sha256-"))
 }
 
-func (o *Bundle) shouldPrintNestedBundlesHeader(bundlePath string, bundlesProcessed int) bool {
-	return o.rootBundle(bundlePath) && bundlesProcessed == 1
-}
-
 func (o *Bundle) rootBundle(bundlePath string) bool {
 	return bundlePath == ""
 }
@@ -267,6 +391,39 @@ func (o *Bundle) checkedImage() (regv1.Image, error) {
 	return img, err
 }
 
+// pulledImages caches, for every image referenced by the pulled bundles, if the image is a bundle or not
+type pulledImages struct {
+	lock     sync.Mutex
+	isBundle map[string]bool
+}
+
+// IsBundle checks if the image is a bundle, only reaching out to the registry when this information is not yet known
+func (p *pulledImages) IsBundle(imgRef ImageRef, imgRetriever ImagesMetadata, throttleReq *util.Throttle) (bool, error) {
+	if imgRef.IsBundle != nil {
+		return *imgRef.IsBundle, nil
+	}
+
+	p.lock.Lock()
+	isBundle, found := p.isBundle[imgRef.Image]
+	p.lock.Unlock()
+	if found {
+		return isBundle, nil
+	}
+
+	throttleReq.Take()
+	isBundle, err := NewBundle(imgRef.PrimaryLocation(), imgRetriever).IsBundle()
+	throttleReq.Done()
+	if err != nil {
+		return false, err
+	}
+
+	p.lock.Lock()
+	p.isBundle[imgRef.Image] = isBundle
+	p.lock.Unlock()
+
+	return isBundle, nil
+}
+
 type uiBlockWriter struct {
 	ui goui.UI
 }
@@ -0,0 +1,171 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+)
+
+// BundleCycleError Error returned when a bundle references itself, directly or through its nested bundles
+type BundleCycleError struct {
+	// Chain References of the bundles that form the cycle, the first and the last are the same bundle
+	Chain []string
+}
+
+func (e BundleCycleError) Error() string {
+	return fmt.Sprintf("Found cycle between bundles: %s", strings.Join(e.Chain, " -> "))
+}
+
+// MaxBundleDepthError Error returned when bundles are nested more levels than allowed
+type MaxBundleDepthError struct {
+	MaxDepth int
+	// Chain References of the bundles from the root bundle to the first bundle that is too deep
+	Chain []string
+}
+
+func (e MaxBundleDepthError) Error() string {
+	return fmt.Sprintf("Expected bundles to be nested at most %d levels deep: %s", e.MaxDepth, strings.Join(e.Chain, " -> "))
+}
+
+// bundleGraph records the nested bundles of every bundle, by digest,
+// to find reference cycles and how deep bundles are nested
+type bundleGraph struct {
+	// maxDepth is the maximum number of levels of nested bundles, 0 means no limit
+	maxDepth int
+
+	lock   sync.Mutex
+	names  map[string]string
+	nested map[string][]string
+}
+
+func newBundleGraph(maxDepth int) *bundleGraph {
+	return &bundleGraph{maxDepth: maxDepth, names: map[string]string{}, nested: map[string][]string{}}
+}
+
+// TooDeep checks if a bundle at the depth, 0 being the root bundle, should not be read
+func (g *bundleGraph) TooDeep(depth int) bool {
+	return g.maxDepth > 0 && depth > g.maxDepth
+}
+
+// CheckChain checks that the last bundle of the chain, which starts with the root bundle,
+// is not already part of the chain and is not nested deeper than the maximum depth
+func (g *bundleGraph) CheckChain(chain []string) error {
+	last := chain[len(chain)-1]
+	for i, chainBundle := range chain[:len(chain)-1] {
+		if sameDigest(chainBundle, last) {
+			return BundleCycleError{Chain: append([]string{}, chain[i:]...)}
+		}
+	}
+	if g.TooDeep(len(chain) - 1) {
+		return MaxBundleDepthError{MaxDepth: g.maxDepth, Chain: append([]string{}, chain...)}
+	}
+	return nil
+}
+
+// Add records that the bundle references the nested bundle, both are digest references
+func (g *bundleGraph) Add(bundleRef, nestedBundleRef string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	bundleDigest := g.digest(bundleRef)
+	nestedDigest := g.digest(nestedBundleRef)
+	for _, existing := range g.nested[bundleDigest] {
+		if existing == nestedDigest {
+			return
+		}
+	}
+	g.nested[bundleDigest] = append(g.nested[bundleDigest], nestedDigest)
+}
+
+func (g *bundleGraph) digest(ref string) string {
+	digest := ref
+	if digestRef, err := regname.NewDigest(ref); err == nil {
+		digest = digestRef.DigestStr()
+	}
+	if _, found := g.names[digest]; !found {
+		g.names[digest] = ref
+	}
+	return digest
+}
+
+// Validate returns a BundleCycleError when the bundles reachable from the root bundle form a cycle,
+// or a MaxBundleDepthError when they are nested deeper than the maximum depth
+func (g *bundleGraph) Validate(rootBundleRef string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	root := g.digest(rootBundleRef)
+
+	// deepestChain caches, for every visited bundle, the longest chain of nested bundles it starts
+	deepestChain := map[string][]string{}
+	inProgress := map[string]int{}
+	var path []string
+
+	var visit func(digest string) error
+	visit = func(digest string) error {
+		if idx, found := inProgress[digest]; found {
+			return BundleCycleError{Chain: g.refs(append(append([]string{}, path[idx:]...), digest))}
+		}
+		if _, found := deepestChain[digest]; found {
+			return nil
+		}
+
+		inProgress[digest] = len(path)
+		path = append(path, digest)
+
+		var deepest []string
+		nested := append([]string{}, g.nested[digest]...)
+		sort.Strings(nested)
+		for _, nestedDigest := range nested {
+			err := visit(nestedDigest)
+			if err != nil {
+				return err
+			}
+			if len(deepestChain[nestedDigest]) > len(deepest) {
+				deepest = deepestChain[nestedDigest]
+			}
+		}
+		deepestChain[digest] = append([]string{digest}, deepest...)
+
+		path = path[:len(path)-1]
+		delete(inProgress, digest)
+		return nil
+	}
+
+	err := visit(root)
+	if err != nil {
+		return err
+	}
+
+	// The chain contains the root bundle, which is not nested
+	if chain := deepestChain[root]; g.TooDeep(len(chain) - 1) {
+		return MaxBundleDepthError{MaxDepth: g.maxDepth, Chain: g.refs(chain[:g.maxDepth+2])}
+	}
+	return nil
+}
+
+func sameDigest(ref1, ref2 string) bool {
+	digest1, err := regname.NewDigest(ref1)
+	if err != nil {
+		return ref1 == ref2
+	}
+	digest2, err := regname.NewDigest(ref2)
+	if err != nil {
+		return false
+	}
+	return digest1.DigestStr() == digest2.DigestStr()
+}
+
+func (g *bundleGraph) refs(digests []string) []string {
+	var refs []string
+	for _, digest := range digests {
+		refs = append(refs, g.names[digest])
+	}
+	return refs
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestBundleNesting(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	app := fakeRegistry.WithRandomImage("library/app:v1")
+	reg := fakeRegistry.Build()
+	ui := util.NewUILevelLogger(util.LogWarn, &bundlefakes.FakeUI{})
+
+	pushBundle := func(t *testing.T, name string, images ...string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, ".imgpkg"), 0700))
+		imagesYAML := "apiVersion: imgpkg.carvel.dev/v1alpha1\nkind: ImagesLock\nimages:\n"
+		for _, image := range images {
+			imagesYAML += "- image: " + image + "\n"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".imgpkg", "images.yml"), []byte(imagesYAML), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(name), 0600))
+
+		uploadRef, err := regname.NewTag(fakeRegistry.ReferenceOnTestServer(name))
+		require.NoError(t, err)
+		bundleRef, err := bundle.NewContents([]string{dir}, nil).Push(uploadRef, reg, &bundlefakes.FakeUI{})
+		require.NoError(t, err)
+		return bundleRef
+	}
+
+	// root -> middle -> leaf -> app
+	leafBundle := pushBundle(t, "library/leaf", app.RefDigest)
+	middleBundle := pushBundle(t, "library/middle", leafBundle)
+	rootBundle := pushBundle(t, "library/root", middleBundle)
+
+	t.Run("when copying bundles nested deeper than the maximum depth it errors with the chain of bundles", func(t *testing.T) {
+		_, _, err := bundle.NewBundle(rootBundle, reg).WithMaxDepth(1).AllImagesRefs(1, ui)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Expected bundles to be nested at most 1 levels deep: %s -> %s -> %s", rootBundle, middleBundle, leafBundle))
+
+		bundles, _, err := bundle.NewBundle(rootBundle, reg).WithMaxDepth(2).AllImagesRefs(1, ui)
+		require.NoError(t, err)
+		assert.Len(t, bundles, 3)
+	})
+
+	t.Run("when pulling bundles nested deeper than the maximum depth it errors before pulling them", func(t *testing.T) {
+		outputPath := t.TempDir()
+		err := bundle.NewBundle(rootBundle, reg).WithMaxDepth(1).Pull(outputPath, &bundlefakes.FakeUI{}, true, 1)
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf("Expected bundles to be nested at most 1 levels deep: %s -> %s -> %s", rootBundle, middleBundle, leafBundle), err.Error())
+
+		leafDigest, err := regname.NewDigest(leafBundle)
+		require.NoError(t, err)
+		assert.NoDirExists(t, filepath.Join(outputPath, bundle.ImgpkgDir, bundle.BundlesDir, strings.ReplaceAll(leafDigest.DigestStr(), "sha256:", "sha256-")))
+
+		err = bundle.NewBundle(rootBundle, reg).WithMaxDepth(2).Pull(t.TempDir(), &bundlefakes.FakeUI{}, true, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("when bundles reference each other it errors with the cycle", func(t *testing.T) {
+		bundleA := pushBundle(t, "library/bundle-a")
+		bundleB := pushBundle(t, "library/bundle-b")
+		bundleC := pushBundle(t, "library/bundle-c")
+		nestedBundles := map[string]string{bundleA: bundleB, bundleB: bundleC, bundleC: bundleA}
+
+		imagesLockReader := &bundlefakes.FakeImagesLockReader{}
+		imagesLockReader.ReadStub = func(img regv1.Image) (lockconfig.ImagesLock, error) {
+			digest, err := img.Digest()
+			require.NoError(t, err)
+			for bundleRef, nestedBundleRef := range nestedBundles {
+				if bundleDigest, _ := regname.NewDigest(bundleRef); bundleDigest.DigestStr() == digest.String() {
+					return lockconfig.ImagesLock{Images: []lockconfig.ImageRef{{Image: nestedBundleRef}}}, nil
+				}
+			}
+			return lockconfig.ImagesLock{}, fmt.Errorf("unexpected image %s", digest)
+		}
+
+		_, _, err := bundle.NewBundleWithReader(bundleA, reg, imagesLockReader).AllImagesRefs(1, ui)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("Found cycle between bundles: %s -> %s -> %s -> %s", bundleA, bundleB, bundleC, bundleA))
+	})
+}
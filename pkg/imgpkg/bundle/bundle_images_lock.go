@@ -22,7 +22,9 @@ import (
 func (o *Bundle) AllImagesRefs(concurrency int, ui util.UIWithLevels) ([]*Bundle, ImageRefs, error) {
 	throttleReq := util.NewThrottle(concurrency)
 
-	bundles, allImageRefs, err := o.buildAllImagesLock(&throttleReq, &processedImages{processedImgs: map[string]struct{}{}}, ui)
+	graph := newBundleGraph(o.maxDepth)
+
+	bundles, allImageRefs, err := o.buildAllImagesLock(&throttleReq, &processedImages{processedImgs: map[string]struct{}{}}, graph, nil, ui)
 	if err != nil {
 		return nil, ImageRefs{}, err
 	}
@@ -37,10 +39,20 @@ func (o *Bundle) AllImagesRefs(concurrency int, ui util.UIWithLevels) ([]*Bundle
 				panic(fmt.Sprintf("Internal inconsistency: The Image '%s' cannot be found in the total list of images", ref.Image))
 			}
 			bundle.updateCachedImageRef(imgRef)
+			if imgRef.IsBundle != nil && *imgRef.IsBundle {
+				graph.Add(bundle.DigestRef(), imgRef.Image)
+			}
 		}
 	}
 
-	return bundles, allImageRefs, err
+	// Images already processed by another bundle are skipped while traversing, so
+	// cycles and depth are only fully known once every bundle has been read
+	err = graph.Validate(o.DigestRef())
+	if err != nil {
+		return nil, ImageRefs{}, err
+	}
+
+	return bundles, allImageRefs, nil
 }
 
 // UpdateImageRefs updates the bundle cached images without talking to the registry
@@ -73,7 +85,9 @@ func (o *Bundle) UpdateImageRefs(bundles []*Bundle) error {
 	return nil
 }
 
-func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *processedImages, ui util.UIWithLevels) ([]*Bundle, ImageRefs, error) {
+// buildAllImagesLock reads the images of the bundle and of its nested bundles,
+// parentBundles contains the references of the bundles from the root bundle up to the parent of this bundle
+func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *processedImages, graph *bundleGraph, parentBundles []string, ui util.UIWithLevels) ([]*Bundle, ImageRefs, error) {
 	o.cachedImageRefs = map[string]ImageRef{}
 
 	img, err := o.checkedImage()
@@ -85,6 +99,7 @@ func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *p
 	if err != nil {
 		panic(fmt.Sprintf("Internal inconsistency: The Bundle Reference '%s' does not have a digest", o.DigestRef()))
 	}
+	bundleChain := append(append([]string{}, parentBundles...), o.DigestRef())
 
 	locationsConfig := LocationsConfig{
 		ui:              ui,
@@ -119,7 +134,7 @@ func (o *Bundle) buildAllImagesLock(throttleReq *util.Throttle, processedImgs *p
 
 		image := image.DeepCopy()
 		go func() {
-			nestedBundles, nestedBundlesProcessedImageRefs, imgRef, err := o.imagesLockIfIsBundle(throttleReq, image, processedImgs, ui)
+			nestedBundles, nestedBundlesProcessedImageRefs, imgRef, err := o.imagesLockIfIsBundle(throttleReq, image, processedImgs, graph, bundleChain, ui)
 			if err != nil {
 				errChan <- err
 				return
@@ -168,7 +183,7 @@ func (o *Bundle) fetchImagesRef(img regv1.Image, locationsConfig ImageRefLocatio
 	return refs, nil
 }
 
-func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, imgRef ImageRef, processedImgs *processedImages, ui util.UIWithLevels) ([]*Bundle, ImageRefs, lockconfig.ImageRef, error) {
+func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, imgRef ImageRef, processedImgs *processedImages, graph *bundleGraph, bundleChain []string, ui util.UIWithLevels) ([]*Bundle, ImageRefs, lockconfig.ImageRef, error) {
 	throttleReq.Take()
 	// We need to check where we can find the image we are looking for.
 	// First checks the current bundle repository and if it cannot be found there
@@ -192,7 +207,12 @@ func (o *Bundle) imagesLockIfIsBundle(throttleReq *util.Throttle, imgRef ImageRe
 	var processedImageRefs ImageRefs
 	var nestedBundles []*Bundle
 	if isBundle {
-		nestedBundles, processedImageRefs, err = bundle.buildAllImagesLock(throttleReq, processedImgs, ui)
+		err = graph.CheckChain(append(append([]string{}, bundleChain...), imgRef.Image))
+		if err != nil {
+			return nil, ImageRefs{}, lockconfig.ImageRef{}, err
+		}
+
+		nestedBundles, processedImageRefs, err = bundle.buildAllImagesLock(throttleReq, processedImgs, graph, bundleChain, ui)
 		if err != nil {
 			return nil, ImageRefs{}, lockconfig.ImageRef{}, fmt.Errorf("Retrieving images for bundle '%s': %s", imgRef.Image, err)
 		}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/cppforlife/go-cli-ui/ui"
 	goui "github.com/cppforlife/go-cli-ui/ui"
+	"github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
@@ -38,7 +39,7 @@ func TestPullBundleWritingContentsToDisk(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -67,7 +68,7 @@ func TestPullBundleWritingContentsToDisk(t *testing.T) {
 		defer os.Remove(outputPath)
 
 		// test subject
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 		assert.DirExists(t, outputPath)
 
@@ -95,7 +96,7 @@ func TestPullNestedBundlesWritingContentsToDisk(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -121,7 +122,7 @@ func TestPullNestedBundlesWritingContentsToDisk(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -150,7 +151,7 @@ func TestPullNestedBundlesWritingContentsToDisk(t *testing.T) {
 		defer os.Remove(outputPath)
 
 		// test subject
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		// assert icecream bundle was recursively pulled onto disk
@@ -174,6 +175,45 @@ func TestPullNestedBundlesWritingContentsToDisk(t *testing.T) {
 	})
 }
 
+func TestPullNestedBundlesFromClosureLock(t *testing.T) {
+	fakeUI := &bundlefakes.FakeUI{}
+
+	t.Run("nested bundles are pulled from the locations recorded in the lock", func(t *testing.T) {
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		// repo/bundle_icecream_with_single_bundle - dependsOn - icecream/bundle, that was moved to mirror/icecream
+		icecreamBundle := fakeRegistry.WithBundleFromPath("icecream/bundle", "test_assets/bundle_with_mult_images").WithImageRefs([]lockconfig.ImageRef{})
+		fakeRegistry.CopyBundleImage(icecreamBundle, "mirror/icecream")
+		fakeRegistry.RemoveByImageRef(icecreamBundle.RefDigest)
+		rootBundle := fakeRegistry.WithBundleFromPath("repo/bundle_icecream_with_single_bundle", "test_assets/bundle_icecream_with_single_bundle").
+			WithImageRefs([]lockconfig.ImageRef{{Image: icecreamBundle.RefDigest}})
+		reg := fakeRegistry.Build()
+
+		mirroredIcecreamBundle := fakeRegistry.ReferenceOnTestServer("mirror/icecream") + "@" + icecreamBundle.Digest
+		closureLock := lockconfig.NewEmptyBundleClosureLock()
+		closureLock.Bundle = lockconfig.BundleRef{Image: rootBundle.RefDigest}
+		closureLock.Bundles = []lockconfig.ClosureBundleRef{
+			{Image: rootBundle.RefDigest, Images: []string{icecreamBundle.RefDigest}},
+			{Image: mirroredIcecreamBundle},
+		}
+		assert.NoError(t, closureLock.Validate())
+
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.Remove(outputPath)
+
+		err = bundle.NewBundleFromClosureLock(closureLock, reg).Pull(outputPath, fakeUI, true, 5)
+		assert.NoError(t, err)
+
+		outputDirConfigFile := filepath.Join(outputPath, ".imgpkg", "bundles", strings.ReplaceAll(icecreamBundle.Digest, "sha256:", "sha256-"), "config.yml")
+		assert.FileExists(t, outputDirConfigFile)
+
+		err = bundle.NewBundle(rootBundle.RefDigest, reg).Pull(outputPath, fakeUI, true, 5)
+		assert.Error(t, err, "Expected the nested bundle to not be found in its original location")
+	})
+}
+
 func TestPullNestedBundlesLocalizesImagesLockFile(t *testing.T) {
 	fakeUI := &bundlefakes.FakeUI{}
 	pullNestedBundles := true
@@ -201,7 +241,7 @@ func TestPullNestedBundlesLocalizesImagesLockFile(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -259,7 +299,7 @@ kind: ImagesLock
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		outputDirImagesYmlFile := filepath.Join(outputPath, ".imgpkg", "bundles", strings.ReplaceAll(icecreamBundle.Digest, "sha256:", "sha256-"), ".imgpkg", "images.yml")
@@ -341,7 +381,7 @@ func TestPullNestedBundlesLocalizesImagesLockFileWithLocationOCI(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -409,7 +449,7 @@ kind: ImagesLock
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -477,7 +517,7 @@ kind: ImagesLock
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -541,7 +581,7 @@ kind: ImagesLock
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, fakeUI, pullNestedBundles)
+		err = subject.Pull(outputPath, fakeUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -578,7 +618,7 @@ func TestPullBundleOutputToUser(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.Regexp(t,
@@ -605,7 +645,7 @@ One or more images not found in bundle repo; skipping lock file update`, bundleN
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.Regexp(t,
@@ -615,6 +655,22 @@ One or more images not found in bundle repo; skipping lock file update`, bundleN
 Locating image lock file images...
 One or more images not found in bundle repo; skipping lock file update`, bundleName), output.String())
 	})
+
+	t.Run("bundle that cannot be fetched returns the error without output", func(t *testing.T) {
+		output := bytes.NewBufferString("")
+		writerUI := ui.NewWriterUI(output, output, nil)
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		subject := bundle.NewBundle(fakeRegistry.ReferenceOnTestServer("repo/missing-bundle"), fakeRegistry.Build())
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.Remove(outputPath)
+
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
+		assert.Error(t, err)
+		assert.Empty(t, output.String())
+	})
 }
 
 func TestPullAllNestedBundlesOutputToUser(t *testing.T) {
@@ -647,7 +703,7 @@ func TestPullAllNestedBundlesOutputToUser(t *testing.T) {
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.Regexp(t,
@@ -663,6 +719,40 @@ The bundle repo \(%s\) is hosting every image specified in the bundle's Images L
 `, fakeRegistry.ReferenceOnTestServer("repo/bundle-with-collocated-bundles")), output.String())
 	})
 
+	t.Run("when a nested bundle fails to be pulled, the output of the bundles already pulled is written", func(t *testing.T) {
+		defer output.Reset()
+
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		// repo/bundle_icecream_with_single_bundle - dependsOn - icecream/bundle - dependsOn - apples/bundle (missing)
+		applesBundle := fakeRegistry.WithBundleFromPath("apples/bundle", "test_assets/bundle_with_mult_images").WithImageRefs([]lockconfig.ImageRef{})
+		icecreamBundle := fakeRegistry.WithBundleFromPath("icecream/bundle", "test_assets/bundle_apples_with_single_bundle").WithImageRefs([]lockconfig.ImageRef{
+			{Image: applesBundle.RefDigest},
+		})
+		rootBundle := fakeRegistry.WithBundleFromPath("repo/bundle_icecream_with_single_bundle", "test_assets/bundle_icecream_with_single_bundle").WithImageRefs([]lockconfig.ImageRef{
+			{Image: icecreamBundle.RefDigest},
+		})
+		fakeRegistry.RemoveByImageRef(applesBundle.RefDigest)
+
+		subject := bundle.NewBundle(rootBundle.RefDigest, fakeRegistry.Build())
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.Remove(outputPath)
+
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
+		assert.Error(t, err)
+
+		assert.Regexp(t,
+			fmt.Sprintf(`Pulling bundle '%s'
+  Extracting layer 'sha256:.*' \(1/1\)
+
+Nested bundles
+  Pulling nested bundle '%s'
+    Extracting layer 'sha256:.*' \(1/1\)
+`, rootBundle.RefDigest, icecreamBundle.RefDigest), output.String())
+	})
+
 	t.Run("bundle referencing another *not* colocated bundle", func(t *testing.T) {
 		defer output.Reset()
 
@@ -679,7 +769,7 @@ The bundle repo \(%s\) is hosting every image specified in the bundle's Images L
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		icecreamBundleName := fakeRegistry.ReferenceOnTestServer("icecream/bundle")
@@ -723,7 +813,7 @@ One or more images not found in bundle repo; skipping lock file update`, bundleN
 		assert.NoError(t, err)
 		defer os.Remove(outputPath)
 
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		assert.DirExists(t, outputPath)
@@ -767,7 +857,7 @@ One or more images not found in bundle repo; skipping lock file update`, bundleW
 		defer os.Remove(outputPath)
 
 		// test subject
-		err = subject.Pull(outputPath, writerUI, pullNestedBundles)
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 5)
 		assert.NoError(t, err)
 
 		//assert log message
@@ -784,6 +874,62 @@ Nested bundles
 Locating image lock file images...
 One or more images not found in bundle repo; skipping lock file update`, icecreamWithSingleBundle.RefDigest, icecreamBundle.RefDigest, applesBundle.RefDigest), output.String())
 	})
+
+	t.Run("bundle referencing multiple bundles pulled concurrently", func(t *testing.T) {
+		defer output.Reset()
+
+		fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+		defer fakeRegistry.CleanUp()
+
+		// repo/bundle_with_multiple_bundle - dependsOn - [apples/bundle, icecream/bundle, oranges/bundle]
+		applesBundle := fakeRegistry.WithBundleFromPath("apples/bundle", "test_assets/bundle").WithImageRefs([]lockconfig.ImageRef{
+			{Image: fakeRegistry.WithRandomImage("library/apples").RefDigest},
+		})
+		icecreamBundle := fakeRegistry.WithBundleFromPath("icecream/bundle", "test_assets/bundle").WithImageRefs([]lockconfig.ImageRef{
+			{Image: fakeRegistry.WithRandomImage("library/icecream").RefDigest},
+		})
+		orangesBundle := fakeRegistry.WithBundleFromPath("oranges/bundle", "test_assets/bundle").WithImageRefs([]lockconfig.ImageRef{
+			{Image: fakeRegistry.WithRandomImage("library/oranges").RefDigest},
+		})
+
+		bundleWithMultipleBundles := fakeRegistry.WithBundleFromPath("repo/bundle_with_multiple_bundle", "test_assets/bundle_with_mult_images").WithImageRefs([]lockconfig.ImageRef{
+			{Image: applesBundle.RefDigest},
+			{Image: icecreamBundle.RefDigest},
+			{Image: orangesBundle.RefDigest},
+		})
+
+		subject := bundle.NewBundle(bundleWithMultipleBundles.RefDigest, fakeRegistry.Build())
+		outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+		assert.NoError(t, err)
+		defer os.Remove(outputPath)
+
+		err = subject.Pull(outputPath, writerUI, pullNestedBundles, 3)
+		assert.NoError(t, err)
+
+		assert.Regexp(t,
+			fmt.Sprintf(`Pulling bundle '%s'
+  Extracting layer 'sha256:.*' \(1/1\)
+
+Nested bundles
+  Pulling nested bundle '%s'
+    Extracting layer 'sha256:.*' \(1/1\)
+  Pulling nested bundle '%s'
+    Extracting layer 'sha256:.*' \(1/1\)
+  Pulling nested bundle '%s'
+    Extracting layer 'sha256:.*' \(1/1\)
+
+Locating image lock file images...
+One or more images not found in bundle repo; skipping lock file update`, bundleWithMultipleBundles.RefDigest,
+				applesBundle.RefDigest,
+				icecreamBundle.RefDigest,
+				orangesBundle.RefDigest), output.String())
+
+		for _, nestedBundle := range []string{applesBundle.RefDigest, icecreamBundle.RefDigest, orangesBundle.RefDigest} {
+			digest, err := name.NewDigest(nestedBundle)
+			assert.NoError(t, err)
+			assert.DirExists(t, filepath.Join(outputPath, ".imgpkg", "bundles", strings.ReplaceAll(digest.DigestStr(), "sha256:", "sha256-")))
+		}
+	})
 }
 
 func TestNoteCopy(t *testing.T) {
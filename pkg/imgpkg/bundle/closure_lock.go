@@ -0,0 +1,114 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+)
+
+// ClosureLock Builds a BundleClosureLock with the bundle and every nested bundle and image it references
+func (o *Bundle) ClosureLock(concurrency int, ui util.UIWithLevels) (lockconfig.BundleClosureLock, error) {
+	bundles, imageRefs, err := o.AllImagesRefs(concurrency, ui)
+	if err != nil {
+		return lockconfig.BundleClosureLock{}, err
+	}
+
+	lock := lockconfig.NewEmptyBundleClosureLock()
+	lock.Bundle = lockconfig.BundleRef{Image: o.DigestRef(), Tag: o.Tag()}
+
+	processedBundles := map[string]struct{}{}
+	for _, bundle := range bundles {
+		if _, found := processedBundles[bundle.DigestRef()]; found {
+			continue
+		}
+		processedBundles[bundle.DigestRef()] = struct{}{}
+
+		closureBundle := lockconfig.ClosureBundleRef{Image: bundle.DigestRef()}
+		for _, ref := range bundle.allCachedImageRefs() {
+			closureBundle.Images = append(closureBundle.Images, ref.Image)
+		}
+		sort.Strings(closureBundle.Images)
+
+		lock.Bundles = append(lock.Bundles, closureBundle)
+	}
+
+	for _, ref := range imageRefs.ImageRefs() {
+		if ref.IsBundle != nil && *ref.IsBundle {
+			continue
+		}
+		lock.Images = append(lock.Images, lockconfig.ClosureImageRef{Image: ref.PrimaryLocation()})
+	}
+
+	// The root bundle is always the first one, nested bundles are found concurrently
+	// so they are sorted to keep the lock stable
+	sort.Slice(lock.Bundles[1:], func(i, j int) bool { return lock.Bundles[i+1].Image < lock.Bundles[j+1].Image })
+	sort.Slice(lock.Images, func(i, j int) bool { return lock.Images[i].Image < lock.Images[j].Image })
+
+	return lock, nil
+}
+
+// NewBundlesFromClosureLock Creates every bundle of the closure, with the images they contain already known,
+// so that the images locks of the nested bundles do not need to be read. The root bundle is the first one
+func NewBundlesFromClosureLock(lock lockconfig.BundleClosureLock, imagesMetadata ImagesMetadata) ([]*Bundle, error) {
+	rootBundle, found := lock.FindBundle(lock.Bundle.Image)
+	if !found {
+		return nil, fmt.Errorf("Expected bundle '%s' to be part of the bundles of the bundle closure lock", lock.Bundle.Image)
+	}
+
+	closureBundles := []lockconfig.ClosureBundleRef{rootBundle}
+	for _, closureBundle := range lock.Bundles {
+		if closureBundle.Image != rootBundle.Image {
+			closureBundles = append(closureBundles, closureBundle)
+		}
+	}
+
+	var bundles []*Bundle
+	for _, closureBundle := range closureBundles {
+		bundle, err := newBundleFromClosureBundleRef(closureBundle, lock, imagesMetadata)
+		if err != nil {
+			return nil, err
+		}
+
+		isBundle, err := bundle.IsBundle()
+		if err != nil {
+			return nil, fmt.Errorf("Checking if '%s' is a bundle: %s", closureBundle.Image, err)
+		}
+		if !isBundle {
+			return nil, fmt.Errorf("Expected '%s' of the bundle closure lock to be a bundle", closureBundle.Image)
+		}
+
+		bundles = append(bundles, bundle)
+	}
+	return bundles, nil
+}
+
+// NewBundleFromClosureLock Creates the root bundle of the closure, nested bundles will be pulled from
+// the locations recorded in the lock
+func NewBundleFromClosureLock(lock lockconfig.BundleClosureLock, imagesMetadata ImagesMetadata) *Bundle {
+	bundle := NewBundle(lock.Bundle.Image, imagesMetadata)
+	bundle.closureLock = &lock
+	return bundle
+}
+
+func newBundleFromClosureBundleRef(closureBundle lockconfig.ClosureBundleRef, lock lockconfig.BundleClosureLock, imagesMetadata ImagesMetadata) (*Bundle, error) {
+	bundle := NewBundle(closureBundle.Image, imagesMetadata)
+	bundle.closureLock = &lock
+	bundle.cachedImageRefs = map[string]ImageRef{}
+
+	for _, ref := range closureBundle.Images {
+		location, isBundle, found := lock.Find(ref)
+		if !found {
+			return nil, fmt.Errorf("Expected image '%s' of bundle '%s' to be part of the bundles or images of the bundle closure lock", ref, closureBundle.Image)
+		}
+
+		imgRef := NewImageRef(lockconfig.ImageRef{Image: ref}, isBundle)
+		imgRef.AddLocation(location)
+		bundle.updateCachedImageRef(imgRef)
+	}
+	return bundle, nil
+}
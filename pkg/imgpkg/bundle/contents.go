@@ -12,6 +12,7 @@ import (
 	"github.com/cppforlife/go-cli-ui/ui"
 	regname "github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
 )
 
@@ -24,6 +25,8 @@ const (
 type Contents struct {
 	paths         []string
 	excludedPaths []string
+	signer        plainimage.ImageSigner
+	replacedFiles map[string][]byte
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . ImagesMetadataWriter
@@ -36,6 +39,48 @@ func NewContents(paths []string, excludedPaths []string) Contents {
 	return Contents{paths: paths, excludedPaths: excludedPaths}
 }
 
+// ResolveTags Returns Contents that push the images lock with the images referenced by tag resolved to digests.
+// The images lock in the bundle directory is only updated when writeBack is true
+func (b Contents) ResolveTags(resolver TagResolver, writeBack bool) (Contents, error) {
+	err := b.validate()
+	if err != nil {
+		return Contents{}, err
+	}
+
+	imgpkgDirs, err := b.findImgpkgDirs()
+	if err != nil {
+		return Contents{}, err
+	}
+	imagesLockPath := filepath.Join(imgpkgDirs[0], ImagesLockFile)
+
+	imagesLock, err := lockconfig.NewImagesLockWithTagsFromPath(imagesLockPath)
+	if err != nil {
+		return Contents{}, err
+	}
+
+	resolvedLock, err := resolver.Resolve(imagesLock)
+	if err != nil {
+		return Contents{}, err
+	}
+
+	if writeBack {
+		return b, resolvedLock.WriteToPath(imagesLockPath)
+	}
+
+	bs, err := resolvedLock.AsBytes()
+	if err != nil {
+		return Contents{}, err
+	}
+	b.replacedFiles = map[string][]byte{imagesLockPath: bs}
+	return b, nil
+}
+
+// WithSigner Returns Contents that sign the bundle before it is tagged
+func (b Contents) WithSigner(signer plainimage.ImageSigner) Contents {
+	b.signer = signer
+	return b
+}
+
 func (b Contents) Push(uploadRef regname.Tag, registry ImagesMetadataWriter, ui ui.UI) (string, error) {
 	err := b.validate()
 	if err != nil {
@@ -43,7 +88,7 @@ func (b Contents) Push(uploadRef regname.Tag, registry ImagesMetadataWriter, ui
 	}
 
 	labels := map[string]string{BundleConfigLabel: "true"}
-	return plainimage.NewContents(b.paths, b.excludedPaths).Push(uploadRef, labels, registry, ui)
+	return plainimage.NewContents(b.paths, b.excludedPaths).WithSigner(b.signer).WithReplacedFiles(b.replacedFiles).Push(uploadRef, labels, registry, ui)
 }
 
 func (b Contents) PresentsAsBundle() (bool, error) {
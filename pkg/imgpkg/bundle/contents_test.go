@@ -4,6 +4,7 @@
 package bundle_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
@@ -85,3 +86,81 @@ images:
 		}
 	})
 }
+
+type fakeSigner struct {
+	registry  *bundlefakes.FakeImagesMetadataWriter
+	signed    []name.Digest
+	tagWrites int
+}
+
+func (s *fakeSigner) Sign(imageRef name.Digest) (name.Digest, error) {
+	s.signed = append(s.signed, imageRef)
+	for i := 0; i < s.registry.WriteImageCallCount(); i++ {
+		if ref, _ := s.registry.WriteImageArgsForCall(i); ref.Identifier() == "tag" {
+			s.tagWrites++
+		}
+	}
+	return imageRef.Context().Digest("sha256:0000000000000000000000000000000000000000000000000000000000000000"), nil
+}
+
+func TestNewContentsBundleWithSigner(t *testing.T) {
+	fakeUI := &bundlefakes.FakeUI{}
+	fakeRegistry := &bundlefakes.FakeImagesMetadataWriter{}
+	assets := &helpers.Assets{T: t}
+	defer assets.CleanCreatedFolders()
+	bundleBuilder := helpers.NewBundleDir(t, assets)
+	bundleDir := bundleBuilder.CreateBundleDir(helpers.BundleYAML, helpers.ImagesYAML)
+
+	t.Run("signs the bundle pushed by digest before writing the tag", func(t *testing.T) {
+		signer := &fakeSigner{registry: fakeRegistry}
+		subject := bundle.NewContents([]string{bundleDir}, nil).WithSigner(signer)
+		imgTag, err := name.NewTag("my.registry.io/new-bundle:tag")
+		if err != nil {
+			t.Fatalf("failed to read tag: %s", err)
+		}
+
+		imageURL, err := subject.Push(imgTag, fakeRegistry, fakeUI)
+		if err != nil {
+			t.Fatalf("not expecting push to fail: %s", err)
+		}
+
+		if len(signer.signed) != 1 || signer.signed[0].Name() != imageURL {
+			t.Fatalf("expected '%s' to be signed, got: %v", imageURL, signer.signed)
+		}
+		if signer.tagWrites != 0 {
+			t.Fatalf("expected the tag to be written after signing")
+		}
+		if fakeRegistry.WriteImageCallCount() != 2 {
+			t.Fatalf("expected the bundle to be written by digest and by tag, got %d writes", fakeRegistry.WriteImageCallCount())
+		}
+		tagRef, _ := fakeRegistry.WriteImageArgsForCall(1)
+		if tagRef.Name() != imgTag.Name() {
+			t.Fatalf("expected last write to be '%s', got '%s'", imgTag.Name(), tagRef.Name())
+		}
+	})
+
+	t.Run("when signing fails it does not write the tag", func(t *testing.T) {
+		fakeRegistry := &bundlefakes.FakeImagesMetadataWriter{}
+		subject := bundle.NewContents([]string{bundleDir}, nil).WithSigner(failingSigner{})
+		imgTag, err := name.NewTag("my.registry.io/new-bundle:tag")
+		if err != nil {
+			t.Fatalf("failed to read tag: %s", err)
+		}
+
+		_, err = subject.Push(imgTag, fakeRegistry, fakeUI)
+		if err == nil {
+			t.Fatalf("expected push to fail")
+		}
+		for i := 0; i < fakeRegistry.WriteImageCallCount(); i++ {
+			if ref, _ := fakeRegistry.WriteImageArgsForCall(i); ref.Name() == imgTag.Name() {
+				t.Fatalf("expected tag not to be written")
+			}
+		}
+	})
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Sign(name.Digest) (name.Digest, error) {
+	return name.Digest{}, fmt.Errorf("signing failed")
+}
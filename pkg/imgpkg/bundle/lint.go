@@ -0,0 +1,382 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	plainimg "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+)
+
+// Linter Validates a bundle, and every image and bundle it references, without copying them
+type Linter struct {
+	imgRetriever ImagesMetadata
+	// maxClosureSize is the maximum number of bytes of the images in the closure of the bundle, 0 means no limit
+	maxClosureSize int64
+	ui             util.UIWithLevels
+}
+
+// NewLinter Builds a Linter, maxClosureSize is the maximum number of bytes of the closure of the bundle, 0 means no limit
+func NewLinter(imgRetriever ImagesMetadata, maxClosureSize int64, ui util.UIWithLevels) Linter {
+	return Linter{imgRetriever: imgRetriever, maxClosureSize: maxClosureSize, ui: ui}
+}
+
+// LintContents Returns the problems found in a bundle directory, and in the images it references.
+// The size of the bundle directory is not part of the closure size
+func (l Linter) LintContents(contents Contents) []string {
+	run := newLintRun(l)
+
+	err := contents.validate()
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	err = plainimg.NewContents(contents.paths, contents.excludedPaths).Validate()
+	if err != nil {
+		run.addProblem("%s", err)
+	}
+
+	imgpkgDirs, err := contents.findImgpkgDirs()
+	if err != nil {
+		return append(run.problems, err.Error())
+	}
+	imagesLockPath := filepath.Join(imgpkgDirs[0], ImagesLockFile)
+
+	imagesLock, err := lockconfig.NewImagesLockFromPath(imagesLockPath)
+	if err != nil {
+		return append(run.problems, fmt.Sprintf("Reading '%s': %s", imagesLockPath, err))
+	}
+
+	run.lintImages(imagesLockPath, imagesLock, "", nil, nil)
+	run.checkClosureSize()
+	return run.problems
+}
+
+// LintBundle Returns the problems found in a pushed bundle, and in the images it references
+func (l Linter) LintBundle(bundleRef string) ([]string, error) {
+	ref, err := regname.ParseReference(bundleRef, regname.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := l.imgRetriever.Get(ref)
+	if err != nil {
+		return nil, fmt.Errorf("Fetching bundle '%s': %s", bundleRef, err)
+	}
+	bundleDigestRef := ref.Context().Digest(desc.Digest.String())
+
+	isBundle, err := l.isBundle(desc)
+	if err != nil {
+		return nil, fmt.Errorf("Checking if '%s' is a bundle: %s", bundleRef, err)
+	}
+	if !isBundle {
+		return nil, notABundleError{}
+	}
+
+	run := newLintRun(l)
+	run.addSize(desc)
+	run.lintBundle(bundleDigestRef, desc, nil)
+	run.checkClosureSize()
+	return run.problems, nil
+}
+
+func (l Linter) isBundle(desc *regremote.Descriptor) (bool, error) {
+	if !desc.MediaType.IsImage() {
+		return false, nil
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return false, err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return false, err
+	}
+	_, present := cfg.Config.Labels[BundleConfigLabel]
+	return present, nil
+}
+
+// lintRun keeps track of the problems found while linting a bundle
+type lintRun struct {
+	linter   Linter
+	problems []string
+
+	lintedBundles map[string]struct{}
+	sizedDigests  map[regv1.Hash]struct{}
+	closureSize   int64
+}
+
+func newLintRun(linter Linter) *lintRun {
+	return &lintRun{
+		linter:        linter,
+		lintedBundles: map[string]struct{}{},
+		sizedDigests:  map[regv1.Hash]struct{}{},
+	}
+}
+
+func (r *lintRun) addProblem(msg string, args ...interface{}) {
+	r.problems = append(r.problems, fmt.Sprintf(msg, args...))
+}
+
+// lintBundle lints a bundle that was found in the registry. bundleChain contains the bundles
+// that reference it, starting with the root bundle, and is used to find reference cycles
+func (r *lintRun) lintBundle(bundleDigestRef regname.Digest, desc *regremote.Descriptor, bundleChain []string) {
+	r.lintedBundles[bundleDigestRef.DigestStr()] = struct{}{}
+
+	img, err := desc.Image()
+	if err != nil {
+		r.addProblem("Bundle '%s': %s", bundleDigestRef.Name(), err)
+		return
+	}
+
+	duplicatePaths, err := duplicateLayerPaths(img)
+	if err != nil {
+		r.addProblem("Bundle '%s': Reading layers: %s", bundleDigestRef.Name(), err)
+	}
+	if len(duplicatePaths) > 0 {
+		r.addProblem("Bundle '%s': Found duplicate paths: %s", bundleDigestRef.Name(), strings.Join(duplicatePaths, ", "))
+	}
+
+	imagesLock, err := (&singleLayerReader{}).Read(img)
+	if err != nil {
+		r.addProblem("Bundle '%s': Reading ImagesLock file: %s", bundleDigestRef.Name(), err)
+		return
+	}
+
+	locationsConfig, err := NewLocations(r.linter.ui).Fetch(r.linter.imgRetriever, bundleDigestRef)
+	if err != nil {
+		if _, ok := err.(*LocationsNotFound); !ok {
+			r.addProblem("Bundle '%s': %s", bundleDigestRef.Name(), err)
+		}
+		locationsConfig = ImageLocationsConfig{}
+	}
+
+	bundleChain = append(append([]string{}, bundleChain...), bundleDigestRef.Name())
+	r.lintImages(bundleDigestRef.Name(), imagesLock, bundleDigestRef.Context().Name(), locationsConfig.Images, bundleChain)
+}
+
+// lintImages checks the images of an images lock. Images are looked up in the bundle repository first,
+// when provided, and then in their original location
+func (r *lintRun) lintImages(lockSource string, imagesLock lockconfig.ImagesLock, bundleRepo string, imageLocations []ImageLocation, bundleChain []string) {
+	recordedAsBundle := map[string]bool{}
+	for _, location := range imageLocations {
+		recordedAsBundle[location.Image] = location.IsBundle
+	}
+
+	for _, imageRef := range imagesLock.Images {
+		digestRef, err := regname.NewDigest(imageRef.Image)
+		if err != nil {
+			r.addProblem("Image '%s' in '%s': %s", imageRef.Image, lockSource, err)
+			continue
+		}
+
+		locations := []string{imageRef.Image}
+		if len(bundleRepo) > 0 {
+			locations = append([]string{bundleRepo + "@" + digestRef.DigestStr()}, locations...)
+		}
+
+		imageURL, err := r.linter.imgRetriever.FirstImageExists(locations)
+		if err != nil {
+			r.addProblem("Image '%s' in '%s' was not found: %s", imageRef.Image, lockSource, err)
+			continue
+		}
+		imageURLRef, err := regname.NewDigest(imageURL)
+		if err != nil {
+			r.addProblem("Image '%s' in '%s': %s", imageRef.Image, lockSource, err)
+			continue
+		}
+
+		desc, err := r.linter.imgRetriever.Get(imageURLRef)
+		if err != nil {
+			r.addProblem("Image '%s' in '%s' could not be fetched: %s", imageRef.Image, lockSource, err)
+			continue
+		}
+		if desc.Digest.String() != digestRef.DigestStr() {
+			r.addProblem("Image '%s' in '%s' does not match its digest, found '%s'", imageRef.Image, lockSource, desc.Digest)
+			continue
+		}
+		r.addSize(desc)
+
+		isBundle, err := r.linter.isBundle(desc)
+		if err != nil {
+			r.addProblem("Checking if image '%s' in '%s' is a bundle: %s", imageRef.Image, lockSource, err)
+			continue
+		}
+		if expected, found := recordedAsBundle[imageRef.Image]; found && expected != isBundle {
+			if expected {
+				r.addProblem("Image '%s' in '%s' is recorded as a bundle but it is not a bundle", imageRef.Image, lockSource)
+			} else {
+				r.addProblem("Image '%s' in '%s' is a bundle but it is not recorded as a bundle", imageRef.Image, lockSource)
+			}
+		}
+		if !isBundle {
+			continue
+		}
+
+		nestedDigestRef := imageURLRef.Context().Digest(digestRef.DigestStr())
+		for i, chainBundle := range bundleChain {
+			chainDigest, err := regname.NewDigest(chainBundle)
+			if err == nil && chainDigest.DigestStr() == digestRef.DigestStr() {
+				cycle := append(append([]string{}, bundleChain[i:]...), nestedDigestRef.Name())
+				r.addProblem("%s", BundleCycleError{Chain: cycle})
+				isBundle = false
+				break
+			}
+		}
+		if !isBundle {
+			continue
+		}
+
+		if _, linted := r.lintedBundles[digestRef.DigestStr()]; linted {
+			continue
+		}
+		r.lintBundle(nestedDigestRef, desc, bundleChain)
+	}
+}
+
+// addSize adds the size of the image, or of every image of the index, to the closure size.
+// Blobs shared between images are only counted once
+func (r *lintRun) addSize(desc *regremote.Descriptor) {
+	if r.linter.maxClosureSize <= 0 {
+		return
+	}
+
+	var err error
+	if desc.MediaType.IsIndex() {
+		var idx regv1.ImageIndex
+		idx, err = desc.ImageIndex()
+		if err == nil {
+			err = r.addIndexSize(desc.Digest, desc.Size, idx)
+		}
+	} else {
+		var img regv1.Image
+		img, err = desc.Image()
+		if err == nil {
+			err = r.addImageSize(desc.Digest, desc.Size, img)
+		}
+	}
+	if err != nil {
+		r.addProblem("Calculating size of '%s': %s", desc.Ref.Name(), err)
+	}
+}
+
+func (r *lintRun) addIndexSize(digest regv1.Hash, size int64, idx regv1.ImageIndex) error {
+	if !r.addBlobSize(digest, size) {
+		return nil
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, child := range manifest.Manifests {
+		switch {
+		case child.MediaType.IsIndex():
+			childIdx, err := idx.ImageIndex(child.Digest)
+			if err != nil {
+				return err
+			}
+			err = r.addIndexSize(child.Digest, child.Size, childIdx)
+			if err != nil {
+				return err
+			}
+		case child.MediaType.IsImage():
+			childImg, err := idx.Image(child.Digest)
+			if err != nil {
+				return err
+			}
+			err = r.addImageSize(child.Digest, child.Size, childImg)
+			if err != nil {
+				return err
+			}
+		default:
+			r.addBlobSize(child.Digest, child.Size)
+		}
+	}
+	return nil
+}
+
+func (r *lintRun) addImageSize(digest regv1.Hash, size int64, img regv1.Image) error {
+	if !r.addBlobSize(digest, size) {
+		return nil
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	r.addBlobSize(manifest.Config.Digest, manifest.Config.Size)
+	for _, layer := range manifest.Layers {
+		r.addBlobSize(layer.Digest, layer.Size)
+	}
+	return nil
+}
+
+// addBlobSize returns false when the blob was already counted
+func (r *lintRun) addBlobSize(digest regv1.Hash, size int64) bool {
+	if _, found := r.sizedDigests[digest]; found {
+		return false
+	}
+	r.sizedDigests[digest] = struct{}{}
+	r.closureSize += size
+	return true
+}
+
+func (r *lintRun) checkClosureSize() {
+	if r.linter.maxClosureSize > 0 && r.closureSize > r.linter.maxClosureSize {
+		r.addProblem("Expected closure size to be at most %d bytes, was %d bytes", r.linter.maxClosureSize, r.closureSize)
+	}
+}
+
+// duplicateLayerPaths returns the paths that are present more than once in the layers of the image
+func duplicateLayerPaths(img regv1.Image) ([]string, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]int{}
+	for _, layer := range layers {
+		reader, err := layer.Uncompressed()
+		if err != nil {
+			return nil, err
+		}
+
+		tarReader := tar.NewReader(reader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				reader.Close()
+				return nil, fmt.Errorf("Reading tar: %s", err)
+			}
+			seen[filepath.Clean(header.Name)]++
+		}
+		reader.Close()
+	}
+
+	var duplicates []string
+	for path, count := range seen {
+		if count > 1 {
+			duplicates = append(duplicates, path)
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates, nil
+}
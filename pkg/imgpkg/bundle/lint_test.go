@@ -0,0 +1,114 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestLinter(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	app := fakeRegistry.WithRandomImage("library/app:v1")
+	index := fakeRegistry.WithARandomImageIndex("library/index:v1", 2)
+	reg := fakeRegistry.Build()
+	missingImage := fakeRegistry.ReferenceOnTestServer("library/missing@sha256:" + strings.Repeat("a", 64))
+	ui := util.NewUILevelLogger(util.LogWarn, &bundlefakes.FakeUI{})
+
+	bundleDir := func(t *testing.T, images ...string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(dir, ".imgpkg"), 0700))
+		imagesYAML := "apiVersion: imgpkg.carvel.dev/v1alpha1\nkind: ImagesLock\nimages:\n"
+		for _, image := range images {
+			imagesYAML += "- image: " + image + "\n"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".imgpkg", "images.yml"), []byte(imagesYAML), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte("config"), 0600))
+		return dir
+	}
+	pushBundle := func(t *testing.T, name string, images ...string) string {
+		uploadRef, err := regname.NewTag(fakeRegistry.ReferenceOnTestServer(name))
+		require.NoError(t, err)
+		bundleRef, err := bundle.NewContents([]string{bundleDir(t, images...)}, nil).Push(uploadRef, reg, &bundlefakes.FakeUI{})
+		require.NoError(t, err)
+		return bundleRef
+	}
+
+	nestedBundle := pushBundle(t, "library/nested", app.RefDigest)
+
+	t.Run("when the bundle and its nested bundles are valid it returns no problems", func(t *testing.T) {
+		rootBundle := pushBundle(t, "library/root", nestedBundle, index.RefDigest)
+
+		problems, err := bundle.NewLinter(reg, 0, ui).LintBundle(rootBundle)
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+
+		problems = bundle.NewLinter(reg, 0, ui).LintContents(bundle.NewContents([]string{bundleDir(t, nestedBundle, index.RefDigest)}, nil))
+		assert.Empty(t, problems)
+	})
+
+	t.Run("when images are missing it returns a problem for each of them", func(t *testing.T) {
+		brokenNestedBundle := pushBundle(t, "library/broken-nested", missingImage)
+		rootBundle := pushBundle(t, "library/root", brokenNestedBundle, app.RefDigest)
+
+		problems, err := bundle.NewLinter(reg, 0, ui).LintBundle(rootBundle)
+		require.NoError(t, err)
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0], "Image '"+missingImage+"' in '"+brokenNestedBundle+"' was not found")
+	})
+
+	t.Run("when the closure is bigger than the maximum size it returns a problem", func(t *testing.T) {
+		problems := bundle.NewLinter(reg, 1024, ui).LintContents(bundle.NewContents([]string{bundleDir(t, nestedBundle, index.RefDigest)}, nil))
+		require.Len(t, problems, 1)
+		assert.Regexp(t, `^Expected closure size to be at most 1024 bytes, was \d+ bytes$`, problems[0])
+	})
+
+	t.Run("when the locations of a bundle record an image as a bundle that is not a bundle it returns a problem", func(t *testing.T) {
+		rootBundle := pushBundle(t, "library/relocated", app.RefDigest)
+		rootDigest, err := regname.NewDigest(rootBundle)
+		require.NoError(t, err)
+		err = bundle.NewLocations(ui).Save(reg, rootDigest, bundle.ImageLocationsConfig{
+			APIVersion: bundle.LocationAPIVersion,
+			Kind:       bundle.ImageLocationsKind,
+			Images:     []bundle.ImageLocation{{Image: app.RefDigest, IsBundle: true}},
+		}, &bundlefakes.FakeUI{})
+		require.NoError(t, err)
+
+		problems, err := bundle.NewLinter(reg, 0, ui).LintBundle(rootBundle)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Image '" + app.RefDigest + "' in '" + rootBundle + "' is recorded as a bundle but it is not a bundle"}, problems)
+	})
+
+	t.Run("when files of the bundle directory end up in the same path it returns a problem", func(t *testing.T) {
+		otherDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(otherDir, "config.yml"), []byte("other config"), 0600))
+
+		problems := bundle.NewLinter(reg, 0, ui).LintContents(bundle.NewContents([]string{bundleDir(t, app.RefDigest), otherDir}, nil))
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0], "Found duplicate paths")
+	})
+
+	t.Run("when the images lock of the bundle directory is not valid it returns a problem", func(t *testing.T) {
+		problems := bundle.NewLinter(reg, 0, ui).LintContents(bundle.NewContents([]string{bundleDir(t, "library/app:v1")}, nil))
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0], "Expected ref to be in digest form")
+	})
+
+	t.Run("when the pushed image is not a bundle it errors", func(t *testing.T) {
+		_, err := bundle.NewLinter(reg, 0, ui).LintBundle(app.RefDigest)
+		require.Error(t, err)
+		assert.True(t, bundle.IsNotBundleError(err))
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"sync"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+)
+
+// pullProgress writes the buffered output of each pulled bundle as soon as the bundle, and every bundle
+// that comes before it in the order of the images lock files, is pulled
+type pullProgress struct {
+	ui            goui.UI
+	root          *pulledBundle
+	bundlesToPull map[string]*pulledBundle
+	// bundles contains every bundle to pull, in the order they were added
+	bundles       []*pulledBundle
+	printedEvents int
+
+	lock sync.Mutex
+}
+
+func newPullProgress(ui goui.UI, root *pulledBundle) *pullProgress {
+	return &pullProgress{ui: ui, root: root, bundlesToPull: map[string]*pulledBundle{}, bundles: []*pulledBundle{root}}
+}
+
+// Add records the nested bundle that will be pulled for the image ref
+func (p *pullProgress) Add(imageRef string, pulled *pulledBundle) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bundlesToPull[imageRef] = pulled
+	p.bundles = append(p.bundles, pulled)
+}
+
+// Contains checks if a nested bundle was already recorded for the image ref
+func (p *pullProgress) Contains(imageRef string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	_, found := p.bundlesToPull[imageRef]
+	return found
+}
+
+// Done marks the bundle as pulled and writes all the output that can be written
+func (p *pullProgress) Done(pulled *pulledBundle) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pulled.done = true
+	p.flush()
+}
+
+// FlushOnError writes the output that can be written in order, followed by the output of
+// the bundles that were not printed yet, so that no output is lost when the pull fails
+func (p *pullProgress) FlushOnError() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.flush()
+
+	for _, pulled := range p.bundles {
+		if pulled.printed || pulled.output == nil {
+			continue
+		}
+		pulled.printed = true
+
+		ui := p.ui
+		for i := 0; i < pulled.depth; i++ {
+			ui = goui.NewIndentingUI(ui)
+		}
+
+		if pulled == p.root {
+			ui.BeginLinef("Pulling bundle '%s'\n", pulled.bundle.DigestRef())
+		} else {
+			ui.BeginLinef("Pulling nested bundle '%s'\n", pulled.bundle.DigestRef())
+		}
+		pulled.output.Replay(goui.NewIndentingUI(ui))
+	}
+}
+
+// flush writes the output that was not written yet. The whole output is computed every time,
+// up until the first bundle that was not pulled yet, since it is deterministic
+func (p *pullProgress) flush() {
+	var events []func()
+	p.outputEvents(p.root, p.ui, map[string]struct{}{}, &events)
+
+	for _, event := range events[p.printedEvents:] {
+		event()
+	}
+	p.printedEvents = len(events)
+}
+
+// outputEvents returns false when it reaches a bundle that was not pulled yet
+func (p *pullProgress) outputEvents(pulled *pulledBundle, ui goui.UI, printedBundles map[string]struct{}, events *[]func()) bool {
+	if pulled == nil || !pulled.done {
+		return false
+	}
+
+	isRoot := pulled == p.root
+	*events = append(*events, func() {
+		if isRoot {
+			ui.BeginLinef("Pulling bundle '%s'\n", pulled.bundle.DigestRef())
+		} else {
+			ui.BeginLinef("Pulling nested bundle '%s'\n", pulled.bundle.DigestRef())
+		}
+		pulled.output.Replay(goui.NewIndentingUI(ui))
+		pulled.printed = true
+	})
+
+	for i, bundleImgRef := range pulled.nestedBundles {
+		if isRoot && i == 0 {
+			*events = append(*events, func() { ui.BeginLinef("\nNested bundles\n") })
+		}
+
+		imageRef := bundleImgRef.Image
+		if _, alreadyPrinted := printedBundles[imageRef]; alreadyPrinted {
+			*events = append(*events, func() {
+				goui.NewIndentingUI(ui).BeginLinef("Pulling nested bundle '%s'\n", imageRef)
+				goui.NewIndentingUI(ui).BeginLinef("Skipped, already downloaded\n")
+			})
+			continue
+		}
+		printedBundles[imageRef] = struct{}{}
+
+		if !p.outputEvents(p.bundlesToPull[imageRef], goui.NewIndentingUI(ui), printedBundles, events) {
+			return false
+		}
+	}
+	return true
+}
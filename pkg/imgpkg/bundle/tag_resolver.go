@@ -0,0 +1,219 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"golang.org/x/mod/semver"
+)
+
+// OriginalTagAnnotation Annotation that records the tag, or semver constraint, an image was referenced by
+// before it was resolved to a digest
+const OriginalTagAnnotation = "imgpkg.carvel.dev/original-tag"
+
+// TagResolverRegistry Interface to resolve tags to digests
+type TagResolverRegistry interface {
+	Digest(regname.Reference) (regv1.Hash, error)
+	ListTags(regname.Repository) ([]string, error)
+}
+
+// TagResolver Resolves the images of an images lock referenced by tag or by semver constraint to digests
+type TagResolver struct {
+	registry TagResolverRegistry
+	ui       ui.UI
+}
+
+// NewTagResolver Builds a TagResolver that uses the registry to resolve the tags
+func NewTagResolver(registry TagResolverRegistry, ui ui.UI) TagResolver {
+	return TagResolver{registry: registry, ui: ui}
+}
+
+// Resolve Returns the images lock with every image referenced by digest. Images that were referenced by tag,
+// or by semver constraint (example: nginx:~1.25), record it in the OriginalTagAnnotation annotation
+func (t TagResolver) Resolve(lock lockconfig.ImagesLock) (lockconfig.ImagesLock, error) {
+	resolved := lock
+	resolved.Images = nil
+
+	for _, imageRef := range lock.Images {
+		if _, err := regname.NewDigest(imageRef.Image); err == nil {
+			resolved.Images = append(resolved.Images, imageRef)
+			continue
+		}
+
+		repo, tag, err := t.resolveTag(imageRef.Image)
+		if err != nil {
+			return lockconfig.ImagesLock{}, fmt.Errorf("Resolving image '%s': %s", imageRef.Image, err)
+		}
+
+		digest, err := t.registry.Digest(repo.Tag(tag))
+		if err != nil {
+			return lockconfig.ImagesLock{}, fmt.Errorf("Resolving image '%s': %s", imageRef.Image, err)
+		}
+
+		resolvedRef := imageRef.DeepCopy()
+		resolvedRef.Image = repo.Digest(digest.String()).Name()
+		resolvedRef.Annotations[OriginalTagAnnotation] = tagOf(imageRef.Image)
+		resolved.Images = append(resolved.Images, resolvedRef)
+
+		t.ui.BeginLinef("Resolved image '%s' to '%s'\n", imageRef.Image, resolvedRef.Image)
+	}
+
+	return resolved, resolved.Validate()
+}
+
+// resolveTag returns the repository of the image and the tag it refers to,
+// picking the highest tag that satisfies the semver constraint when a constraint is used
+func (t TagResolver) resolveTag(image string) (regname.Repository, string, error) {
+	tag := tagOf(image)
+	if !strings.ContainsAny(tag, semverConstraintOperators) {
+		tagRef, err := regname.NewTag(image, regname.WeakValidation)
+		if err != nil {
+			return regname.Repository{}, "", err
+		}
+		return tagRef.Context(), tagRef.TagStr(), nil
+	}
+
+	repo, err := regname.NewRepository(strings.TrimSuffix(image, ":"+tag), regname.WeakValidation)
+	if err != nil {
+		return regname.Repository{}, "", err
+	}
+
+	constraint, err := newSemverConstraint(tag)
+	if err != nil {
+		return regname.Repository{}, "", fmt.Errorf("Parsing semver constraint '%s': %s", tag, err)
+	}
+
+	tags, err := t.registry.ListTags(repo)
+	if err != nil {
+		return regname.Repository{}, "", fmt.Errorf("Listing tags: %s", err)
+	}
+
+	var highestTag, highestVersion string
+	for _, candidate := range tags {
+		version, ok := semverOfTag(candidate)
+		if !ok || !constraint.matches(version) {
+			continue
+		}
+		if len(highestTag) == 0 || semver.Compare(version, highestVersion) > 0 {
+			highestTag, highestVersion = candidate, version
+		}
+	}
+	if len(highestTag) == 0 {
+		return regname.Repository{}, "", fmt.Errorf("Expected a tag to satisfy semver constraint '%s'", tag)
+	}
+
+	return repo, highestTag, nil
+}
+
+// tagOf returns the tag part of the image reference, or latest when it has none
+func tagOf(image string) string {
+	lastSlash := strings.LastIndex(image, "/")
+	lastColon := strings.LastIndex(image, ":")
+	if lastColon <= lastSlash {
+		return regname.DefaultTag
+	}
+	return image[lastColon+1:]
+}
+
+const semverConstraintOperators = "~^<>=, "
+
+// semverConstraint Comparisons that a version needs to satisfy, all of them need to match
+type semverConstraint []semverComparison
+
+type semverComparison struct {
+	operator string
+	version  string
+}
+
+// newSemverConstraint parses space or comma separated comparisons (examples: ~1.25, ^2.1.0, >=1.2 <1.5)
+func newSemverConstraint(constraint string) (semverConstraint, error) {
+	var result semverConstraint
+
+	for _, term := range strings.FieldsFunc(constraint, func(r rune) bool { return r == ' ' || r == ',' }) {
+		operator := strings.TrimRight(term, "0123456789.v")
+		if strings.ContainsAny(operator, "0123456789") || len(strings.TrimLeft(operator, "~^<>=")) > 0 {
+			return nil, fmt.Errorf("Expected version of '%s' to be in the form major.minor.patch", term)
+		}
+		version := strings.TrimPrefix(term[len(operator):], "v")
+
+		parts := strings.Split(version, ".")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("Expected version of '%s' to be in the form major.minor.patch", term)
+		}
+		numbers := make([]int, 3)
+		for i, part := range parts {
+			number, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("Expected version of '%s' to be in the form major.minor.patch", term)
+			}
+			numbers[i] = number
+		}
+		lower := fmt.Sprintf("v%d.%d.%d", numbers[0], numbers[1], numbers[2])
+
+		switch operator {
+		case "~":
+			upper := fmt.Sprintf("v%d.%d.0", numbers[0], numbers[1]+1)
+			if len(parts) == 1 {
+				upper = fmt.Sprintf("v%d.0.0", numbers[0]+1)
+			}
+			result = append(result, semverComparison{">=", lower}, semverComparison{"<", upper})
+		case "^":
+			upper := fmt.Sprintf("v%d.0.0", numbers[0]+1)
+			if numbers[0] == 0 && len(parts) > 1 {
+				upper = fmt.Sprintf("v0.%d.0", numbers[1]+1)
+			}
+			result = append(result, semverComparison{">=", lower}, semverComparison{"<", upper})
+		case "", "=":
+			result = append(result, semverComparison{"=", lower})
+		case ">=", ">", "<=", "<":
+			result = append(result, semverComparison{operator, lower})
+		default:
+			return nil, fmt.Errorf("Unknown operator '%s' (known: ~, ^, =, >=, >, <=, <)", operator)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("Expected at least one comparison")
+	}
+	return result, nil
+}
+
+func (c semverConstraint) matches(version string) bool {
+	for _, comparison := range c {
+		cmp := semver.Compare(version, comparison.version)
+		var matches bool
+		switch comparison.operator {
+		case "=":
+			matches = cmp == 0
+		case ">=":
+			matches = cmp >= 0
+		case ">":
+			matches = cmp > 0
+		case "<=":
+			matches = cmp <= 0
+		case "<":
+			matches = cmp < 0
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+// semverOfTag returns the canonical semver of tags that are release versions (examples: 1.25.3, v1.25)
+func semverOfTag(tag string) (string, bool) {
+	version := "v" + strings.TrimPrefix(tag, "v")
+	if !semver.IsValid(version) || len(semver.Prerelease(version)) > 0 || len(semver.Build(version)) > 0 {
+		return "", false
+	}
+	return semver.Canonical(version), true
+}
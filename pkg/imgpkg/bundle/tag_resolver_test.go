@@ -0,0 +1,70 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestTagResolver_Resolve(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{})
+	defer fakeRegistry.CleanUp()
+	images := map[string]*helpers.ImageOrImageIndexWithTarPath{}
+	for _, tag := range []string{"1.24.9", "1.25.1", "v1.25.3", "1.25.4-rc.1", "1.26.0", "stable"} {
+		images[tag] = fakeRegistry.WithRandomImage("library/nginx:" + tag)
+	}
+	digestImage := fakeRegistry.WithRandomImage("library/app")
+	reg := fakeRegistry.Build()
+	nginx := fakeRegistry.ReferenceOnTestServer("library/nginx")
+
+	imagesLock := func(imageRefs ...lockconfig.ImageRef) lockconfig.ImagesLock {
+		lock := lockconfig.NewEmptyImagesLock()
+		lock.Images = imageRefs
+		return lock
+	}
+	subject := bundle.NewTagResolver(reg, &bundlefakes.FakeUI{})
+
+	t.Run("resolves tags and semver constraints and records them in an annotation", func(t *testing.T) {
+		resolved, err := subject.Resolve(imagesLock(
+			lockconfig.ImageRef{Image: nginx + ":stable", Annotations: map[string]string{"team": "a"}},
+			lockconfig.ImageRef{Image: nginx + ":~1.25"},
+			lockconfig.ImageRef{Image: nginx + ":>=1.24 <1.25"},
+			lockconfig.ImageRef{Image: digestImage.RefDigest},
+		))
+		require.NoError(t, err)
+
+		require.Len(t, resolved.Images, 4)
+		assert.Equal(t, images["stable"].RefDigest, resolved.Images[0].Image)
+		assert.Equal(t, map[string]string{"team": "a", bundle.OriginalTagAnnotation: "stable"}, resolved.Images[0].Annotations)
+		assert.Equal(t, images["v1.25.3"].RefDigest, resolved.Images[1].Image)
+		assert.Equal(t, "~1.25", resolved.Images[1].Annotations[bundle.OriginalTagAnnotation])
+		assert.Equal(t, images["1.24.9"].RefDigest, resolved.Images[2].Image)
+		assert.Equal(t, lockconfig.ImageRef{Image: digestImage.RefDigest}, resolved.Images[3])
+	})
+
+	t.Run("caret constraints allow minor and patch updates", func(t *testing.T) {
+		resolved, err := subject.Resolve(imagesLock(lockconfig.ImageRef{Image: nginx + ":^1.24.0"}))
+		require.NoError(t, err)
+		assert.Equal(t, images["1.26.0"].RefDigest, resolved.Images[0].Image)
+	})
+
+	t.Run("when no tag satisfies the constraint it errors", func(t *testing.T) {
+		_, err := subject.Resolve(imagesLock(lockconfig.ImageRef{Image: nginx + ":~2.0"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected a tag to satisfy semver constraint '~2.0'")
+	})
+
+	t.Run("when the constraint is invalid it errors", func(t *testing.T) {
+		_, err := subject.Resolve(imagesLock(lockconfig.ImageRef{Image: nginx + ":~1.x"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Parsing semver constraint '~1.x'")
+	})
+}
@@ -15,6 +15,7 @@ func (b *BundleFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&b.Bundle, "bundle", "b", "", "Set bundle (example: docker.io/dkalinin/test-content)")
 }
 
-func (b *BundleFlags) SetCopy(cmd *cobra.Command) {
-	cmd.Flags().StringVarP(&b.Bundle, "bundle", "b", "", "Bundle reference for copying (happens thickly, i.e. bundle image + all referenced images)")
+// SetCopy Registers the bundle flag of copy, bundles provided after the first one are added to additionalBundles
+func (b *BundleFlags) SetCopy(cmd *cobra.Command, additionalBundles *[]string) {
+	cmd.Flags().VarP(newRepeatableStringValue(&b.Bundle, additionalBundles), "bundle", "b", "Bundle reference for copying (happens thickly, i.e. bundle image + all referenced images) (can be specified multiple times with --to-tar)")
 }
@@ -0,0 +1,87 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+)
+
+type BundleLintOptions struct {
+	ui ui.UI
+
+	BundleFlags   BundleFlags
+	FileFlags     FileFlags
+	RegistryFlags RegistryFlags
+
+	MaxClosureSize SizeValue
+}
+
+func NewBundleLintOptions(ui ui.UI) *BundleLintOptions {
+	return &BundleLintOptions{ui: ui}
+}
+
+func NewBundleLintCmd(o *BundleLintOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Validate a bundle directory or a pushed bundle without copying it",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Lint the bundle directory config/ before pushing it
+  imgpkg bundle lint -f config/
+
+  # Lint a pushed bundle, failing when its images add up to more than 2GiB
+  imgpkg bundle lint -b repo/app1-config --max-closure-size 2GiB`,
+	}
+	o.BundleFlags.Set(cmd)
+	o.FileFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().Var(&o.MaxClosureSize, "max-closure-size", "Maximum size of the images referenced by the bundle and its nested bundles, 0 means unlimited (examples: 2GiB, 500MB)")
+	return cmd
+}
+
+func (o *BundleLintOptions) Run() error {
+	isBundle := o.BundleFlags.Bundle != ""
+	isFiles := len(o.FileFlags.Files) > 0
+
+	switch {
+	case isBundle && isFiles:
+		return fmt.Errorf("Expected only one of bundle or file")
+	case !isBundle && !isFiles:
+		return fmt.Errorf("Expected either bundle or file")
+	}
+
+	reg, err := registry.NewSimpleRegistry(o.RegistryFlags.AsRegistryOpts(o.ui))
+	if err != nil {
+		return err
+	}
+
+	linter := bundle.NewLinter(reg, int64(o.MaxClosureSize), util.NewUILevelLogger(util.LogWarn, o.ui))
+
+	var problems []string
+	var subject string
+	if isBundle {
+		subject = o.BundleFlags.Bundle
+		problems, err = linter.LintBundle(o.BundleFlags.Bundle)
+		if err != nil {
+			return err
+		}
+	} else {
+		subject = strings.Join(o.FileFlags.Files, ", ")
+		problems = linter.LintContents(bundle.NewContents(o.FileFlags.Files, o.FileFlags.ExcludedFilePaths))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Linting bundle '%s':\n- %s", subject, strings.Join(problems, "\n- "))
+	}
+
+	o.ui.BeginLinef("Bundle '%s' has no problems\n", subject)
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+)
+
+func TestBundleLint(t *testing.T) {
+	t.Run("requires either a bundle or files", func(t *testing.T) {
+		subject := BundleLintOptions{ui: &bundlefakes.FakeUI{}}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Equal(t, "Expected either bundle or file", err.Error())
+
+		subject = BundleLintOptions{ui: &bundlefakes.FakeUI{}, BundleFlags: BundleFlags{"repo/bundle"}, FileFlags: FileFlags{Files: []string{"config"}}}
+		err = subject.Run()
+		require.Error(t, err)
+		assert.Equal(t, "Expected only one of bundle or file", err.Error())
+	})
+
+	t.Run("reports every problem of the bundle directory", func(t *testing.T) {
+		bundleDir := t.TempDir()
+		require.NoError(t, createBundleDir(bundleDir, emptyImagesYaml+"images:\n- image: nginx:1.25\n"))
+
+		subject := BundleLintOptions{ui: &bundlefakes.FakeUI{}, FileFlags: FileFlags{Files: []string{bundleDir}}}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Linting bundle '"+bundleDir+"':\n- Reading '"+filepath.Join(bundleDir, ".imgpkg", "images.yml")+"'")
+	})
+
+	t.Run("when there are no problems it succeeds", func(t *testing.T) {
+		bundleDir := t.TempDir()
+		require.NoError(t, createBundleDir(bundleDir, ""))
+		require.NoError(t, os.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte("config"), 0600))
+
+		fakeUI := &bundlefakes.FakeUI{}
+		subject := BundleLintOptions{ui: fakeUI, FileFlags: FileFlags{Files: []string{bundleDir}}}
+		require.NoError(t, subject.Run())
+
+		msg, args := fakeUI.BeginLinefArgsForCall(0)
+		assert.Equal(t, "Bundle '%s' has no problems\n", msg)
+		assert.Equal(t, []interface{}{bundleDir}, args)
+	})
+}
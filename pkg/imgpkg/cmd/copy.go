@@ -36,6 +36,15 @@ type CopyOptions struct {
 
 	Concurrency             int
 	IncludeNonDistributable bool
+	MaxBundleDepth          int
+
+	// AdditionalBundles, AdditionalImages and AdditionalLockFilePaths are the sources provided after
+	// the first bundle, image and lock file, more than one source can only be copied to a tar
+	AdditionalBundles       []string
+	AdditionalImages        []string
+	AdditionalLockFilePaths []string
+	// BundleFilters selects the root bundles that are copied from a tar, every bundle is copied when empty
+	BundleFilters []string
 }
 
 // NewCopyOptions constructor for building a CopyOptions, holding values derived via flags
@@ -52,6 +61,12 @@ func NewCopyCmd(o *CopyOptions) *cobra.Command {
     # Copy bundle dkalinin/app1-bundle to local tarball at /Volumes/app1-bundle.tar
     imgpkg copy -b dkalinin/app1-bundle --to-tar /Volumes/app1-bundle.tar
 
+    # Copy bundles dkalinin/app1-bundle and dkalinin/app2-bundle to a single local tarball at /Volumes/apps.tar
+    imgpkg copy -b dkalinin/app1-bundle -b dkalinin/app2-bundle --to-tar /Volumes/apps.tar
+
+    # Copy only bundle dkalinin/app2-bundle from the local tarball at /Volumes/apps.tar to another registry
+    imgpkg copy --tar /Volumes/apps.tar --bundle-filter '*/app2-bundle' --to-repo internal-registry/app2-bundle
+
     # Copy bundle dkalinin/app1-bundle to another registry (or repository)
     imgpkg copy -b dkalinin/app1-bundle --to-repo internal-registry/app1-bundle
 
@@ -59,9 +74,9 @@ func NewCopyCmd(o *CopyOptions) *cobra.Command {
     imgpkg copy -i dkalinin/app1-image --to-repo internal-registry/app1-image`,
 	}
 
-	o.ImageFlags.SetCopy(cmd)
-	o.BundleFlags.SetCopy(cmd)
-	o.LockInputFlags.Set(cmd)
+	o.ImageFlags.SetCopy(cmd, &o.AdditionalImages)
+	o.BundleFlags.SetCopy(cmd, &o.AdditionalBundles)
+	o.LockInputFlags.SetCopy(cmd, &o.AdditionalLockFilePaths)
 	o.LockOutputFlags.Set(cmd)
 	o.TarFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
@@ -70,18 +85,60 @@ func NewCopyCmd(o *CopyOptions) *cobra.Command {
 	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
 	cmd.Flags().BoolVar(&o.IncludeNonDistributable, "include-non-distributable-layers", false,
 		"Include non-distributable layers when copying an image/bundle")
+	cmd.Flags().StringSliceVar(&o.BundleFilters, "bundle-filter", nil, "Only copy the bundles of the tar whose repository matches the pattern (example: registry.io/products/app-*) (can be specified multiple times)")
+	cmd.Flags().IntVar(&o.MaxBundleDepth, "max-bundle-depth", 0, "Maximum levels of nested bundles to copy, error when bundles are nested deeper (0 means unlimited)")
 	return cmd
 }
 
 func (c *CopyOptions) Run() error {
-	if !c.hasOneSrc() {
-		return fmt.Errorf("Expected either --lock, --bundle (-b), --image (-i), or --tar as a source")
+	if !c.hasValidSrc() {
+		return fmt.Errorf("Expected either --lock, --bundle (-b), --image (-i), or --tar as a source (hint: More than one --lock, --bundle (-b) or --image (-i) can only be copied with --to-tar)")
 	}
 	if !c.hasOneDst() {
 		return fmt.Errorf("Expected either --to-tar or --to-repo")
 	}
+	if len(c.BundleFilters) > 0 && !c.TarFlags.IsSrc() {
+		return fmt.Errorf("Expected --tar as a source when using --bundle-filter")
+	}
+	if c.MaxBundleDepth < 0 {
+		return fmt.Errorf("Expected --max-bundle-depth to be 0 or greater")
+	}
+	if len(c.TarFlags.EncryptRecipients) > 0 && !c.TarFlags.IsDst() {
+		return fmt.Errorf("Expected --to-tar as a destination when using --encrypt-recipient")
+	}
+	if len(c.TarFlags.DecryptIdentities) > 0 && !c.TarFlags.IsSrc() {
+		return fmt.Errorf("Expected --tar as a source when using --decrypt-identity")
+	}
+	if c.TarFlags.SigningKeyPath != "" && !c.TarFlags.IsDst() {
+		return fmt.Errorf("Expected --to-tar as a destination when using --tar-signing-key")
+	}
+	if c.TarFlags.TrustedKeyPath != "" && !c.TarFlags.IsSrc() {
+		return fmt.Errorf("Expected --tar as a source when using --tar-trusted-key")
+	}
+	if c.SignatureFlags.PolicyPath != "" && c.TarFlags.IsSrc() {
+		return fmt.Errorf("Signature policy (--signature-policy) cannot be used with tar source (--tar), verify it when copying to the tar")
+	}
 
-	registryOpts := c.RegistryFlags.AsRegistryOpts()
+	tarRecipients, err := c.TarFlags.Recipients()
+	if err != nil {
+		return err
+	}
+	tarIdentities, err := c.TarFlags.Identities()
+	if err != nil {
+		return err
+	}
+	tarSigningKey, err := c.TarFlags.SigningKey(c.ui)
+	if err != nil {
+		return err
+	}
+	tarTrustedKey, err := c.TarFlags.TrustedKey()
+	if err != nil {
+		return err
+	}
+
+	prefixedLogger := util.NewUIPrefixedWriter("copy | ", c.ui)
+
+	registryOpts := c.RegistryFlags.AsRegistryOpts(prefixedLogger)
 	registryOpts.IncludeNonDistributableLayers = c.IncludeNonDistributable
 
 	reg, err := registry.NewSimpleRegistry(registryOpts)
@@ -89,20 +146,38 @@ func (c *CopyOptions) Run() error {
 		return err
 	}
 
-	prefixedLogger := util.NewUIPrefixedWriter("copy | ", c.ui)
 	levelLogger := util.NewUILevelLogger(util.LogWarn, prefixedLogger)
 	imagesUploaderLogger := util.NewProgressBar(levelLogger, "done uploading images", "Error uploading images")
 
 	imageSet := ctlimgset.NewImageSet(c.Concurrency, prefixedLogger)
-	tarImageSet := ctlimgset.NewTarImageSet(imageSet, c.Concurrency, prefixedLogger)
+	tarImageSet := ctlimgset.NewTarImageSet(imageSet, c.Concurrency, registryOpts.RetryPolicy, prefixedLogger).
+		WithRecipients(tarRecipients...).WithIdentities(tarIdentities...).
+		WithSigningKey(tarSigningKey).WithTrustedKey(tarTrustedKey).WithImgpkgVersion(Version)
+
+	var retrievers []signature.Retriever
+	if artifactKinds := c.SignatureFlags.ArtifactKinds(); len(artifactKinds) > 0 {
+		retrievers = append(retrievers, signature.NewArtifacts(signature.NewCosign(reg), artifactKinds, c.Concurrency))
+	}
+	if c.SignatureFlags.IncludesReferrers() {
+		retrievers = append(retrievers, signature.NewReferrers(reg, c.SignatureFlags.ReferrersArtifactTypes, c.Concurrency))
+	}
 
 	var signatureRetriever SignatureRetriever
-	if c.SignatureFlags.CopyCosignSignatures {
-		signatureRetriever = signature.NewSignatures(signature.NewCosign(reg), c.Concurrency)
+	if len(retrievers) > 0 {
+		signatureRetriever = signature.NewMulti(retrievers...)
 	} else {
 		signatureRetriever = signature.NewNoop()
 	}
 
+	var signaturePolicyVerifier SignaturePolicyVerifier
+	if c.SignatureFlags.PolicyPath != "" {
+		policy, err := signature.NewPolicyFromPath(c.SignatureFlags.PolicyPath)
+		if err != nil {
+			return err
+		}
+		signaturePolicyVerifier = signature.NewPolicyVerifier(policy, signature.NewCosign(reg), reg, c.Concurrency)
+	}
+
 	repoSrc := CopyRepoSrc{
 		ImageFlags:              c.ImageFlags,
 		BundleFlags:             c.BundleFlags,
@@ -110,12 +185,19 @@ func (c *CopyOptions) Run() error {
 		TarFlags:                c.TarFlags,
 		IncludeNonDistributable: c.IncludeNonDistributable,
 		Concurrency:             c.Concurrency,
+		MaxBundleDepth:          c.MaxBundleDepth,
+		BundleFilters:           c.BundleFilters,
 
 		ui:                 levelLogger,
 		registry:           registry.NewRegistryWithProgress(reg, imagesUploaderLogger),
 		imageSet:           imageSet,
 		tarImageSet:        tarImageSet,
 		signatureRetriever: signatureRetriever,
+
+		signaturePolicyVerifier: signaturePolicyVerifier,
+	}
+	if c.SignatureFlags.IncludesReferrers() {
+		repoSrc.referrersFallback = signature.NewReferrersFallback(reg)
 	}
 
 	switch {
@@ -126,26 +208,30 @@ func (c *CopyOptions) Run() error {
 		if c.LockOutputFlags.LockFilePath != "" {
 			return fmt.Errorf("Cannot output lock file with tar destination")
 		}
-		return repoSrc.CopyToTar(c.TarFlags.TarDst)
+		srcs := c.copySources(repoSrc)
+		return srcs[0].CopyToTar(c.TarFlags.TarDst, srcs[1:]...)
 
 	case c.isRepoDst():
 		processedImages, err := repoSrc.CopyToRepo(c.RepoDst)
 		if err != nil {
 			return err
 		}
-		return c.writeLockOutput(processedImages, reg)
+		return c.writeLockOutput(processedImages, reg, levelLogger)
 
 	default:
 		panic("Unreachable")
 	}
 }
 
-func (c *CopyOptions) writeLockOutput(processedImages *ctlimgset.ProcessedImages, registry registry.Registry) error {
+func (c *CopyOptions) writeLockOutput(processedImages *ctlimgset.ProcessedImages, registry registry.Registry, ui util.UIWithLevels) error {
 	if c.LockOutputFlags.LockFilePath == "" {
 		return nil
 	}
 
-	processedImageRootBundle := c.findProcessedImageRootBundle(processedImages)
+	processedImageRootBundle, err := c.findProcessedImageRootBundle(processedImages)
+	if err != nil {
+		return err
+	}
 
 	if processedImageRootBundle != nil {
 		// this is an optimization to avoid getting an image descriptor for an ImageIndex, since we know
@@ -164,12 +250,19 @@ func (c *CopyOptions) writeLockOutput(processedImages *ctlimgset.ProcessedImages
 			panic(fmt.Errorf("Internal inconsistency: '%s' should be a bundle but it is not", processedImageRootBundle.DigestRef))
 		}
 
+		if c.LockOutputFlags.Closure {
+			return c.writeBundleClosureLockOutput(foundBundle, ui)
+		}
 		return c.writeBundleLockOutput(foundBundle)
 	}
 
+	if c.LockOutputFlags.Closure {
+		return fmt.Errorf("Expected a bundle to have been copied to output a bundle closure lock")
+	}
+
 	// if the tarball was created with an older version (prior to assign a label to the root bundle) and it contains a bundle
 	// then return an error to the user informing them to recreate the tarball, since we don't know which is the root bundle.
-	err := c.informUserIfTarballNeedsToBeRecreated(processedImages, registry)
+	err = c.informUserIfTarballNeedsToBeRecreated(processedImages, registry)
 	if err != nil {
 		return err
 	}
@@ -177,13 +270,14 @@ func (c *CopyOptions) writeLockOutput(processedImages *ctlimgset.ProcessedImages
 	return c.writeImagesLockOutput(processedImages)
 }
 
-func (c *CopyOptions) findProcessedImageRootBundle(processedImages *ctlimgset.ProcessedImages) *ctlimgset.ProcessedImage {
+func (c *CopyOptions) findProcessedImageRootBundle(processedImages *ctlimgset.ProcessedImages) (*ctlimgset.ProcessedImage, error) {
 	var bundleProcessedImage *ctlimgset.ProcessedImage
 
 	for _, processedImage := range processedImages.All() {
 		if _, ok := processedImage.Labels[rootBundleLabelKey]; ok {
+			// Tarballs can contain more than one bundle
 			if bundleProcessedImage != nil {
-				panic("Internal inconsistency: expected only 1 root bundle")
+				return nil, fmt.Errorf("Expected a single bundle to have been copied to output a lock file (hint: Select the bundle with --bundle-filter)")
 			}
 			bundleProcessedImage = &ctlimgset.ProcessedImage{
 				UnprocessedImageRef: processedImage.UnprocessedImageRef,
@@ -193,7 +287,7 @@ func (c *CopyOptions) findProcessedImageRootBundle(processedImages *ctlimgset.Pr
 			}
 		}
 	}
-	return bundleProcessedImage
+	return bundleProcessedImage, nil
 }
 
 func (c *CopyOptions) informUserIfTarballNeedsToBeRecreated(processedImages *ctlimgset.ProcessedImages, registry registry.Registry) error {
@@ -226,18 +320,45 @@ func (c *CopyOptions) hasOneDst() bool {
 	return (repoSet || tarSet) && !(repoSet && tarSet)
 }
 
-func (c *CopyOptions) hasOneSrc() bool {
-	var seen bool
-	for _, ref := range []string{c.LockInputFlags.LockFilePath, c.TarFlags.TarSrc,
-		c.BundleFlags.Bundle, c.ImageFlags.Image} {
-		if ref != "" {
-			if seen {
-				return false
-			}
-			seen = true
+func (c *CopyOptions) hasValidSrc() bool {
+	srcs := len(c.copySources(CopyRepoSrc{}))
+	if c.TarFlags.IsSrc() {
+		return srcs == 0
+	}
+	return srcs == 1 || (srcs > 1 && c.TarFlags.IsDst())
+}
+
+// copySources returns a copy of repoSrc for every lock file, image and bundle provided, each one with only that source set
+func (c *CopyOptions) copySources(repoSrc CopyRepoSrc) []CopyRepoSrc {
+	newSrc := func(lockInputFlags LockInputFlags, imageFlags ImageFlags, bundleFlags BundleFlags) CopyRepoSrc {
+		src := repoSrc
+		src.LockInputFlags = lockInputFlags
+		src.ImageFlags = imageFlags
+		src.BundleFlags = bundleFlags
+		return src
+	}
+
+	var srcs []CopyRepoSrc
+	for _, lockFilePath := range nonEmpty(append([]string{c.LockInputFlags.LockFilePath}, c.AdditionalLockFilePaths...)) {
+		srcs = append(srcs, newSrc(LockInputFlags{LockFilePath: lockFilePath}, ImageFlags{}, BundleFlags{}))
+	}
+	for _, image := range nonEmpty(append([]string{c.ImageFlags.Image}, c.AdditionalImages...)) {
+		srcs = append(srcs, newSrc(LockInputFlags{}, ImageFlags{Image: image}, BundleFlags{}))
+	}
+	for _, bundle := range nonEmpty(append([]string{c.BundleFlags.Bundle}, c.AdditionalBundles...)) {
+		srcs = append(srcs, newSrc(LockInputFlags{}, ImageFlags{}, BundleFlags{Bundle: bundle}))
+	}
+	return srcs
+}
+
+func nonEmpty(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
 		}
 	}
-	return seen
+	return result
 }
 
 func (c *CopyOptions) writeImagesLockOutput(processedImages *ctlimgset.ProcessedImages) error {
@@ -287,6 +408,17 @@ func (c *CopyOptions) writeBundleLockOutput(bundle *bundle.Bundle) error {
 	return bundleLock.WriteToPath(c.LockOutputFlags.LockFilePath)
 }
 
+// writeBundleClosureLockOutput reads the copied bundle, whose locations were already noted,
+// to record where every nested bundle and image was copied to
+func (c *CopyOptions) writeBundleClosureLockOutput(bundle *bundle.Bundle, ui util.UIWithLevels) error {
+	closureLock, err := bundle.ClosureLock(c.Concurrency, ui)
+	if err != nil {
+		return fmt.Errorf("Reading copied bundle '%s': %s", bundle.DigestRef(), err)
+	}
+
+	return closureLock.WriteToPath(c.LockOutputFlags.LockFilePath)
+}
+
 func processedImagesMediaType(processedImages *ctlimgset.ProcessedImages) []string {
 	everyMediaType := []string{}
 	for _, image := range processedImages.All() {
@@ -0,0 +1,185 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	ctlbundle "github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
+)
+
+// selectFilteredBundles selects, from the images of the tar source, the root bundles that match --bundle-filter
+// together with every image they reference and the signatures and referrers of those images
+func (c CopyRepoSrc) selectFilteredBundles(tarReader imagetar.TarReader, imgOrIndexes []imagedesc.ImageOrIndex) ([]imagedesc.ImageOrIndex, error) {
+	for _, filter := range c.BundleFilters {
+		if _, err := path.Match(filter, ""); err != nil {
+			return nil, fmt.Errorf("Parsing --bundle-filter '%s': %s", filter, err)
+		}
+	}
+
+	rootBundles, err := c.rootBundlesInTar(tarReader, imgOrIndexes)
+	if err != nil {
+		return nil, err
+	}
+
+	var selectedBundles []string
+	for _, rootBundle := range rootBundles {
+		if c.bundleFilterMatches(rootBundle) {
+			selectedBundles = append(selectedBundles, rootBundle)
+		}
+	}
+	if len(selectedBundles) == 0 {
+		return nil, fmt.Errorf("Expected --bundle-filter to match at least one of the bundles in tar file '%s': %s", c.TarFlags.TarSrc, strings.Join(rootBundles, ", "))
+	}
+
+	imagesMetadata := imagetar.NewTarImagesMetadata(imgOrIndexes)
+	selectedDigests := map[string]struct{}{}
+
+	for _, bundleRef := range selectedBundles {
+		c.ui.Debugf("Selecting bundle '%s' from tar\n", bundleRef)
+
+		bundles, imageRefs, err := ctlbundle.NewBundle(bundleRef, imagesMetadata).WithMaxDepth(c.MaxBundleDepth).AllImagesRefs(c.Concurrency, c.ui)
+		if err != nil {
+			return nil, fmt.Errorf("Reading images of bundle '%s': %s", bundleRef, err)
+		}
+
+		refs := []string{}
+		for _, bundle := range bundles {
+			refs = append(refs, bundle.DigestRef())
+		}
+		for _, imageRef := range imageRefs.ImageRefs() {
+			refs = append(refs, imageRef.Image)
+		}
+
+		for _, ref := range refs {
+			digestRef, err := regname.NewDigest(ref)
+			if err != nil {
+				return nil, err
+			}
+			selectedDigests[digestRef.DigestStr()] = struct{}{}
+		}
+	}
+
+	return selectImagesWithArtifacts(imgOrIndexes, selectedDigests)
+}
+
+// rootBundlesInTar returns the bundles listed in the catalog of the tar,
+// tarballs created before the catalog existed only have the root bundle labeled
+func (c CopyRepoSrc) rootBundlesInTar(tarReader imagetar.TarReader, imgOrIndexes []imagedesc.ImageOrIndex) ([]string, error) {
+	catalog, err := tarReader.Catalog()
+	if err != nil {
+		return nil, fmt.Errorf("Reading catalog of tar file '%s': %s", c.TarFlags.TarSrc, err)
+	}
+
+	var rootBundles []string
+	if catalog != nil {
+		for _, bundle := range catalog.Bundles {
+			rootBundles = append(rootBundles, bundle.Image)
+		}
+		return rootBundles, nil
+	}
+
+	for _, imgOrIndex := range imgOrIndexes {
+		if _, ok := imgOrIndex.Labels[rootBundleLabelKey]; ok {
+			rootBundles = append(rootBundles, imgOrIndex.Ref())
+		}
+	}
+	return rootBundles, nil
+}
+
+// bundleFilterMatches checks if the repository of the bundle matches any of the filters
+func (c CopyRepoSrc) bundleFilterMatches(bundleRef string) bool {
+	repos := []string{strings.SplitN(bundleRef, "@", 2)[0]}
+	if digestRef, err := regname.NewDigest(bundleRef); err == nil {
+		repos = append(repos, digestRef.Context().Name())
+	}
+
+	for _, filter := range c.BundleFilters {
+		for _, repo := range repos {
+			if matched, _ := path.Match(filter, repo); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectImagesWithArtifacts returns the images with the selected digests, and the artifacts that refer to them:
+// signatures, attestations and SBOMs tagged after the digest of their image, and referrers with a subject
+func selectImagesWithArtifacts(imgOrIndexes []imagedesc.ImageOrIndex, selectedDigests map[string]struct{}) ([]imagedesc.ImageOrIndex, error) {
+	selected := make([]bool, len(imgOrIndexes))
+
+	// Artifacts can also have artifacts, so images are checked again until no more images are selected
+	for changed := true; changed; {
+		changed = false
+
+		for i, imgOrIndex := range imgOrIndexes {
+			if selected[i] {
+				continue
+			}
+
+			digest, err := imgOrIndex.Digest()
+			if err != nil {
+				return nil, err
+			}
+
+			subjectDigest, err := artifactSubjectDigest(imgOrIndex)
+			if err != nil {
+				return nil, err
+			}
+
+			_, isSelected := selectedDigests[digest.String()]
+			_, isSubjectSelected := selectedDigests[subjectDigest]
+			if isSelected || (subjectDigest != "" && isSubjectSelected) {
+				selected[i] = true
+				selectedDigests[digest.String()] = struct{}{}
+				changed = true
+			}
+		}
+	}
+
+	var result []imagedesc.ImageOrIndex
+	for i, imgOrIndex := range imgOrIndexes {
+		if selected[i] {
+			result = append(result, imgOrIndex)
+		}
+	}
+	return result, nil
+}
+
+// artifactSubjectDigest returns the digest of the image the artifact refers to, or an empty string when it is not an artifact
+func artifactSubjectDigest(imgOrIndex imagedesc.ImageOrIndex) (string, error) {
+	// Tags of the cosign artifacts and of the referrers tag schema are the digest of the image (example: sha256-abc.sig)
+	if tag := imgOrIndex.Tag(); strings.HasPrefix(tag, "sha256-") {
+		return strings.Replace(strings.SplitN(tag, ".", 2)[0], "-", ":", 1), nil
+	}
+
+	var manifest []byte
+	var err error
+	switch {
+	case imgOrIndex.Image != nil:
+		manifest, err = (*imgOrIndex.Image).RawManifest()
+	case imgOrIndex.Index != nil:
+		manifest, err = (*imgOrIndex.Index).RawManifest()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var withSubject struct {
+		Subject *struct {
+			Digest string `json:"digest"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(manifest, &withSubject); err != nil || withSubject.Subject == nil {
+		return "", nil
+	}
+	return withSubject.Subject.Digest, nil
+}
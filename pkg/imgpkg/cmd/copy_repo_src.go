@@ -22,6 +22,16 @@ type SignatureRetriever interface {
 	Fetch(images *imageset.UnprocessedImageRefs) (*imageset.UnprocessedImageRefs, error)
 }
 
+// ReferrersFallbackWriter Lists the copied artifacts in the referrers index of their subject at the destination
+type ReferrersFallbackWriter interface {
+	Write(images *ctlimgset.ProcessedImages) error
+}
+
+// SignaturePolicyVerifier Checks that images comply with a signature policy
+type SignaturePolicyVerifier interface {
+	Verify(images *imageset.UnprocessedImageRefs, origImageRefs map[string]string) error
+}
+
 type CopyRepoSrc struct {
 	ImageFlags              ImageFlags
 	BundleFlags             BundleFlags
@@ -29,23 +39,53 @@ type CopyRepoSrc struct {
 	TarFlags                TarFlags
 	IncludeNonDistributable bool
 	Concurrency             int
+	// MaxBundleDepth is the maximum number of levels of nested bundles that are copied, 0 means no limit
+	MaxBundleDepth int
+	// BundleFilters selects the root bundles copied from a tar source, every bundle is copied when empty
+	BundleFilters []string
 
 	ui                 util.UIWithLevels
 	imageSet           ctlimgset.ImageSet
 	tarImageSet        ctlimgset.TarImageSet
 	registry           registry.ImagesReaderWriter
 	signatureRetriever SignatureRetriever
+	// signaturePolicyVerifier is optional, when provided source images are only copied if they comply with the policy
+	signaturePolicyVerifier SignaturePolicyVerifier
+	// referrersFallback is optional, when provided the copied artifacts are listed in the referrers tag schema
+	// of the destination when the destination registry does not support the referrers API
+	referrersFallback ReferrersFallbackWriter
 }
 
-func (c CopyRepoSrc) CopyToTar(dstPath string) error {
+// CopyToTar Copies the images of this source, and of the other sources, into a single tarball.
+// Images and layers shared by the sources are only written once
+func (c CopyRepoSrc) CopyToTar(dstPath string, otherSrcs ...CopyRepoSrc) error {
 	c.ui.Tracef("CopyToTar\n")
 
-	unprocessedImageRefs, _, err := c.getAllSourceImages()
-	if err != nil {
-		return err
+	unprocessedImageRefs := ctlimgset.NewUnprocessedImageRefs()
+	imageRefsByKey := map[string]ctlimgset.UnprocessedImageRef{}
+	catalog := imagetar.Catalog{}
+
+	for _, src := range append([]CopyRepoSrc{c}, otherSrcs...) {
+		srcImageRefs, _, err := src.getAllSourceImages()
+		if err != nil {
+			return err
+		}
+
+		for _, imageRef := range srcImageRefs.All() {
+			// A bundle can be the root bundle of a source and a nested bundle of another one
+			if existing, found := imageRefsByKey[imageRef.Key()]; found {
+				imageRef.Labels = mergeLabels(existing.Labels, imageRef.Labels)
+			}
+			imageRefsByKey[imageRef.Key()] = imageRef
+			unprocessedImageRefs.Add(imageRef)
+
+			if _, isRootBundle := imageRef.Labels[rootBundleLabelKey]; isRootBundle && !catalog.Contains(imageRef.DigestRef) {
+				catalog.Bundles = append(catalog.Bundles, imagetar.CatalogBundle{Image: imageRef.DigestRef, Tag: imageRef.Tag})
+			}
+		}
 	}
 
-	ids, err := c.tarImageSet.Export(unprocessedImageRefs, dstPath, c.registry,
+	ids, err := c.tarImageSet.Export(unprocessedImageRefs, catalog, dstPath, c.registry,
 		imagetar.NewImageLayerWriterCheck(c.IncludeNonDistributable))
 	if err != nil {
 		return err
@@ -71,7 +111,12 @@ func (c CopyRepoSrc) CopyToRepo(repo string) (*ctlimgset.ProcessedImages, error)
 			return nil, fmt.Errorf("Cannot use tar source (--tar) with tar destination (--to-tar)")
 		}
 
-		processedImages, err = c.tarImageSet.Import(c.TarFlags.TarSrc, importRepo, c.registry)
+		var selector func(imagetar.TarReader, []imagedesc.ImageOrIndex) ([]imagedesc.ImageOrIndex, error)
+		if len(c.BundleFilters) > 0 {
+			selector = c.selectFilteredBundles
+		}
+
+		processedImages, err = c.tarImageSet.ImportSelected(c.TarFlags.TarSrc, selector, importRepo, c.registry)
 		if err != nil {
 			return nil, err
 		}
@@ -130,15 +175,31 @@ func (c CopyRepoSrc) CopyToRepo(repo string) (*ctlimgset.ProcessedImages, error)
 		return nil, fmt.Errorf("Tagging images: %s", err)
 	}
 
+	if c.referrersFallback != nil {
+		err = c.referrersFallback.Write(processedImages)
+		if err != nil {
+			return nil, fmt.Errorf("Listing referrers: %s", err)
+		}
+	}
+
 	return processedImages, nil
 }
 
 func (c CopyRepoSrc) getAllSourceImages() (*ctlimgset.UnprocessedImageRefs, []*ctlbundle.Bundle, error) {
-	unprocessedImageRefs, bundles, err := c.getProvidedSourceImages()
+	unprocessedImageRefs, bundles, origImageRefs, err := c.getProvidedSourceImages()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	if c.signaturePolicyVerifier != nil {
+		c.ui.Debugf("Verifying signature policy\n")
+
+		err := c.signaturePolicyVerifier.Verify(unprocessedImageRefs, origImageRefs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	c.ui.Debugf("Fetching signatures\n")
 
 	signatures, err := c.signatureRetriever.Fetch(unprocessedImageRefs)
@@ -153,14 +214,17 @@ func (c CopyRepoSrc) getAllSourceImages() (*ctlimgset.UnprocessedImageRefs, []*c
 	return unprocessedImageRefs, bundles, nil
 }
 
-func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs, []*ctlbundle.Bundle, error) {
+// getProvidedSourceImages returns the images to copy and, for the images read from a location other than the one
+// recorded in the images lock of their bundle, the image in the images lock
+func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs, []*ctlbundle.Bundle, map[string]string, error) {
 	unprocessedImageRefs := ctlimgset.NewUnprocessedImageRefs()
+	origImageRefs := map[string]string{}
 
 	switch {
 	case c.LockInputFlags.LockFilePath != "":
-		bundleLock, imagesLock, err := lockconfig.NewLockFromPath(c.LockInputFlags.LockFilePath)
+		bundleLock, closureLock, imagesLock, err := lockconfig.NewLockFromPath(c.LockInputFlags.LockFilePath)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 
 		switch {
@@ -168,11 +232,12 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 			c.ui.Tracef("get images from BundleLock file\n")
 			_, bundles, imagesRef, err := c.getBundleImageRefs(bundleLock.Bundle.Image)
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 
 			for _, img := range imagesRef.ImageRefs() {
 				unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: img.PrimaryLocation()})
+				origImageRefs[img.PrimaryLocation()] = img.Image
 			}
 
 			unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{
@@ -183,7 +248,33 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 				},
 			})
 
-			return unprocessedImageRefs, bundles, nil
+			return unprocessedImageRefs, bundles, origImageRefs, nil
+
+		case closureLock != nil:
+			c.ui.Tracef("get images from BundleClosureLock file\n")
+			bundles, err := ctlbundle.NewBundlesFromClosureLock(*closureLock, c.registry)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			for _, img := range closureLock.Images {
+				unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: img.Image})
+			}
+			for _, bundle := range closureLock.Bundles {
+				if bundle.Image != closureLock.Bundle.Image {
+					unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: bundle.Image})
+				}
+			}
+
+			unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{
+				DigestRef: closureLock.Bundle.Image,
+				Tag:       closureLock.Bundle.Tag,
+				Labels: map[string]string{
+					rootBundleLabelKey: "",
+				},
+			})
+
+			return unprocessedImageRefs, bundles, origImageRefs, nil
 
 		case imagesLock != nil:
 			c.ui.Tracef("get images from ImagesLock file\n")
@@ -192,15 +283,15 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 
 				ok, err := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry).IsBundle()
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 				if ok {
-					return nil, nil, fmt.Errorf("Unable to copy bundles using an Images Lock file (hint: Create a bundle with these images)")
+					return nil, nil, nil, fmt.Errorf("Unable to copy bundles using an Images Lock file (hint: Create a bundle with these images)")
 				}
 
 				unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: plainImg.DigestRef()})
 			}
-			return unprocessedImageRefs, nil, nil
+			return unprocessedImageRefs, nil, origImageRefs, nil
 
 		default:
 			panic("Unreachable")
@@ -212,24 +303,25 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 
 		ok, err := ctlbundle.NewBundleFromPlainImage(plainImg, c.registry).IsBundle()
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		if ok {
-			return nil, nil, fmt.Errorf("Expected bundle flag when copying a bundle (hint: Use -b instead of -i for bundles)")
+			return nil, nil, nil, fmt.Errorf("Expected bundle flag when copying a bundle (hint: Use -b instead of -i for bundles)")
 		}
 
 		unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: plainImg.DigestRef(), Tag: plainImg.Tag()})
-		return unprocessedImageRefs, nil, nil
+		return unprocessedImageRefs, nil, origImageRefs, nil
 
 	default:
 		c.ui.Tracef("copy bundle\n")
 		bundle, allBundles, imagesRef, err := c.getBundleImageRefs(c.BundleFlags.Bundle)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 
 		for _, img := range imagesRef.ImageRefs() {
 			unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{DigestRef: img.PrimaryLocation()})
+			origImageRefs[img.PrimaryLocation()] = img.Image
 		}
 
 		unprocessedImageRefs.Add(ctlimgset.UnprocessedImageRef{
@@ -240,12 +332,12 @@ func (c CopyRepoSrc) getProvidedSourceImages() (*ctlimgset.UnprocessedImageRefs,
 			}},
 		)
 
-		return unprocessedImageRefs, allBundles, nil
+		return unprocessedImageRefs, allBundles, origImageRefs, nil
 	}
 }
 
 func (c CopyRepoSrc) getBundleImageRefs(bundleRef string) (*ctlbundle.Bundle, []*ctlbundle.Bundle, ctlbundle.ImageRefs, error) {
-	bundle := ctlbundle.NewBundle(bundleRef, c.registry)
+	bundle := ctlbundle.NewBundle(bundleRef, c.registry).WithMaxDepth(c.MaxBundleDepth)
 	isBundle, err := bundle.IsBundle()
 	if err != nil {
 		return nil, nil, ctlbundle.ImageRefs{}, err
@@ -261,6 +353,19 @@ func (c CopyRepoSrc) getBundleImageRefs(bundleRef string) (*ctlbundle.Bundle, []
 	return bundle, nestedBundles, imageRefs, nil
 }
 
+func mergeLabels(labels ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, l := range labels {
+		for key, value := range l {
+			if merged == nil {
+				merged = map[string]string{}
+			}
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
 func imageRefDescriptorsMediaTypes(ids *imagedesc.ImageRefDescriptors) []string {
 	mediaTypes := []string{}
 	for _, descriptor := range ids.Descriptors() {
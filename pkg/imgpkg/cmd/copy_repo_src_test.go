@@ -6,20 +6,30 @@ package cmd
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
+	"filippo.io/age"
 	goui "github.com/cppforlife/go-cli-ui/ui"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
@@ -30,6 +40,7 @@ import (
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
 )
@@ -54,7 +65,7 @@ func TestMain(m *testing.M) {
 	subject = CopyRepoSrc{
 		ui:                 uiLogger,
 		imageSet:           imageSet,
-		tarImageSet:        imageset.NewTarImageSet(imageSet, 1, confUI),
+		tarImageSet:        imageset.NewTarImageSet(imageSet, 1, util.RetryPolicy{MaxAttempts: 3}, confUI),
 		Concurrency:        1,
 		signatureRetriever: &fakeSignatureRetriever{},
 	}
@@ -312,6 +323,337 @@ func TestToTarImageContainingNonDistributableLayers(t *testing.T) {
 	})
 }
 
+func TestToTarMultipleSources(t *testing.T) {
+	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
+	fakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer fakeRegistry.CleanUp()
+	sharedImage := fakeRegistry.WithRandomImage("library/shared")
+	app1Image := fakeRegistry.WithRandomImage("library/app1")
+	app2Image := fakeRegistry.WithRandomImage("library/app2")
+	reg := fakeRegistry.Build()
+
+	pushBundle := func(repo string, images ...string) string {
+		bundleDir := t.TempDir()
+		require.NoError(t, os.Mkdir(filepath.Join(bundleDir, bundle.ImgpkgDir), 0700))
+		imagesLock := lockconfig.ImagesLock{LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind}}
+		for _, image := range images {
+			imagesLock.Images = append(imagesLock.Images, lockconfig.ImageRef{Image: image})
+		}
+		require.NoError(t, imagesLock.WriteToPath(filepath.Join(bundleDir, bundle.ImgpkgDir, bundle.ImagesLockFile)))
+
+		uploadRef, err := name.NewTag(fakeRegistry.ReferenceOnTestServer(repo))
+		require.NoError(t, err)
+		bundleRef, err := bundle.NewContents([]string{bundleDir}, nil).Push(uploadRef, reg, &bundlefakes.FakeUI{})
+		require.NoError(t, err)
+		return bundleRef
+	}
+	app1Bundle := pushBundle("library/app1-bundle", sharedImage.RefDigest, app1Image.RefDigest)
+	app2Bundle := pushBundle("library/app2-bundle", sharedImage.RefDigest, app2Image.RefDigest)
+
+	tarFile := filepath.Join(t.TempDir(), "bundles.tar")
+
+	app1Src := subject
+	app1Src.BundleFlags = BundleFlags{app1Bundle}
+	app1Src.registry = reg
+	app2Src := app1Src
+	app2Src.BundleFlags = BundleFlags{app2Bundle}
+	imageSrc := app1Src
+	imageSrc.BundleFlags = BundleFlags{}
+	imageSrc.ImageFlags = ImageFlags{sharedImage.RefDigest}
+
+	require.NoError(t, app1Src.CopyToTar(tarFile, app2Src, imageSrc))
+
+	t.Run("the tar contains every layer once and a catalog with the bundles", func(t *testing.T) {
+		assertTarballContainsEveryLayer(t, tarFile)
+
+		file, err := os.Open(tarFile)
+		require.NoError(t, err)
+		defer file.Close()
+		entries := map[string]int{}
+		tarReader := tar.NewReader(file)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			entries[header.Name]++
+		}
+		for entry, count := range entries {
+			assert.Equalf(t, 1, count, "expected entry %s to be written once", entry)
+		}
+
+		catalog, err := imagetar.NewTarReader(tarFile).Catalog()
+		require.NoError(t, err)
+		require.NotNil(t, catalog)
+		assert.Equal(t, []imagetar.CatalogBundle{{Image: app1Bundle}, {Image: app2Bundle}}, catalog.Bundles)
+	})
+
+	t.Run("the tar starts with its format, listing the root bundles", func(t *testing.T) {
+		format, err := imagetar.NewTarReader(tarFile).Format()
+		require.NoError(t, err)
+		require.NotNil(t, format)
+		assert.Equal(t, imagetar.FormatVersion, format.Version)
+		assert.Equal(t, []string{app1Bundle, app2Bundle}, format.RootBundles)
+		assert.Equal(t, []string{imagetar.FeatureCatalog}, format.Features)
+	})
+
+	t.Run("when filtering the bundles only the matching bundles are copied to the repository", func(t *testing.T) {
+		destFakeRegistry := helpers.NewFakeRegistry(t, logger)
+		defer destFakeRegistry.CleanUp()
+
+		subject := subject
+		subject.TarFlags.TarSrc = tarFile
+		subject.BundleFilters = []string{"*/library/app2-*"}
+		subject.registry = destFakeRegistry.Build()
+
+		processedImages, err := subject.CopyToRepo(destFakeRegistry.ReferenceOnTestServer("library/copied"))
+		require.NoError(t, err)
+
+		var copiedDigests []string
+		for _, processedImage := range processedImages.All() {
+			copiedDigests = append(copiedDigests, strings.Split(processedImage.DigestRef, "@")[1])
+		}
+		app2BundleDigest, err := name.NewDigest(app2Bundle)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{app2BundleDigest.DigestStr(), sharedImage.Digest, app2Image.Digest}, copiedDigests)
+	})
+
+	t.Run("when no bundle matches the filter it errors", func(t *testing.T) {
+		subject := subject
+		subject.TarFlags.TarSrc = tarFile
+		subject.BundleFilters = []string{"*/library/other-bundle"}
+		subject.registry = fakeRegistry.Build()
+
+		_, err := subject.CopyToRepo(fakeRegistry.ReferenceOnTestServer("library/copied"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --bundle-filter to match at least one of the bundles in tar file")
+	})
+}
+
+func TestToTarEncrypted(t *testing.T) {
+	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
+	fakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer fakeRegistry.CleanUp()
+	appImage := fakeRegistry.WithRandomImage("library/app")
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(t.TempDir(), "identity.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+
+	recipients, err := imagetar.ParseRecipients([]string{identity.Recipient().String()})
+	require.NoError(t, err)
+	identities, err := imagetar.ParseIdentities([]string{identityFile})
+	require.NoError(t, err)
+
+	tarFile := filepath.Join(t.TempDir(), "encrypted.tar")
+
+	imageSrc := subject
+	imageSrc.ImageFlags = ImageFlags{appImage.RefDigest}
+	imageSrc.registry = fakeRegistry.Build()
+	imageSrc.tarImageSet = imageSrc.tarImageSet.WithRecipients(recipients...)
+	require.NoError(t, imageSrc.CopyToTar(tarFile))
+
+	t.Run("the tar file does not disclose its entries", func(t *testing.T) {
+		contents, err := os.ReadFile(tarFile)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(contents, []byte("age-encryption.org/v1")))
+		assert.NotContains(t, string(contents), "manifest.json")
+		assert.NotContains(t, string(contents), strings.TrimPrefix(appImage.Digest, "sha256:"))
+	})
+
+	t.Run("when copying with the identity the images are decrypted to the repository", func(t *testing.T) {
+		destFakeRegistry := helpers.NewFakeRegistry(t, logger)
+		defer destFakeRegistry.CleanUp()
+
+		tarSrc := subject
+		tarSrc.TarFlags.TarSrc = tarFile
+		tarSrc.registry = destFakeRegistry.Build()
+		tarSrc.tarImageSet = tarSrc.tarImageSet.WithIdentities(identities...)
+
+		processedImages, err := tarSrc.CopyToRepo(destFakeRegistry.ReferenceOnTestServer("library/copied"))
+		require.NoError(t, err)
+		require.Len(t, processedImages.All(), 1)
+		assert.Equal(t, appImage.Digest, strings.Split(processedImages.All()[0].DigestRef, "@")[1])
+	})
+
+	t.Run("when copying without an identity it errors", func(t *testing.T) {
+		tarSrc := subject
+		tarSrc.TarFlags.TarSrc = tarFile
+		tarSrc.registry = fakeRegistry.Build()
+
+		_, err := tarSrc.CopyToRepo(fakeRegistry.ReferenceOnTestServer("library/copied"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected an identity to decrypt the encrypted tar file (hint: Provide it with --decrypt-identity)")
+	})
+
+	t.Run("when copying with another identity it errors", func(t *testing.T) {
+		otherIdentity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+
+		tarSrc := subject
+		tarSrc.TarFlags.TarSrc = tarFile
+		tarSrc.registry = fakeRegistry.Build()
+		tarSrc.tarImageSet = tarSrc.tarImageSet.WithIdentities(otherIdentity)
+
+		_, err = tarSrc.CopyToRepo(fakeRegistry.ReferenceOnTestServer("library/copied"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Decrypting tar file")
+	})
+}
+
+func TestToTarSigned(t *testing.T) {
+	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
+	fakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer fakeRegistry.CleanUp()
+	appImage := fakeRegistry.WithRandomImage("library/app")
+	reg := fakeRegistry.Build()
+
+	bundleDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, bundle.ImgpkgDir), 0700))
+	imagesLock := lockconfig.ImagesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+		Images:      []lockconfig.ImageRef{{Image: appImage.RefDigest}},
+	}
+	require.NoError(t, imagesLock.WriteToPath(filepath.Join(bundleDir, bundle.ImgpkgDir, bundle.ImagesLockFile)))
+	uploadRef, err := name.NewTag(fakeRegistry.ReferenceOnTestServer("library/app-bundle"))
+	require.NoError(t, err)
+	bundleRef, err := bundle.NewContents([]string{bundleDir}, nil).Push(uploadRef, reg, &bundlefakes.FakeUI{})
+	require.NoError(t, err)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	copyToTar := func(t *testing.T, key *ecdsa.PrivateKey) string {
+		tarFile := filepath.Join(t.TempDir(), "signed.tar")
+		bundleSrc := subject
+		bundleSrc.BundleFlags = BundleFlags{bundleRef}
+		bundleSrc.registry = reg
+		bundleSrc.tarImageSet = bundleSrc.tarImageSet.WithSigningKey(key)
+		require.NoError(t, bundleSrc.CopyToTar(tarFile))
+		return tarFile
+	}
+
+	copyFromTar := func(t *testing.T, tarFile string, trustedKey *ecdsa.PublicKey) error {
+		tarSrc := subject
+		tarSrc.TarFlags.TarSrc = tarFile
+		tarSrc.registry = reg
+		tarSrc.tarImageSet = tarSrc.tarImageSet.WithTrustedKey(trustedKey)
+		_, err := tarSrc.CopyToRepo(fakeRegistry.ReferenceOnTestServer("library/copied"))
+		return err
+	}
+
+	t.Run("when the tar is signed by the trusted key it is copied to the repository", func(t *testing.T) {
+		require.NoError(t, copyFromTar(t, copyToTar(t, signingKey), &signingKey.PublicKey))
+	})
+
+	t.Run("when the tar is signed by another key it errors", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		err = copyFromTar(t, copyToTar(t, otherKey), &signingKey.PublicKey)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected tar file to be signed by the trusted key")
+	})
+
+	t.Run("when the tar is not signed it errors", func(t *testing.T) {
+		err := copyFromTar(t, copyToTar(t, nil), &signingKey.PublicKey)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected tar file to be signed, but it does not contain signature.json")
+	})
+
+	t.Run("when the root bundle of the tar was changed after signing it errors", func(t *testing.T) {
+		tarFile := copyToTar(t, signingKey)
+		rewriteTarEntries(t, tarFile, func(name string, contents []byte) []byte {
+			if name != imagetar.CatalogFile {
+				return contents
+			}
+			return []byte(strings.Replace(string(contents), bundleRef, appImage.RefDigest, 1))
+		})
+
+		err := copyFromTar(t, tarFile, &signingKey.PublicKey)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected tar file entry 'catalog.json' to have signed digest")
+	})
+
+	t.Run("when a layer of the tar was changed after signing it errors", func(t *testing.T) {
+		tarFile := copyToTar(t, signingKey)
+		rewriteTarEntries(t, tarFile, func(name string, contents []byte) []byte {
+			if !strings.HasSuffix(name, ".tar.gz") {
+				return contents
+			}
+			contents[len(contents)-1] ^= 0xff
+			return contents
+		})
+
+		// Copy to another registry, so that the layers are uploaded from the tar
+		dstRegistry := helpers.NewFakeRegistry(t, logger)
+		defer dstRegistry.CleanUp()
+		tarSrc := subject
+		tarSrc.TarFlags.TarSrc = tarFile
+		tarSrc.registry = dstRegistry.Build()
+		tarSrc.tarImageSet = tarSrc.tarImageSet.WithTrustedKey(&signingKey.PublicKey)
+		_, err := tarSrc.CopyToRepo(dstRegistry.ReferenceOnTestServer("library/copied"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Reading signed tar file entry")
+		assert.Contains(t, err.Error(), "error verifying sha256 checksum")
+	})
+
+	t.Run("when an entry is added to the tar after signing it errors", func(t *testing.T) {
+		tarFile := copyToTar(t, signingKey)
+		file, err := os.OpenFile(tarFile, os.O_RDWR, 0600)
+		require.NoError(t, err)
+		// Overwrite the end of archive marker of the tar
+		_, err = file.Seek(-1024, io.SeekEnd)
+		require.NoError(t, err)
+		tarWriter := tar.NewWriter(file)
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "extra.json", Size: 2, Mode: 0600}))
+		_, err = tarWriter.Write([]byte("{}"))
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, file.Close())
+
+		err = copyFromTar(t, tarFile, &signingKey.PublicKey)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected tar file entry 'extra.json' to be signed")
+	})
+}
+
+// rewriteTarEntries rewrites the tarball replacing the contents of every entry with the result of the change
+func rewriteTarEntries(t *testing.T, tarFile string, change func(name string, contents []byte) []byte) {
+	src, err := os.Open(tarFile)
+	require.NoError(t, err)
+	defer src.Close()
+
+	rewrittenFile := tarFile + ".rewritten"
+	dst, err := os.Create(rewrittenFile)
+	require.NoError(t, err)
+	defer dst.Close()
+
+	tarReader := tar.NewReader(src)
+	tarWriter := tar.NewWriter(dst)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		contents, err := ioutil.ReadAll(tarReader)
+		require.NoError(t, err)
+		contents = change(hdr.Name, contents)
+		hdr.Size = int64(len(contents))
+
+		require.NoError(t, tarWriter.WriteHeader(hdr))
+		_, err = tarWriter.Write(contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+
+	require.NoError(t, os.Rename(rewrittenFile, tarFile))
+}
+
 func TestToTarImageIndex(t *testing.T) {
 	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
 	numOfImagesForImageIndex := int64(3)
@@ -504,6 +846,51 @@ bundle:
 		assert.Equal(t, processedBundle.DigestRef, destRepo+"@"+bundleWithNestedBundle.Digest)
 	})
 
+	t.Run("When a bundle closure lock is provided, it copies every image of the closure to repo", func(t *testing.T) {
+		assets := &helpers.Assets{T: t}
+		defer assets.CleanCreatedFolders()
+
+		reg := fakeRegistry.Build()
+		closureLock, err := bundle.NewBundle(bundleWithNestedBundle.RefDigest, reg).ClosureLock(1, subject.ui)
+		require.NoError(t, err)
+		require.Len(t, closureLock.Bundles, 2)
+		require.Len(t, closureLock.Images, 2)
+
+		closureLockPath := filepath.Join(assets.CreateTempFolder("bundle-closure-lock"), "lock.yml")
+		require.NoError(t, closureLock.WriteToPath(closureLockPath))
+
+		subject := subject
+		subject.BundleFlags.Bundle = ""
+		subject.LockInputFlags.LockFilePath = closureLockPath
+		subject.registry = reg
+
+		destRepo := fakeRegistry.ReferenceOnTestServer("library/bundle-closure-copy")
+		processedImages, err := subject.CopyToRepo(destRepo)
+		require.NoError(t, err)
+
+		processedImageDigest := []string{}
+		for _, processedImage := range processedImages.All() {
+			processedImageDigest = append(processedImageDigest, processedImage.DigestRef)
+		}
+		assert.ElementsMatch(t, processedImageDigest, []string{
+			destRepo + "@" + bundleWithNestedBundle.Digest,
+			destRepo + "@" + bundleWithTwoImages.Digest,
+			destRepo + "@" + randomImage.Digest,
+			destRepo + "@" + randomImage2.Digest,
+		})
+
+		copiedClosureLock, err := bundle.NewBundle(destRepo+"@"+bundleWithNestedBundle.Digest, fakeRegistry.Build()).ClosureLock(1, subject.ui)
+		require.NoError(t, err)
+		assert.Equal(t, []lockconfig.ClosureBundleRef{
+			{Image: destRepo + "@" + bundleWithNestedBundle.Digest, Images: []string{bundleWithTwoImages.RefDigest}},
+			{Image: destRepo + "@" + bundleWithTwoImages.Digest, Images: sortedStrings(randomImage.RefDigest, randomImage2.RefDigest)},
+		}, copiedClosureLock.Bundles)
+		assert.ElementsMatch(t, []lockconfig.ClosureImageRef{
+			{Image: destRepo + "@" + randomImage.Digest},
+			{Image: destRepo + "@" + randomImage2.Digest},
+		}, copiedClosureLock.Images)
+	})
+
 	t.Run("When recursive bundle is enabled and an images lock file is provided, it returns an error message to the user", func(t *testing.T) {
 		assets := &helpers.Assets{T: t}
 		defer assets.CleanCreatedFolders()
@@ -1097,7 +1484,7 @@ images:
 		subject := subject
 		subject.ImageFlags.Image = image2RefDigest
 		subject.registry = fakeRegistry.BuildWithRegistryOpts(registry.Opts{
-			RetryCount: 2,
+			RetryPolicy: util.RetryPolicy{MaxAttempts: 2},
 		})
 		numberOfTries := 0
 
@@ -1254,3 +1641,302 @@ func downloadImagesLocation(t *testing.T, imgRef, location string) {
 	err = ctlimg.NewDirImage(filepath.Join(location), img, writerUI).AsDirectory()
 	require.NoError(t, err)
 }
+
+func TestToRepoFromTarWithCosignArtifacts(t *testing.T) {
+	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
+	fakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer fakeRegistry.CleanUp()
+
+	image := fakeRegistry.WithRandomImage("library/image")
+	artifactTag := func(suffix string) string {
+		return strings.ReplaceAll(image.Digest, ":", "-") + "." + suffix
+	}
+	sigImage := fakeRegistry.WithRandomImage("library/image:" + artifactTag("sig"))
+	attImage := fakeRegistry.WithRandomImage("library/image:" + artifactTag("att"))
+	sbomImage := fakeRegistry.WithRandomImage("library/image:" + artifactTag("sbom"))
+
+	reg := fakeRegistry.Build()
+
+	subject := subject
+	subject.ImageFlags = ImageFlags{image.RefDigest}
+	subject.registry = reg
+	subject.signatureRetriever = signature.NewArtifacts(signature.NewCosign(reg), []signature.ArtifactKind{signature.AttestationArtifact, signature.SBOMArtifact}, 1)
+
+	assets := &helpers.Assets{T: t}
+	defer assets.CleanCreatedFolders()
+	tarFile := filepath.Join(assets.CreateTempFolder("tar-with-cosign-artifacts"), "image.tar")
+
+	require.NoError(t, subject.CopyToTar(tarFile))
+
+	destFakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer destFakeRegistry.CleanUp()
+	destRepo := destFakeRegistry.ReferenceOnTestServer("library/image-copy")
+
+	subject.ImageFlags = ImageFlags{}
+	subject.TarFlags.TarSrc = tarFile
+	subject.registry = destFakeRegistry.Build()
+	subject.signatureRetriever = signature.NewNoop()
+
+	_, err := subject.CopyToRepo(destRepo)
+	require.NoError(t, err)
+
+	for suffix, artifact := range map[string]*helpers.ImageOrImageIndexWithTarPath{"att": attImage, "sbom": sbomImage} {
+		artifactRef, err := name.NewTag(destRepo + ":" + artifactTag(suffix))
+		require.NoError(t, err)
+
+		desc, err := remote.Head(artifactRef)
+		require.NoError(t, err, "expected %s to be copied", artifactRef)
+		assert.Equal(t, artifact.Digest, desc.Digest.String())
+	}
+
+	sigRef, err := name.NewTag(destRepo + ":" + artifactTag("sig"))
+	require.NoError(t, err)
+	_, err = remote.Head(sigRef)
+	require.Error(t, err, "expected signature %s not to be copied", sigImage.Digest)
+}
+
+func TestToRepoFromTarWithReferrers(t *testing.T) {
+	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
+	fakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer fakeRegistry.CleanUp()
+
+	image := fakeRegistry.WithRandomImage("library/image")
+	imageDigest, err := regv1.NewHash(image.Digest)
+	require.NoError(t, err)
+	imageSize, err := image.Image.Size()
+	require.NoError(t, err)
+
+	// Registries without the referrers API list the referrers in an index tagged with the digest of the subject
+	artifact := imageWithSubject(t, image.Image, regv1.Descriptor{MediaType: types.OCIManifestSchema1, Digest: imageDigest, Size: imageSize}, "")
+	referrersIndex := fakeRegistry.WithImageIndex("library/image:"+strings.ReplaceAll(image.Digest, ":", "-"), artifact)
+
+	reg := fakeRegistry.Build()
+
+	subject := subject
+	subject.ImageFlags = ImageFlags{image.RefDigest}
+	subject.registry = reg
+	subject.signatureRetriever = signature.NewReferrers(reg, nil, 1)
+
+	assets := &helpers.Assets{T: t}
+	defer assets.CleanCreatedFolders()
+	tarFile := filepath.Join(assets.CreateTempFolder("tar-with-referrers"), "image.tar")
+
+	require.NoError(t, subject.CopyToTar(tarFile))
+
+	destFakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer destFakeRegistry.CleanUp()
+	destRepo := destFakeRegistry.ReferenceOnTestServer("library/image-copy")
+
+	subject.ImageFlags = ImageFlags{}
+	subject.TarFlags.TarSrc = tarFile
+	subject.registry = destFakeRegistry.Build()
+	subject.signatureRetriever = signature.NewNoop()
+
+	_, err = subject.CopyToRepo(destRepo)
+	require.NoError(t, err)
+
+	artifactDigest, err := artifact.Digest()
+	require.NoError(t, err)
+	artifactRef, err := name.NewDigest(destRepo + "@" + artifactDigest.String())
+	require.NoError(t, err)
+	desc, err := remote.Get(artifactRef)
+	require.NoError(t, err, "expected referrer %s to be copied", artifactRef)
+	assert.Contains(t, string(desc.Manifest), `"subject":{"mediaType":"application/vnd.oci.image.manifest.v1+json","size":`)
+	assert.Contains(t, string(desc.Manifest), image.Digest)
+
+	indexRef, err := name.NewTag(destRepo + ":" + strings.ReplaceAll(image.Digest, ":", "-"))
+	require.NoError(t, err)
+	desc, err = remote.Get(indexRef)
+	require.NoError(t, err, "expected referrers index %s to be copied", indexRef)
+	assert.Equal(t, referrersIndex.Digest, desc.Digest.String())
+}
+
+func TestToRepoWithReferrersFilteredByArtifactType(t *testing.T) {
+	logger := &helpers.Logger{LogLevel: helpers.LogDebug}
+	fakeRegistry := helpers.NewFakeRegistry(t, logger)
+	defer fakeRegistry.CleanUp()
+
+	image := fakeRegistry.WithRandomImage("library/image")
+	imageDigest, err := regv1.NewHash(image.Digest)
+	require.NoError(t, err)
+	imageSize, err := image.Image.Size()
+	require.NoError(t, err)
+	imageDesc := regv1.Descriptor{MediaType: types.OCIManifestSchema1, Digest: imageDigest, Size: imageSize}
+
+	sbom := imageWithSubject(t, image.Image, imageDesc, "application/spdx+json")
+	notarySignature := imageWithSubject(t, image.Image, imageDesc, "application/vnd.cncf.notary.signature")
+	fakeRegistry.WithImage("library/image:sbom", sbom)
+	fakeRegistry.WithImage("library/image:notary-signature", notarySignature)
+	reg := fakeRegistry.Build()
+
+	fallbackTag := strings.ReplaceAll(image.Digest, ":", "-")
+	writeReferrersIndex(t, fakeRegistry.ReferenceOnTestServer("library/image:"+fallbackTag),
+		referrerArtifact{sbom, "application/spdx+json"},
+		referrerArtifact{notarySignature, "application/vnd.cncf.notary.signature"})
+
+	// The destination already lists an artifact that was not copied, which must be kept
+	destRepo := fakeRegistry.ReferenceOnTestServer("library/image-copy")
+	existingArtifact := imageWithSubject(t, image.Image, imageDesc, "application/vnd.example.report")
+	existingArtifactRef, err := name.ParseReference(destRepo + ":report")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(existingArtifactRef, existingArtifact))
+	writeReferrersIndex(t, destRepo+":"+fallbackTag, referrerArtifact{existingArtifact, "application/vnd.example.report"})
+
+	subject := subject
+	subject.ImageFlags = ImageFlags{image.RefDigest}
+	subject.registry = reg
+	subject.signatureRetriever = signature.NewReferrers(reg, []string{"application/spdx+json"}, 1)
+	subject.referrersFallback = signature.NewReferrersFallback(reg)
+
+	_, err = subject.CopyToRepo(destRepo)
+	require.NoError(t, err)
+
+	indexRef, err := name.NewTag(destRepo + ":" + fallbackTag)
+	require.NoError(t, err)
+	desc, err := remote.Get(indexRef)
+	require.NoError(t, err)
+	index, err := regv1.ParseIndexManifest(bytes.NewReader(desc.Manifest))
+	require.NoError(t, err)
+
+	var listedDigests []string
+	for _, manifest := range index.Manifests {
+		listedDigests = append(listedDigests, manifest.Digest.String())
+	}
+	existingArtifactDigest, err := existingArtifact.Digest()
+	require.NoError(t, err)
+	sbomDigest, err := sbom.Digest()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{existingArtifactDigest.String(), sbomDigest.String()}, listedDigests)
+	assert.Contains(t, string(desc.Manifest), `"artifactType":"application/spdx+json"`)
+}
+
+// writeReferrersIndex Writes an index of the referrers tag schema listing the provided artifacts with their artifactType
+func writeReferrersIndex(t *testing.T, ref string, artifacts ...referrerArtifact) {
+	var manifests []map[string]interface{}
+	for _, artifact := range artifacts {
+		digest, err := artifact.image.Digest()
+		require.NoError(t, err)
+		size, err := artifact.image.Size()
+		require.NoError(t, err)
+		manifests = append(manifests, map[string]interface{}{
+			"mediaType":    types.OCIManifestSchema1,
+			"digest":       digest.String(),
+			"size":         size,
+			"artifactType": artifact.artifactType,
+		})
+	}
+
+	rawIndex, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     types.OCIImageIndex,
+		"manifests":     manifests,
+	})
+	require.NoError(t, err)
+
+	tag, err := name.NewTag(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.Put(tag, rawReferrersIndex(rawIndex)))
+}
+
+type referrerArtifact struct {
+	image        regv1.Image
+	artifactType string
+}
+
+type rawReferrersIndex []byte
+
+func (i rawReferrersIndex) RawManifest() ([]byte, error) { return i, nil }
+
+func (i rawReferrersIndex) MediaType() (types.MediaType, error) { return types.OCIImageIndex, nil }
+
+// imageWithSubjectManifest Image with the subject field added to its manifest
+type imageWithSubjectManifest struct {
+	regv1.Image
+	rawManifest []byte
+}
+
+func imageWithSubject(t *testing.T, subjectImage regv1.Image, subject regv1.Descriptor, artifactType string) regv1.Image {
+	img, err := random.Image(500, 1)
+	require.NoError(t, err)
+
+	rawManifest, err := img.RawManifest()
+	require.NoError(t, err)
+	manifest := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rawManifest, &manifest))
+	manifest["subject"] = subject
+	if len(artifactType) > 0 {
+		manifest["artifactType"] = artifactType
+	}
+
+	rawManifest, err = json.Marshal(manifest)
+	require.NoError(t, err)
+
+	return imageWithSubjectManifest{Image: img, rawManifest: rawManifest}
+}
+
+func (i imageWithSubjectManifest) RawManifest() ([]byte, error) { return i.rawManifest, nil }
+
+func (i imageWithSubjectManifest) Digest() (regv1.Hash, error) {
+	hash, _, err := regv1.SHA256(bytes.NewReader(i.rawManifest))
+	return hash, err
+}
+
+func (i imageWithSubjectManifest) Size() (int64, error) { return int64(len(i.rawManifest)), nil }
+
+func TestToRepoBundleWithSignaturePolicy(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	signedImage := fakeRegistry.WithRandomImage("library/signed-image")
+	unsignedImage := fakeRegistry.WithRandomImage("library/unsigned-image")
+	bundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle_with_mult_images").
+		WithImageRefs([]lockconfig.ImageRef{
+			{Image: signedImage.RefDigest},
+			{Image: unsignedImage.RefDigest},
+		})
+	reg := fakeRegistry.Build()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	for _, refDigest := range []string{signedImage.RefDigest, bundle.RefDigest} {
+		imgDigest, err := name.NewDigest(refDigest)
+		require.NoError(t, err)
+		_, err = signature.NewCosignSigner(reg, key).Sign(imgDigest)
+		require.NoError(t, err)
+	}
+
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	policy, err := signature.NewPolicyFromBytes([]byte(fmt.Sprintf(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: SignaturePolicy
+rules:
+- repositories: ["%s"]
+  requireSignature: true
+  publicKeys:
+  - pem: |
+%s`, fakeRegistry.Host(), indent(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})), "      "))), "")
+	require.NoError(t, err)
+
+	subject := subject
+	subject.BundleFlags = BundleFlags{bundle.RefDigest}
+	subject.registry = reg
+	subject.signaturePolicyVerifier = signature.NewPolicyVerifier(policy, signature.NewCosign(reg), reg, 1)
+
+	destRepo := fakeRegistry.ReferenceOnTestServer("library/bundle-copy")
+	_, err = subject.CopyToRepo(destRepo)
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf("Signature policy violations:\n- Image '%s' is not signed", unsignedImage.RefDigest), err.Error())
+
+	destRef, err := name.NewRepository(destRepo)
+	require.NoError(t, err)
+	_, err = remote.List(destRef)
+	require.Error(t, err, "expected nothing to be copied to %s", destRepo)
+}
+
+func indent(text string, prefix string) string {
+	return prefix + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n"+prefix) + "\n"
+}
+
+func sortedStrings(values ...string) []string {
+	sort.Strings(values)
+	return values
+}
@@ -41,6 +41,28 @@ func TestMultiSrc(t *testing.T) {
 	}
 }
 
+func TestMultiSrcWithTarDst(t *testing.T) {
+	err := (&CopyOptions{LockInputFlags: LockInputFlags{LockFilePath: "foo"}, AdditionalBundles: []string{"bar"}, TarFlags: TarFlags{TarSrc: "foo"}}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected either --lock, --bundle (-b), --image (-i), or --tar as a source") {
+		t.Fatalf("Expected error message related to sources, got: %s", err)
+	}
+}
+
+func TestBundleFilterWithoutTarSrc(t *testing.T) {
+	err := (&CopyOptions{BundleFlags: BundleFlags{"foo"}, RepoDst: "bar", BundleFilters: []string{"*/foo"}}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --tar as a source when using --bundle-filter") {
+		t.Fatalf("Expected error message related to the bundle filter, got: %s", err)
+	}
+}
+
 func TestNoSrc(t *testing.T) {
 	err := (&CopyOptions{}).Run()
 	if err == nil {
@@ -62,3 +84,76 @@ func TestTarSrcWithTarDst(t *testing.T) {
 		t.Fatalf("Expected error message related to destinations, got: %s", err)
 	}
 }
+
+func TestSignaturePolicyWithTarSrc(t *testing.T) {
+	err := (&CopyOptions{RepoDst: "foo", TarFlags: TarFlags{TarSrc: "foo"}, SignatureFlags: SignatureFlags{PolicyPath: "policy.yml"}}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Signature policy (--signature-policy) cannot be used with tar source (--tar)") {
+		t.Fatalf("Expected error message related to signature policy, got: %s", err)
+	}
+}
+
+func TestEncryptRecipientWithoutTarDst(t *testing.T) {
+	err := (&CopyOptions{RepoDst: "foo", ImageFlags: ImageFlags{"foo"}, TarFlags: TarFlags{EncryptRecipients: []string{"age1foo"}}}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --to-tar as a destination when using --encrypt-recipient") {
+		t.Fatalf("Expected error message related to --encrypt-recipient, got: %s", err)
+	}
+}
+
+func TestDecryptIdentityWithoutTarSrc(t *testing.T) {
+	err := (&CopyOptions{RepoDst: "foo", ImageFlags: ImageFlags{"foo"}, TarFlags: TarFlags{DecryptIdentities: []string{"identity.txt"}}}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --tar as a source when using --decrypt-identity") {
+		t.Fatalf("Expected error message related to --decrypt-identity, got: %s", err)
+	}
+}
+
+func TestTarSigningKeyWithoutTarDst(t *testing.T) {
+	err := (&CopyOptions{RepoDst: "foo", ImageFlags: ImageFlags{"foo"}, TarFlags: TarFlags{SigningKeyPath: "cosign.key"}}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --to-tar as a destination when using --tar-signing-key") {
+		t.Fatalf("Expected error message related to --tar-signing-key, got: %s", err)
+	}
+}
+
+func TestTarTrustedKeyWithoutTarSrc(t *testing.T) {
+	err := (&CopyOptions{RepoDst: "foo", ImageFlags: ImageFlags{"foo"}, TarFlags: TarFlags{TrustedKeyPath: "cosign.pub"}}).Run()
+	if err == nil {
+		t.Fatalf("Expected Run() to err")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --tar as a source when using --tar-trusted-key") {
+		t.Fatalf("Expected error message related to --tar-trusted-key, got: %s", err)
+	}
+}
+
+func TestCopyCmdRepeatedSources(t *testing.T) {
+	opts := NewCopyOptions(nil)
+	err := NewCopyCmd(opts).ParseFlags([]string{"-b", "bundle1", "-b", "bundle2", "-i", "image1", "--lock", "lock1", "--lock", "lock2"})
+	if err != nil {
+		t.Fatalf("Expected flags to be parsed, got: %s", err)
+	}
+
+	if opts.BundleFlags.Bundle != "bundle1" || strings.Join(opts.AdditionalBundles, ",") != "bundle2" {
+		t.Fatalf("Expected bundles to be bundle1 and bundle2, got: %s and %v", opts.BundleFlags.Bundle, opts.AdditionalBundles)
+	}
+	if opts.ImageFlags.Image != "image1" || len(opts.AdditionalImages) != 0 {
+		t.Fatalf("Expected image to be image1, got: %s and %v", opts.ImageFlags.Image, opts.AdditionalImages)
+	}
+	if opts.LockInputFlags.LockFilePath != "lock1" || strings.Join(opts.AdditionalLockFilePaths, ",") != "lock2" {
+		t.Fatalf("Expected locks to be lock1 and lock2, got: %s and %v", opts.LockInputFlags.LockFilePath, opts.AdditionalLockFilePaths)
+	}
+}
@@ -15,6 +15,7 @@ func (i *ImageFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&i.Image, "image", "i", "", "Set image (example: docker.io/dkalinin/test-content)")
 }
 
-func (i *ImageFlags) SetCopy(cmd *cobra.Command) {
-	cmd.Flags().StringVarP(&i.Image, "image", "i", "", "Image reference for copying a generic image (example: docker.io/dkalinin/test-content)")
+// SetCopy Registers the image flag of copy, images provided after the first one are added to additionalImages
+func (i *ImageFlags) SetCopy(cmd *cobra.Command, additionalImages *[]string) {
+	cmd.Flags().VarP(newRepeatableStringValue(&i.Image, additionalImages), "image", "i", "Image reference for copying a generic image (example: docker.io/dkalinin/test-content) (can be specified multiple times with --to-tar)")
 }
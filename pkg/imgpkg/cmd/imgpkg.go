@@ -55,9 +55,26 @@ func NewImgpkgCmd(o *ImgpkgOptions) *cobra.Command {
 	tagCmd.AddCommand(NewTagResolveCmd(NewTagResolveOptions(o.ui)))
 	cmd.AddCommand(tagCmd)
 
+	lockCmd := NewLockCmd()
+	lockCmd.AddCommand(NewLockValidateCmd(NewLockValidateOptions(o.ui)))
+	lockCmd.AddCommand(NewLockMergeCmd(NewLockMergeOptions(o.ui)))
+	lockCmd.AddCommand(NewLockDiffCmd(NewLockDiffOptions(o.ui)))
+	lockCmd.AddCommand(NewLockConvertCmd(NewLockConvertOptions(o.ui)))
+	lockCmd.AddCommand(NewLockGenerateCmd(NewLockGenerateOptions(o.ui)))
+	cmd.AddCommand(lockCmd)
+
+	bundleCmd := NewBundleCmd()
+	bundleCmd.AddCommand(NewBundleLintCmd(NewBundleLintOptions(o.ui)))
+	cmd.AddCommand(bundleCmd)
+
+	tarCmd := NewTarCmd()
+	tarCmd.AddCommand(NewTarListCmd(NewTarListOptions(o.ui)))
+	tarCmd.AddCommand(NewTarVerifyCmd(NewTarVerifyOptions(o.ui)))
+	cmd.AddCommand(tarCmd)
+
 	// Last one runs first
 	cobrautil.VisitCommands(cmd, cobrautil.ReconfigureCmdWithSubcmd)
-	cobrautil.VisitCommands(cmd, cobrautil.DisallowExtraArgs)
+	cobrautil.VisitCommands(cmd, disallowExtraArgs)
 
 	cobrautil.VisitCommands(cmd, cobrautil.WrapRunEForCmd(func(*cobra.Command, []string) error {
 		o.UIFlags.ConfigureUI(o.ui)
@@ -75,6 +92,14 @@ func NewImgpkgCmd(o *ImgpkgOptions) *cobra.Command {
 	return cmd
 }
 
+// disallowExtraArgs rejects positional arguments, except for the commands that declare the arguments they accept
+func disallowExtraArgs(cmd *cobra.Command) {
+	if cmd.Args != nil {
+		return
+	}
+	cobrautil.DisallowExtraArgs(cmd)
+}
+
 type uiBlockWriter struct {
 	ui ui.UI
 }
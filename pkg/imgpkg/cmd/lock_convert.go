@@ -0,0 +1,66 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+type LockConvertOptions struct {
+	ui ui.UI
+
+	LockPath       string
+	LockOutputPath string
+	APIVersion     string
+}
+
+func NewLockConvertOptions(ui ui.UI) *LockConvertOptions {
+	return &LockConvertOptions{ui: ui}
+}
+
+func NewLockConvertCmd(o *LockConvertOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert a lock file to another apiVersion",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Convert a lock file to the newest apiVersion of its kind
+  imgpkg lock convert --lock bundle.lock.yml --lock-output bundle.lock.yml`,
+	}
+	cmd.Flags().StringVar(&o.LockPath, "lock", "", "Lock file to convert")
+	cmd.Flags().StringVar(&o.LockOutputPath, "lock-output", "", "Location to output the converted lock, printed when not provided")
+	cmd.Flags().StringVar(&o.APIVersion, "api-version", "", "apiVersion to convert to, the newest apiVersion of the lock kind when not provided")
+	return cmd
+}
+
+func (o *LockConvertOptions) Run() error {
+	if len(o.LockPath) == 0 {
+		return fmt.Errorf("Expected --lock")
+	}
+
+	lock, err := lockconfig.NewAnyLockFromPath(o.LockPath)
+	if err != nil {
+		return fmt.Errorf("Reading '%s': %s", o.LockPath, err)
+	}
+
+	converted, err := lockconfig.Convert(lock, o.APIVersion)
+	if err != nil {
+		return err
+	}
+
+	if len(o.LockOutputPath) > 0 {
+		return converted.WriteToPath(o.LockOutputPath)
+	}
+
+	bs, err := converted.AsBytes()
+	if err != nil {
+		return err
+	}
+	o.ui.PrintBlock(bs)
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+type LockDiffOptions struct {
+	ui ui.UI
+
+	LockPaths []string
+}
+
+func NewLockDiffOptions(ui ui.UI) *LockDiffOptions {
+	return &LockDiffOptions{ui: ui}
+}
+
+func NewLockDiffCmd(o *LockDiffOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show the differences between two lock files",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Show what changed between two releases of a bundle
+  imgpkg lock diff --lock v1.0.0/bundle.lock.yml --lock v1.1.0/bundle.lock.yml`,
+	}
+	cmd.Flags().StringSliceVar(&o.LockPaths, "lock", nil, "Lock files to compare, the first one is compared to the second one (specified twice)")
+	return cmd
+}
+
+func (o *LockDiffOptions) Run() error {
+	if len(o.LockPaths) != 2 {
+		return fmt.Errorf("Expected exactly two --lock")
+	}
+
+	var locks []lockconfig.Lock
+	for _, lockPath := range o.LockPaths {
+		lock, err := lockconfig.NewAnyLockFromPath(lockPath)
+		if err != nil {
+			return fmt.Errorf("Reading '%s': %s", lockPath, err)
+		}
+		locks = append(locks, lock)
+	}
+
+	table := uitable.Table{
+		Title:   "Differences",
+		Content: "differences",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Field"),
+			uitable.NewHeader("From"),
+			uitable.NewHeader("To"),
+		},
+	}
+
+	for _, change := range lockconfig.Diff(locks[0], locks[1]) {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(change.Field),
+			uitable.NewValueString(change.From),
+			uitable.NewValueString(change.To),
+		})
+	}
+
+	o.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagescan"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+type LockGenerateOptions struct {
+	ui ui.UI
+
+	FileFlags     FileFlags
+	RegistryFlags RegistryFlags
+
+	ImagePaths []string
+	OutputPath string
+}
+
+func NewLockGenerateOptions(ui ui.UI) *LockGenerateOptions {
+	return &LockGenerateOptions{ui: ui}
+}
+
+func NewLockGenerateCmd(o *LockGenerateOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate ImagesLock from the images referenced in Kubernetes manifests",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Generate the images lock of a bundle from the workloads in config/
+  imgpkg lock generate -f config/ --output .imgpkg/images.yml
+
+  # Also find the images of a custom resource
+  imgpkg lock generate -f config/ --image-path 'App:$.spec.components[*].image' --output .imgpkg/images.yml`,
+	}
+	o.FileFlags.Set(cmd)
+	o.RegistryFlags.Set(cmd)
+	cmd.Flags().StringSliceVar(&o.ImagePaths, "image-path", nil, "Additional path of image references (format: [kind:]jsonpath, example: App:$.spec.components[*].image) (can be specified multiple times)")
+	cmd.Flags().StringVar(&o.OutputPath, "output", "", "Location to output the generated ImagesLock, printed when not provided")
+	return cmd
+}
+
+func (o *LockGenerateOptions) Run() error {
+	if len(o.FileFlags.Files) == 0 {
+		return fmt.Errorf("Expected at least one --file")
+	}
+
+	imagePaths := imagescan.DefaultImagePaths()
+	for _, value := range o.ImagePaths {
+		imagePath, err := imagescan.NewImagePath(value)
+		if err != nil {
+			return err
+		}
+		imagePaths = append(imagePaths, imagePath)
+	}
+
+	foundImages, err := imagescan.NewScanner(imagePaths).ScanPaths(o.FileFlags.Files, o.FileFlags.ExcludedFilePaths)
+	if err != nil {
+		return err
+	}
+
+	reg, err := registry.NewSimpleRegistry(o.RegistryFlags.AsRegistryOpts(o.ui))
+	if err != nil {
+		return err
+	}
+
+	imagesLock, err := bundle.NewTagResolver(reg, o.ui).Resolve(imagescan.NewImagesLock(foundImages))
+	if err != nil {
+		return err
+	}
+
+	if len(o.OutputPath) > 0 {
+		return imagesLock.WriteToPath(o.OutputPath)
+	}
+
+	bs, err := imagesLock.AsBytes()
+	if err != nil {
+		return err
+	}
+	o.ui.PrintBlock(bs)
+	return nil
+}
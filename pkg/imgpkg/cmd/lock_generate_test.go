@@ -0,0 +1,76 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagescan"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestLockGenerate(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	nginx := fakeRegistry.WithRandomImage("library/nginx:1.25")
+	app := fakeRegistry.WithRandomImage("library/app:v1")
+	fakeRegistry.Build()
+
+	configDir := t.TempDir()
+	deploymentPath := filepath.Join(configDir, "deployment.yml")
+	require.NoError(t, os.WriteFile(deploymentPath, []byte(`apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: `+fakeRegistry.ReferenceOnTestServer("library/nginx:1.25")+`
+`), 0600))
+	appPath := filepath.Join(configDir, "app.yml")
+	require.NoError(t, os.WriteFile(appPath, []byte(`kind: App
+spec:
+  image: `+app.RefDigest+`
+`), 0600))
+
+	outputPath := filepath.Join(t.TempDir(), "images.yml")
+	subject := LockGenerateOptions{
+		ui:         &bundlefakes.FakeUI{},
+		FileFlags:  FileFlags{Files: []string{configDir}},
+		ImagePaths: []string{"App:spec.image"},
+		OutputPath: outputPath,
+	}
+	require.NoError(t, subject.Run())
+
+	imagesLock, err := lockconfig.NewImagesLockFromPath(outputPath)
+	require.NoError(t, err)
+	require.Len(t, imagesLock.Images, 2)
+
+	assert.Equal(t, app.RefDigest, imagesLock.Images[0].Image)
+	assert.Equal(t, map[string]string{
+		imagescan.SourceFileAnnotation: appPath,
+		imagescan.SourcePathAnnotation: "spec.image",
+	}, imagesLock.Images[0].Annotations)
+
+	assert.Equal(t, nginx.RefDigest, imagesLock.Images[1].Image)
+	assert.Equal(t, map[string]string{
+		imagescan.SourceFileAnnotation: deploymentPath,
+		imagescan.SourcePathAnnotation: "spec.template.spec.containers[0].image",
+		bundle.OriginalTagAnnotation:   "1.25",
+	}, imagesLock.Images[1].Annotations)
+}
+
+func TestLockGenerateInvalidImagePathError(t *testing.T) {
+	subject := LockGenerateOptions{ui: &bundlefakes.FakeUI{}, FileFlags: FileFlags{Files: []string{t.TempDir()}}, ImagePaths: []string{"App:spec.images[a]"}}
+	err := subject.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Parsing image path 'App:spec.images[a]'")
+}
@@ -15,3 +15,9 @@ func (l *LockInputFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&l.LockFilePath, "lock", "",
 		"Lock file with asset references to copy to destination")
 }
+
+// SetCopy Registers the lock flag of copy, lock files provided after the first one are added to additionalLockFilePaths
+func (l *LockInputFlags) SetCopy(cmd *cobra.Command, additionalLockFilePaths *[]string) {
+	cmd.Flags().Var(newRepeatableStringValue(&l.LockFilePath, additionalLockFilePaths), "lock",
+		"Lock file with asset references to copy to destination (can be specified multiple times with --to-tar)")
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+type LockMergeOptions struct {
+	ui ui.UI
+
+	LockPaths      []string
+	LockOutputPath string
+}
+
+func NewLockMergeOptions(ui ui.UI) *LockMergeOptions {
+	return &LockMergeOptions{ui: ui}
+}
+
+func NewLockMergeCmd(o *LockMergeOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge",
+		Short: "Merge ImagesLock files",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Merge the images of two components into the images lock of a bundle
+  imgpkg lock merge --lock component-a/images.yml --lock component-b/images.yml --lock-output bundle/.imgpkg/images.yml`,
+	}
+	cmd.Flags().StringSliceVar(&o.LockPaths, "lock", nil, "ImagesLock file to merge (can be specified multiple times)")
+	cmd.Flags().StringVar(&o.LockOutputPath, "lock-output", "", "Location to output the merged ImagesLock, printed when not provided")
+	return cmd
+}
+
+func (o *LockMergeOptions) Run() error {
+	if len(o.LockPaths) < 2 {
+		return fmt.Errorf("Expected at least two --lock")
+	}
+
+	var locks []lockconfig.ImagesLock
+	for _, lockPath := range o.LockPaths {
+		lock, err := lockconfig.NewImagesLockFromPath(lockPath)
+		if err != nil {
+			return fmt.Errorf("Reading '%s': %s", lockPath, err)
+		}
+		locks = append(locks, lock)
+	}
+
+	merged, conflicts := lockconfig.MergeImagesLocks(locks...)
+	if len(conflicts) > 0 {
+		var descriptions []string
+		for _, conflict := range conflicts {
+			var lockIdxs []int
+			for lockIdx := range conflict.Values {
+				lockIdxs = append(lockIdxs, lockIdx)
+			}
+			sort.Ints(lockIdxs)
+
+			var values []string
+			for _, lockIdx := range lockIdxs {
+				values = append(values, fmt.Sprintf("'%s' in '%s'", conflict.Values[lockIdx], o.LockPaths[lockIdx]))
+			}
+			descriptions = append(descriptions, fmt.Sprintf("Annotation '%s' of image '%s' has different values: %s",
+				conflict.Annotation, conflict.Image, strings.Join(values, ", ")))
+		}
+		return fmt.Errorf("Merging images locks:\n- %s", strings.Join(descriptions, "\n- "))
+	}
+
+	if len(o.LockOutputPath) > 0 {
+		return merged.WriteToPath(o.LockOutputPath)
+	}
+
+	bs, err := merged.AsBytes()
+	if err != nil {
+		return err
+	}
+	o.ui.PrintBlock(bs)
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestLockMerge(t *testing.T) {
+	app := "registry.io/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"
+	db := "registry.io/db@sha256:cf31af331f38d1d7158470e095b132acd126a7180a54f263d386da88eb681d93"
+	dir := t.TempDir()
+
+	writeLock := func(name string, content string) string {
+		lockPath := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(lockPath, []byte(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+`+content), 0600))
+		return lockPath
+	}
+	componentA := writeLock("component-a.yml", "- image: "+app+"\n  annotations:\n    team: a\n")
+	componentB := writeLock("component-b.yml", "- image: "+db+"\n")
+	conflicting := writeLock("conflicting.yml", "- image: "+app+"\n  annotations:\n    team: b\n")
+
+	t.Run("writes the merged images lock", func(t *testing.T) {
+		outputPath := filepath.Join(dir, "images.yml")
+		subject := LockMergeOptions{ui: &bundlefakes.FakeUI{}, LockPaths: []string{componentA, componentB}, LockOutputPath: outputPath}
+		require.NoError(t, subject.Run())
+
+		merged, err := lockconfig.NewImagesLockFromPath(outputPath)
+		require.NoError(t, err)
+		require.Len(t, merged.Images, 2)
+		assert.Equal(t, app, merged.Images[0].Image)
+		assert.Equal(t, db, merged.Images[1].Image)
+	})
+
+	t.Run("when annotations have different values it reports the lock files", func(t *testing.T) {
+		subject := LockMergeOptions{ui: &bundlefakes.FakeUI{}, LockPaths: []string{componentA, componentB, conflicting}}
+		err := subject.Run()
+		require.Error(t, err)
+		assert.Equal(t, "Merging images locks:\n- Annotation 'team' of image '"+app+"' has different values: "+
+			"'a' in '"+componentA+"', 'b' in '"+conflicting+"'", err.Error())
+	})
+}
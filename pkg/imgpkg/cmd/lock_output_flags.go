@@ -9,9 +9,12 @@ import (
 
 type LockOutputFlags struct {
 	LockFilePath string
+	Closure      bool
 }
 
 func (l *LockOutputFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&l.LockFilePath, "lock-output", "",
 		"Location to output the generated lockfile. Option only available when using --bundle or --lock flags")
+	cmd.Flags().BoolVar(&l.Closure, "lock-output-closure", false,
+		"Output a BundleClosureLock, that records every nested bundle and image of the bundle, instead of a BundleLock")
 }
@@ -0,0 +1,60 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+type LockValidateOptions struct {
+	ui ui.UI
+
+	LockPaths []string
+}
+
+func NewLockValidateOptions(ui ui.UI) *LockValidateOptions {
+	return &LockValidateOptions{ui: ui}
+}
+
+func NewLockValidateCmd(o *LockValidateOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate lock files",
+		RunE:  func(_ *cobra.Command, _ []string) error { return o.Run() },
+		Example: `
+  # Validate a BundleLock, BundleClosureLock or ImagesLock file
+  imgpkg lock validate --lock bundle.lock.yml
+
+  # Validate multiple lock files
+  imgpkg lock validate --lock bundle.lock.yml --lock .imgpkg/images.yml`,
+	}
+	cmd.Flags().StringSliceVar(&o.LockPaths, "lock", nil, "Lock file to validate (can be specified multiple times)")
+	return cmd
+}
+
+func (o *LockValidateOptions) Run() error {
+	if len(o.LockPaths) == 0 {
+		return fmt.Errorf("Expected at least one --lock")
+	}
+
+	var errs []string
+	for _, lockPath := range o.LockPaths {
+		lock, err := lockconfig.NewAnyLockFromPath(lockPath)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Validating '%s': %s", lockPath, err))
+			continue
+		}
+		o.ui.BeginLinef("Validated '%s' (%s)\n", lockPath, lockconfig.VersionOf(lock).Kind)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
@@ -5,15 +5,21 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/cppforlife/go-cli-ui/ui"
 	"github.com/spf13/cobra"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
 )
 
+// autoBundleRef is used as bundle reference to select the root bundle in a tar file
+const autoBundleRef = "auto"
+
 type PullOptions struct {
 	ui ui.UI
 
@@ -23,7 +29,10 @@ type PullOptions struct {
 	BundleFlags          BundleFlags
 	LockInputFlags       LockInputFlags
 	BundleRecursiveFlags BundleRecursiveFlags
+	TarFlags             TarFlags
 	OutputPath           string
+	Concurrency          int
+	MaxBundleDepth       int
 }
 
 func NewPullOptions(ui ui.UI) *PullOptions {
@@ -39,6 +48,12 @@ func NewPullCmd(o *PullOptions) *cobra.Command {
   # Pull bundle repo/app1-bundle and extract into /tmp/app1-bundle
   imgpkg pull -b repo/app1-bundle -o /tmp/app1-bundle
 
+  # Pull bundle repo/app1-bundle and every nested bundle, fetching up to 10 bundles in parallel
+  imgpkg pull -b repo/app1-bundle -o /tmp/app1-bundle --recursive --concurrency 10
+
+  # Pull the bundle copied into the tarball /Volumes/app1-bundle.tar, and every nested bundle, without a registry
+  imgpkg pull --tar /Volumes/app1-bundle.tar -b auto -o /tmp/app1-bundle --recursive
+
   # Pull image repo/app1-image and extract into /tmp/app1-image
   imgpkg pull -i repo/app1-image -o /tmp/app1-image`,
 	}
@@ -48,8 +63,11 @@ func NewPullCmd(o *PullOptions) *cobra.Command {
 	o.BundleFlags.Set(cmd)
 	o.BundleRecursiveFlags.Set(cmd)
 	o.LockInputFlags.Set(cmd)
+	o.TarFlags.SetPull(cmd)
 	cmd.Flags().StringVarP(&o.OutputPath, "output", "o", "", "Output directory path")
 	cmd.MarkFlagRequired("output")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency")
+	cmd.Flags().IntVar(&o.MaxBundleDepth, "max-bundle-depth", 0, "Maximum levels of nested bundles to pull, error when bundles are nested deeper (0 means unlimited)")
 
 	return cmd
 }
@@ -60,31 +78,30 @@ func (po *PullOptions) Run() error {
 		return err
 	}
 
-	reg, err := registry.NewSimpleRegistry(po.RegistryFlags.AsRegistryOpts())
+	if po.TarFlags.IsSrc() {
+		return po.pullFromTar()
+	}
+
+	reg, err := registry.NewSimpleRegistry(po.RegistryFlags.AsRegistryOpts(po.ui))
 	if err != nil {
 		return err
 	}
 
 	switch {
 	case len(po.LockInputFlags.LockFilePath) > 0 || len(po.BundleFlags.Bundle) > 0:
-		bundleRef := po.BundleFlags.Bundle
-
 		if len(po.LockInputFlags.LockFilePath) > 0 {
-			bundleLock, err := lockconfig.NewBundleLockFromPath(po.LockInputFlags.LockFilePath)
+			bundleLock, closureLock, err := lockconfig.NewBundleLockOrClosureLockFromPath(po.LockInputFlags.LockFilePath)
 			if err != nil {
 				return err
 			}
-			bundleRef = bundleLock.Bundle.Image
-		}
-
-		err := bundle.NewBundle(bundleRef, reg).Pull(po.OutputPath, po.ui, po.BundleRecursiveFlags.Recursive)
-		if err != nil {
-			if bundle.IsNotBundleError(err) {
-				return fmt.Errorf("Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
+			if closureLock != nil {
+				// Nested bundles are pulled from the locations recorded in the lock
+				return po.pull(bundle.NewBundleFromClosureLock(*closureLock, reg))
 			}
-			return err
+			return po.pullBundle(bundleLock.Bundle.Image, reg)
 		}
-		return nil
+
+		return po.pullBundle(po.BundleFlags.Bundle, reg)
 
 	case len(po.ImageFlags.Image) > 0:
 		plainImg := plainimage.NewPlainImage(po.ImageFlags.Image, reg)
@@ -114,6 +131,91 @@ func (po *PullOptions) Run() error {
 	}
 }
 
+func (po *PullOptions) pullFromTar() error {
+	identities, err := po.TarFlags.Identities()
+	if err != nil {
+		return err
+	}
+
+	tarReader, err := imagetar.NewTarReader(po.TarFlags.TarSrc).WithIdentities(identities...).Decrypt()
+	if err != nil {
+		return fmt.Errorf("Reading tar file '%s': %s", po.TarFlags.TarSrc, err)
+	}
+	defer tarReader.Close()
+
+	trustedKey, err := po.TarFlags.TrustedKey()
+	if err != nil {
+		return err
+	}
+	if trustedKey != nil {
+		tarReader, err = tarReader.VerifySignature(trustedKey)
+		if err != nil {
+			return fmt.Errorf("Verifying signature of tar file '%s': %s", po.TarFlags.TarSrc, err)
+		}
+	}
+
+	imgOrIndexes, err := tarReader.Read()
+	if err != nil {
+		return fmt.Errorf("Reading tar file '%s': %s", po.TarFlags.TarSrc, err)
+	}
+
+	bundleRef := po.BundleFlags.Bundle
+
+	if len(po.LockInputFlags.LockFilePath) > 0 {
+		bundleLock, closureLock, err := lockconfig.NewBundleLockOrClosureLockFromPath(po.LockInputFlags.LockFilePath)
+		if err != nil {
+			return err
+		}
+		if closureLock != nil {
+			bundleRef = closureLock.Bundle.Image
+		} else {
+			bundleRef = bundleLock.Bundle.Image
+		}
+	}
+
+	if bundleRef == autoBundleRef {
+		bundleRef, err = po.findRootBundleInTar(imgOrIndexes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return po.pullBundle(bundleRef, imagetar.NewTarImagesMetadata(imgOrIndexes))
+}
+
+func (po *PullOptions) findRootBundleInTar(imgOrIndexes []imagedesc.ImageOrIndex) (string, error) {
+	var rootBundleRefs []string
+	for _, imgOrIndex := range imgOrIndexes {
+		if _, ok := imgOrIndex.Labels[rootBundleLabelKey]; ok {
+			rootBundleRefs = append(rootBundleRefs, imgOrIndex.Ref())
+		}
+	}
+
+	switch len(rootBundleRefs) {
+	case 0:
+		return "", fmt.Errorf("Expected tar file '%s' to contain a bundle (hint: Provide the bundle reference with --bundle (-b))", po.TarFlags.TarSrc)
+	case 1:
+		return rootBundleRefs[0], nil
+	default:
+		return "", fmt.Errorf("Expected tar file '%s' to contain a single bundle but found: %s (hint: Provide the bundle reference with --bundle (-b))", po.TarFlags.TarSrc, strings.Join(rootBundleRefs, ", "))
+	}
+}
+
+func (po *PullOptions) pullBundle(bundleRef string, imagesMetadata bundle.ImagesMetadata) error {
+	return po.pull(bundle.NewBundle(bundleRef, imagesMetadata))
+}
+
+func (po *PullOptions) pull(bundleToPull *bundle.Bundle) error {
+	err := bundleToPull.WithMaxDepth(po.MaxBundleDepth).Pull(po.OutputPath, po.ui, po.BundleRecursiveFlags.Recursive, po.Concurrency)
+	if err != nil {
+		if bundle.IsNotBundleError(err) {
+			return fmt.Errorf("Expected bundle image but found plain image (hint: Did you use -i instead of -b?)")
+		}
+		return err
+	}
+	return nil
+}
+
 func (po *PullOptions) validate() error {
 	if po.OutputPath == "" {
 		return fmt.Errorf("Expected --output to be none empty")
@@ -135,5 +237,20 @@ func (po *PullOptions) validate() error {
 	if presentInputParams == 0 {
 		return fmt.Errorf("Expected either image or bundle reference")
 	}
+	if po.TarFlags.IsSrc() && len(po.ImageFlags.Image) > 0 {
+		return fmt.Errorf("Expected either bundle or lock when pulling from a tar file")
+	}
+	if !po.TarFlags.IsSrc() && po.TarFlags.TrustedKeyPath != "" {
+		return fmt.Errorf("Expected --tar when using --tar-trusted-key")
+	}
+	if !po.TarFlags.IsSrc() && po.BundleFlags.Bundle == autoBundleRef {
+		return fmt.Errorf("Expected --tar when using '--bundle %s'", autoBundleRef)
+	}
+	if po.Concurrency < 1 {
+		return fmt.Errorf("Expected --concurrency to be greater than 0")
+	}
+	if po.MaxBundleDepth < 0 {
+		return fmt.Errorf("Expected --max-bundle-depth to be 0 or greater")
+	}
 	return nil
 }
@@ -4,10 +4,30 @@
 package cmd
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
 )
 
 func TestNoImageOrBundleOrLockError(t *testing.T) {
@@ -50,3 +70,240 @@ func TestInvalidArgsPassed(t *testing.T) {
 		t.Fatalf("\nExpceted: %s\nGot: %s", expected, err.Error())
 	}
 }
+
+func TestConcurrencyLessThanOneError(t *testing.T) {
+	pull := PullOptions{OutputPath: "/tmp/some/place", BundleFlags: BundleFlags{"my-bundle"}, Concurrency: 0}
+	err := pull.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --concurrency to be greater than 0") {
+		t.Fatalf("Expected error to contain message about invalid concurrency, got: %s", err)
+	}
+}
+
+func TestMaxBundleDepthLessThanZeroError(t *testing.T) {
+	pull := PullOptions{OutputPath: "/tmp/some/place", BundleFlags: BundleFlags{"my-bundle"}, Concurrency: 1, MaxBundleDepth: -1}
+	err := pull.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --max-bundle-depth to be 0 or greater") {
+		t.Fatalf("Expected error to contain message about invalid max bundle depth, got: %s", err)
+	}
+}
+
+func TestImageFromTarError(t *testing.T) {
+	pull := PullOptions{OutputPath: "/tmp/some/place", ImageFlags: ImageFlags{"image@123456"}, TarFlags: TarFlags{TarSrc: "bundle.tar"}, Concurrency: 1}
+	err := pull.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected either bundle or lock when pulling from a tar file") {
+		t.Fatalf("Expected error to contain message about pulling images from tar, got: %s", err)
+	}
+}
+
+func TestPullFromTar(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	nestedBundle := fakeRegistry.WithBundleFromPath("library/nested-bundle", "test_assets/bundle").
+		WithEveryImageFromPath("test_assets/image_with_config", map[string]string{})
+	rootBundle := fakeRegistry.WithBundleFromPath("library/bundle", "test_assets/bundle_with_mult_images").
+		WithImageRefs([]lockconfig.ImageRef{
+			{Image: nestedBundle.RefDigest},
+		})
+
+	subject := subject
+	subject.BundleFlags = BundleFlags{rootBundle.RefDigest}
+	subject.registry = fakeRegistry.Build()
+
+	bundleTarPath := filepath.Join(os.TempDir(), "bundle.tar")
+	defer os.Remove(bundleTarPath)
+
+	require.NoError(t, subject.CopyToTar(bundleTarPath))
+
+	nestedBundleDigest, err := name.NewDigest(nestedBundle.RefDigest)
+	require.NoError(t, err)
+	nestedBundlePath := filepath.Join(".imgpkg", "bundles", strings.ReplaceAll(nestedBundleDigest.DigestStr(), "sha256:", "sha256-"))
+
+	for _, bundleRef := range []string{"auto", rootBundle.RefDigest} {
+		t.Run("pulls the bundle and its nested bundles using "+bundleRef, func(t *testing.T) {
+			outputPath, err := os.MkdirTemp(os.TempDir(), "test-output-bundle-path")
+			require.NoError(t, err)
+			defer os.RemoveAll(outputPath)
+
+			pull := PullOptions{
+				ui:                   &bundlefakes.FakeUI{},
+				BundleFlags:          BundleFlags{bundleRef},
+				BundleRecursiveFlags: BundleRecursiveFlags{Recursive: true},
+				TarFlags:             TarFlags{TarSrc: bundleTarPath},
+				OutputPath:           outputPath,
+				Concurrency:          2,
+			}
+			require.NoError(t, pull.Run())
+
+			assert.FileExists(t, filepath.Join(outputPath, ".imgpkg", "images.yml"))
+			assert.FileExists(t, filepath.Join(outputPath, nestedBundlePath, ".imgpkg", "images.yml"))
+			assert.FileExists(t, filepath.Join(outputPath, nestedBundlePath, "config.yml"))
+
+			imagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(outputPath, ".imgpkg", "images.yml"))
+			require.NoError(t, err)
+			require.Len(t, imagesLock.Images, 1)
+			assert.Equal(t, nestedBundle.RefDigest, imagesLock.Images[0].Image)
+		})
+	}
+}
+
+func TestPullFromSignedTar(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+
+	reg := fakeRegistry.Build()
+
+	bundleDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, bundle.ImgpkgDir), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(bundleDir, "config.yml"), []byte("config: true"), 0600))
+	imagesLock := lockconfig.ImagesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+	}
+	require.NoError(t, imagesLock.WriteToPath(filepath.Join(bundleDir, bundle.ImgpkgDir, bundle.ImagesLockFile)))
+	uploadRef, err := name.NewTag(fakeRegistry.ReferenceOnTestServer("library/bundle"))
+	require.NoError(t, err)
+	bundleRef, err := bundle.NewContents([]string{bundleDir}, nil).Push(uploadRef, reg, &bundlefakes.FakeUI{})
+	require.NoError(t, err)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	subject := subject
+	subject.BundleFlags = BundleFlags{bundleRef}
+	subject.registry = reg
+	subject.tarImageSet = subject.tarImageSet.WithSigningKey(signingKey)
+
+	bundleTarPath := filepath.Join(t.TempDir(), "bundle.tar")
+	require.NoError(t, subject.CopyToTar(bundleTarPath))
+
+	writePublicKey := func(t *testing.T, key *ecdsa.PublicKey) string {
+		publicKeyDER, err := x509.MarshalPKIXPublicKey(key)
+		require.NoError(t, err)
+		keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+		require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}), 0600))
+		return keyPath
+	}
+
+	pullFromTar := func(t *testing.T, trustedKeyPath string) (string, error) {
+		outputPath := t.TempDir()
+		pull := PullOptions{
+			ui:          &bundlefakes.FakeUI{},
+			BundleFlags: BundleFlags{"auto"},
+			TarFlags:    TarFlags{TarSrc: bundleTarPath, TrustedKeyPath: trustedKeyPath},
+			OutputPath:  outputPath,
+			Concurrency: 2,
+		}
+		return outputPath, pull.Run()
+	}
+
+	t.Run("when the tar is signed by the trusted key it pulls the bundle", func(t *testing.T) {
+		outputPath, err := pullFromTar(t, writePublicKey(t, &signingKey.PublicKey))
+		require.NoError(t, err)
+		assert.FileExists(t, filepath.Join(outputPath, "config.yml"))
+	})
+
+	t.Run("when the tar is signed by another key it errors", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		_, err = pullFromTar(t, writePublicKey(t, &otherKey.PublicKey))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected tar file to be signed by the trusted key")
+	})
+
+	t.Run("when --tar is not provided it errors", func(t *testing.T) {
+		pull := PullOptions{
+			ui:          &bundlefakes.FakeUI{},
+			BundleFlags: BundleFlags{bundleRef},
+			TarFlags:    TarFlags{TrustedKeyPath: "cosign.pub"},
+			OutputPath:  t.TempDir(),
+			Concurrency: 2,
+		}
+		err := pull.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected --tar when using --tar-trusted-key")
+	})
+}
+
+func TestPullThroughMirror(t *testing.T) {
+	mirrorRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer mirrorRegistry.CleanUp()
+
+	// The upstream registry does not contain any image, so the bundle can only be resolved through the mirror
+	var upstreamPathsLock sync.Mutex
+	var upstreamPaths []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			return
+		}
+		upstreamPathsLock.Lock()
+		upstreamPaths = append(upstreamPaths, r.URL.Path)
+		upstreamPathsLock.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	appImage := mirrorRegistry.WithRandomImage("upstream-mirror/library/app")
+	reg := mirrorRegistry.Build()
+
+	bundleDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, bundle.ImgpkgDir), 0700))
+	imagesLock := lockconfig.ImagesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+		Images:      []lockconfig.ImageRef{{Image: fmt.Sprintf("%s/library/app@%s", upstreamURL.Host, appImage.Digest)}},
+	}
+	require.NoError(t, imagesLock.WriteToPath(filepath.Join(bundleDir, bundle.ImgpkgDir, bundle.ImagesLockFile)))
+	uploadRef, err := name.NewTag(mirrorRegistry.ReferenceOnTestServer("upstream-mirror/library/bundle"))
+	require.NoError(t, err)
+	mirroredBundleRef, err := bundle.NewContents([]string{bundleDir}, nil).Push(uploadRef, reg, &bundlefakes.FakeUI{})
+	require.NoError(t, err)
+	mirroredBundleDigest, err := name.NewDigest(mirroredBundleRef)
+	require.NoError(t, err)
+
+	mirrorsConfigPath := filepath.Join(t.TempDir(), "mirrors.yml")
+	require.NoError(t, ioutil.WriteFile(mirrorsConfigPath, []byte(fmt.Sprintf(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: RegistryMirrorsConfig
+registries:
+- registry: %s
+  mirrors:
+  - endpoint: %s
+    insecure: true
+`, upstreamURL.Host, mirrorRegistry.ReferenceOnTestServer("upstream-mirror"))), 0600))
+
+	outputPath := t.TempDir()
+	pull := PullOptions{
+		ui:            &bundlefakes.FakeUI{},
+		BundleFlags:   BundleFlags{fmt.Sprintf("%s/library/bundle@%s", upstreamURL.Host, mirroredBundleDigest.DigestStr())},
+		RegistryFlags: RegistryFlags{Insecure: true, MirrorsConfigPath: mirrorsConfigPath},
+		OutputPath:    outputPath,
+		Concurrency:   1,
+	}
+	require.NoError(t, pull.Run())
+
+	pulledImagesLock, err := lockconfig.NewImagesLockFromPath(filepath.Join(outputPath, bundle.ImgpkgDir, bundle.ImagesLockFile))
+	require.NoError(t, err)
+	require.Len(t, pulledImagesLock.Images, 1)
+	assert.Equal(t, imagesLock.Images[0].Image, pulledImagesLock.Images[0].Image)
+
+	// Only the images missing from the mirror, like the locations image of the bundle, are looked up in the registry
+	require.NotEmpty(t, upstreamPaths)
+	for _, path := range upstreamPaths {
+		assert.NotContains(t, path, mirroredBundleDigest.DigestStr(), "expected the bundle to be read from the mirror")
+		assert.False(t, strings.HasPrefix(path, "/v2/library/app/"), "expected the image to be read from the mirror, but was read from %s", path)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/plainimage"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
 
 type PushOptions struct {
@@ -23,6 +24,11 @@ type PushOptions struct {
 	LockOutputFlags LockOutputFlags
 	FileFlags       FileFlags
 	RegistryFlags   RegistryFlags
+	SignFlags       SignFlags
+
+	Concurrency int
+	ResolveTags bool
+	WriteBack   bool
 }
 
 func NewPushOptions(ui ui.UI) *PushOptions {
@@ -39,22 +45,58 @@ func NewPushCmd(o *PushOptions) *cobra.Command {
   imgpkg push -b repo/app1-config -f config/
 
   # Push image repo/app1-config with contents from multiple locations
-  imgpkg push -i repo/app1-config -f config/ -f additional-config.yml`,
+  imgpkg push -i repo/app1-config -f config/ -f additional-config.yml
+
+  # Push and sign bundle repo/app1-config with a key generated by cosign generate-key-pair
+  imgpkg push -b repo/app1-config -f config/ --sign --key cosign.key
+
+  # Push bundle repo/app1-config resolving the images referenced by tag (nginx:1.25) or semver constraint (nginx:~1.25) in .imgpkg/images.yml
+  imgpkg push -b repo/app1-config -f config/ --resolve-tags`,
 	}
 	o.ImageFlags.Set(cmd)
 	o.BundleFlags.Set(cmd)
 	o.LockOutputFlags.Set(cmd)
 	o.FileFlags.Set(cmd)
 	o.RegistryFlags.Set(cmd)
+	o.SignFlags.Set(cmd)
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", 5, "Concurrency used to read the nested bundles when writing a bundle closure lock")
+	cmd.Flags().BoolVar(&o.ResolveTags, "resolve-tags", false, "Resolve the images referenced by tag or semver constraint in .imgpkg/images.yml to digests in the pushed bundle")
+	cmd.Flags().BoolVar(&o.WriteBack, "write-back", false, "Also write the resolved .imgpkg/images.yml to the bundle directory (only with --resolve-tags)")
 	return cmd
 }
 
 func (po *PushOptions) Run() error {
-	reg, err := registry.NewSimpleRegistry(po.RegistryFlags.AsRegistryOpts())
+	if po.LockOutputFlags.Closure && po.Concurrency < 1 {
+		return fmt.Errorf("Expected --concurrency to be greater than 0")
+	}
+
+	if po.WriteBack && !po.ResolveTags {
+		return fmt.Errorf("Expected --resolve-tags to be provided when using --write-back")
+	}
+	if po.ResolveTags && po.BundleFlags.Bundle == "" {
+		return fmt.Errorf("Expected bundle when using --resolve-tags, images do not have an images lock")
+	}
+
+	err := po.SignFlags.Validate()
+	if err != nil {
+		return err
+	}
+
+	reg, err := registry.NewSimpleRegistry(po.RegistryFlags.AsRegistryOpts(po.ui))
 	if err != nil {
 		return err
 	}
 
+	// Loaded before pushing so that a wrong key or password does not leave an unsigned image behind
+	signer, err := po.SignFlags.Signer(reg, po.ui)
+	if err != nil {
+		return err
+	}
+	var imageSigner plainimage.ImageSigner
+	if signer != nil {
+		imageSigner = signer
+	}
+
 	var imageURL string
 
 	isBundle := po.BundleFlags.Bundle != ""
@@ -68,13 +110,13 @@ func (po *PushOptions) Run() error {
 		return fmt.Errorf("Expected either image or bundle")
 
 	case isBundle:
-		imageURL, err = po.pushBundle(reg)
+		imageURL, err = po.pushBundle(reg, imageSigner)
 		if err != nil {
 			return err
 		}
 
 	case isImage:
-		imageURL, err = po.pushImage(reg)
+		imageURL, err = po.pushImage(reg, imageSigner)
 		if err != nil {
 			return err
 		}
@@ -88,18 +130,40 @@ func (po *PushOptions) Run() error {
 	return nil
 }
 
-func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
+func (po *PushOptions) pushBundle(registry registry.Registry, signer plainimage.ImageSigner) (string, error) {
 	uploadRef, err := regname.NewTag(po.BundleFlags.Bundle, regname.WeakValidation)
 	if err != nil {
 		return "", fmt.Errorf("Parsing '%s': %s", po.BundleFlags.Bundle, err)
 	}
 
-	imageURL, err := bundle.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).Push(uploadRef, registry, po.ui)
+	contents := bundle.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).WithSigner(signer)
+	if po.ResolveTags {
+		contents, err = contents.ResolveTags(bundle.NewTagResolver(registry, po.ui), po.WriteBack)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	imageURL, err := contents.Push(uploadRef, registry, po.ui)
 	if err != nil {
 		return "", err
 	}
 
 	if po.LockOutputFlags.LockFilePath != "" {
+		if po.LockOutputFlags.Closure {
+			closureLock, err := bundle.NewBundle(imageURL, registry).ClosureLock(po.Concurrency, util.NewUILevelLogger(util.LogWarn, po.ui))
+			if err != nil {
+				return "", fmt.Errorf("Reading pushed bundle '%s': %s", imageURL, err)
+			}
+			closureLock.Bundle.Tag = uploadRef.TagStr()
+
+			err = closureLock.WriteToPath(po.LockOutputFlags.LockFilePath)
+			if err != nil {
+				return "", err
+			}
+			return imageURL, nil
+		}
+
 		bundleLock := lockconfig.BundleLock{
 			LockVersion: lockconfig.LockVersion{
 				APIVersion: lockconfig.BundleLockAPIVersion,
@@ -120,7 +184,7 @@ func (po *PushOptions) pushBundle(registry registry.Registry) (string, error) {
 	return imageURL, nil
 }
 
-func (po *PushOptions) pushImage(registry registry.Registry) (string, error) {
+func (po *PushOptions) pushImage(registry registry.Registry, signer plainimage.ImageSigner) (string, error) {
 	if po.LockOutputFlags.LockFilePath != "" {
 		return "", fmt.Errorf("Lock output is not compatible with image, use bundle for lock output")
 	}
@@ -138,5 +202,5 @@ func (po *PushOptions) pushImage(registry registry.Registry) (string, error) {
 		return "", fmt.Errorf("Images cannot be pushed with '.imgpkg' directories, consider using --bundle (-b) option")
 	}
 
-	return plainimage.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).Push(uploadRef, nil, registry, po.ui)
+	return plainimage.NewContents(po.FileFlags.Files, po.FileFlags.ExcludedFilePaths).WithSigner(signer).Push(uploadRef, nil, registry, po.ui)
 }
@@ -13,6 +13,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
 )
 
 const emptyImagesYaml = `apiVersion: imgpkg.carvel.dev/v1alpha1
@@ -235,6 +239,129 @@ func TestImageAndBundleLockError(t *testing.T) {
 	}
 }
 
+func TestClosureLockConcurrencyLessThanOneError(t *testing.T) {
+	push := PushOptions{BundleFlags: BundleFlags{"my-bundle"}, LockOutputFlags: LockOutputFlags{LockFilePath: "lock-file", Closure: true}}
+	err := push.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --concurrency to be greater than 0") {
+		t.Fatalf("Expected error to contain message about invalid flags, got: %s", err)
+	}
+}
+
+func TestSignWithoutKeyError(t *testing.T) {
+	push := PushOptions{BundleFlags: BundleFlags{"my-bundle"}, SignFlags: SignFlags{Sign: true}}
+	err := push.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --key to be provided when using --sign") {
+		t.Fatalf("Expected error to contain message about invalid flags, got: %s", err)
+	}
+}
+
+func TestKeyWithoutSignError(t *testing.T) {
+	push := PushOptions{BundleFlags: BundleFlags{"my-bundle"}, SignFlags: SignFlags{KeyPath: "cosign.key"}}
+	err := push.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --sign to be provided when using --key") {
+		t.Fatalf("Expected error to contain message about invalid flags, got: %s", err)
+	}
+}
+
+func TestSignFlagsValidatedBeforeRegistryError(t *testing.T) {
+	push := PushOptions{
+		BundleFlags:   BundleFlags{"my-bundle"},
+		SignFlags:     SignFlags{Sign: true},
+		RegistryFlags: RegistryFlags{CACertPaths: []string{"/does/not/exist.crt"}},
+	}
+	err := push.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --key to be provided when using --sign") {
+		t.Fatalf("Expected error to contain message about invalid flags, got: %s", err)
+	}
+}
+
+func TestWriteBackWithoutResolveTagsError(t *testing.T) {
+	push := PushOptions{BundleFlags: BundleFlags{"my-bundle"}, WriteBack: true}
+	err := push.Run()
+	if err == nil {
+		t.Fatalf("Expected validations to err, but did not")
+	}
+
+	if !strings.Contains(err.Error(), "Expected --resolve-tags to be provided when using --write-back") {
+		t.Fatalf("Expected error to contain message about invalid flags, got: %s", err)
+	}
+}
+
+func TestPushResolveTags(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	nginx := fakeRegistry.WithRandomImage("library/nginx:1.25.3")
+	fakeRegistry.WithRandomImage("library/nginx:1.26.0")
+	fakeRegistry.Build()
+
+	imagesYAML := emptyImagesYaml + "images:\n- image: " + fakeRegistry.ReferenceOnTestServer("library/nginx") + ":~1.25\n"
+
+	pushAndPull := func(t *testing.T, writeBack bool) (string, string) {
+		bundleDir := t.TempDir()
+		require.NoError(t, createBundleDir(bundleDir, imagesYAML))
+
+		push := PushOptions{
+			ui:          &bundlefakes.FakeUI{},
+			FileFlags:   FileFlags{Files: []string{bundleDir}},
+			BundleFlags: BundleFlags{fakeRegistry.ReferenceOnTestServer("library/bundle")},
+			ResolveTags: true,
+			WriteBack:   writeBack,
+		}
+		require.NoError(t, push.Run())
+
+		outputPath := t.TempDir()
+		pull := PullOptions{
+			ui:          &bundlefakes.FakeUI{},
+			BundleFlags: BundleFlags{fakeRegistry.ReferenceOnTestServer("library/bundle")},
+			OutputPath:  outputPath,
+			Concurrency: 1,
+		}
+		require.NoError(t, pull.Run())
+
+		return bundleDir, outputPath
+	}
+
+	assertResolved := func(t *testing.T, imagesLockPath string) {
+		imagesLock, err := lockconfig.NewImagesLockFromPath(imagesLockPath)
+		require.NoError(t, err)
+		require.Len(t, imagesLock.Images, 1)
+		assert.Equal(t, nginx.RefDigest, imagesLock.Images[0].Image)
+		assert.Equal(t, "~1.25", imagesLock.Images[0].Annotations[bundle.OriginalTagAnnotation])
+	}
+
+	t.Run("pushes the resolved images lock without changing the bundle directory", func(t *testing.T) {
+		bundleDir, outputPath := pushAndPull(t, false)
+
+		assertResolved(t, filepath.Join(outputPath, ".imgpkg", "images.yml"))
+		bs, err := ioutil.ReadFile(filepath.Join(bundleDir, ".imgpkg", "images.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, imagesYAML, string(bs))
+	})
+
+	t.Run("when --write-back is provided it also updates the bundle directory", func(t *testing.T) {
+		bundleDir, outputPath := pushAndPull(t, true)
+
+		assertResolved(t, filepath.Join(outputPath, ".imgpkg", "images.yml"))
+		assertResolved(t, filepath.Join(bundleDir, ".imgpkg", "images.yml"))
+	})
+}
+
 func Cleanup(dirs ...string) {
 	for _, dir := range dirs {
 		os.RemoveAll(dir)
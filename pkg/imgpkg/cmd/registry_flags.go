@@ -5,11 +5,18 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	goui "github.com/cppforlife/go-cli-ui/ui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
 
 type RegistryFlags struct {
@@ -17,14 +24,31 @@ type RegistryFlags struct {
 	VerifyCerts bool
 	Insecure    bool
 
+	ClientCertPath string
+	ClientKeyPath  string
+	CertsDir       string
+
 	Username string
 	Password string
 	Token    string
 	Anon     bool
 
-	RetryCount int
+	AuthFilePaths []string
+
+	CredentialProviderConfigPath string
+	CredentialProviderBinDir     string
+
+	RetryCount           RetryCountValue
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+	RetryMaxElapsedTime  time.Duration
 
 	ResponseHeaderTimeout time.Duration
+
+	MirrorsConfigPath string
+
+	MaxBandwidth          BandwidthValue
+	MaxConcurrencyPerHost int
 }
 
 // Set Registers the flags available to the provided command
@@ -32,14 +56,29 @@ func (r *RegistryFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&r.CACertPaths, "registry-ca-cert-path", nil, "Add CA certificates for registry API (format: /tmp/foo) (can be specified multiple times)")
 	cmd.Flags().BoolVar(&r.VerifyCerts, "registry-verify-certs", true, "Set whether to verify server's certificate chain and host name")
 	cmd.Flags().BoolVar(&r.Insecure, "registry-insecure", false, "Allow the use of http when interacting with registries")
+	cmd.Flags().StringVar(&r.ClientCertPath, "registry-client-cert", "", "Path to the client certificate presented to all registries that request one (format: /tmp/foo.cert)")
+	cmd.Flags().StringVar(&r.ClientKeyPath, "registry-client-key", "", "Path to the key of the client certificate (format: /tmp/foo.key)")
+	cmd.Flags().StringVar(&r.CertsDir, "registry-certs-dir", "", "Path to a directory with certificates of specific registries, laid out like docker's certs.d: <dir>/<host[:port]>/ containing CA certificates (*.crt) and client certificate and key pairs (*.cert, *.key) ($IMGPKG_REGISTRY_CERTS_DIR)")
 
 	cmd.Flags().StringVar(&r.Username, "registry-username", "", "Set username for auth ($IMGPKG_USERNAME)")
 	cmd.Flags().StringVar(&r.Password, "registry-password", "", "Set password for auth ($IMGPKG_PASSWORD)")
 	cmd.Flags().StringVar(&r.Token, "registry-token", "", "Set token for auth ($IMGPKG_TOKEN)")
 	cmd.Flags().BoolVar(&r.Anon, "registry-anon", false, "Set anonymous auth ($IMGPKG_ANON)")
+	cmd.Flags().StringSliceVar(&r.AuthFilePaths, "registry-auth-file", nil, "Read registry credentials from a docker config.json or a Kubernetes image pull Secret (format: /tmp/config.json) (can be specified multiple times)")
+	cmd.Flags().StringVar(&r.CredentialProviderConfigPath, "registry-credential-provider-config", "", "Path to a kubelet CredentialProviderConfig with the credential provider plugins used to get registry credentials ($IMGPKG_REGISTRY_CREDENTIAL_PROVIDER_CONFIG)")
+	cmd.Flags().StringVar(&r.CredentialProviderBinDir, "registry-credential-provider-bin-dir", "", "Directory containing the credential provider plugins, by default they are looked up in $PATH ($IMGPKG_REGISTRY_CREDENTIAL_PROVIDER_BIN_DIR)")
 
 	cmd.Flags().DurationVar(&r.ResponseHeaderTimeout, "registry-response-header-timeout", 30*time.Second, "Maximum time to allow a request to wait for a server's response headers from the registry (ms|s|m|h)")
-	cmd.Flags().IntVar(&r.RetryCount, "registry-retry-count", 5, "Set the number of times imgpkg retries to send requests to the registry in case of an error")
+	r.RetryCount = 5
+	cmd.Flags().Var(&r.RetryCount, "registry-retry-count", "Set the number of times imgpkg retries to send requests to the registry in case of an error, 0 disables retries")
+	cmd.Flags().DurationVar(&r.RetryInitialInterval, "registry-retry-initial-interval", 1*time.Second, "Time to wait before the first retry, doubled after every retry (ms|s|m|h)")
+	cmd.Flags().DurationVar(&r.RetryMaxInterval, "registry-retry-max-interval", 30*time.Second, "Maximum time to wait between retries, unless the registry requests a longer wait with Retry-After (ms|s|m|h)")
+	cmd.Flags().DurationVar(&r.RetryMaxElapsedTime, "registry-retry-max-elapsed-time", 5*time.Minute, "Stop retrying a request once this time has passed since the first attempt (ms|s|m|h)")
+
+	cmd.Flags().Var(&r.MaxBandwidth, "max-bandwidth", "Maximum bandwidth used to transfer data from and to registries, 0 means unlimited (examples: 50MiB/s, 500KB/s, 100Mb/s)")
+	cmd.Flags().IntVar(&r.MaxConcurrencyPerHost, "max-concurrency-per-host", 0, "Maximum number of parallel requests to each registry host, 0 means unlimited")
+
+	cmd.Flags().StringVar(&r.MirrorsConfigPath, "registry-mirrors-config", "", "Path to the file containing the mirrors used to read images from registries ($IMGPKG_REGISTRY_MIRRORS_CONFIG)")
 
 	cmd.Flags().String("registry-azure-cr-config", "", "Path to the file containing Azure container registry configuration information. ($IMGPKG_REGISTRY_AZURE_CR_CONFIG)")
 
@@ -65,20 +104,36 @@ func (r *RegistryFlags) Set(cmd *cobra.Command) {
 	}
 }
 
-func (r *RegistryFlags) AsRegistryOpts() registry.Opts {
+// AsRegistryOpts Returns the options of the registry, the warnings of the registry are written to the ui
+func (r *RegistryFlags) AsRegistryOpts(ui goui.UI) registry.Opts {
 	opts := registry.Opts{
 		CACertPaths: r.CACertPaths,
 		VerifyCerts: r.VerifyCerts,
 		Insecure:    r.Insecure,
 
+		ClientCertPath: r.ClientCertPath,
+		ClientKeyPath:  r.ClientKeyPath,
+		CertsDir:       r.CertsDir,
+
 		Username: r.Username,
 		Password: r.Password,
 		Token:    r.Token,
 		Anon:     r.Anon,
 
-		RetryCount:            r.RetryCount,
+		AuthFilePaths: r.AuthFilePaths,
+
+		CredentialProviderConfigPath: r.CredentialProviderConfigPath,
+		CredentialProviderBinDir:     r.CredentialProviderBinDir,
+
 		ResponseHeaderTimeout: r.ResponseHeaderTimeout,
+		RetryPolicy:           r.AsRetryPolicy(),
+
+		MirrorsConfigPath: r.MirrorsConfigPath,
+
+		MaxBandwidth:          int64(r.MaxBandwidth),
+		MaxConcurrencyPerHost: r.MaxConcurrencyPerHost,
 
+		Logger:      util.NewUILevelLogger(util.LogWarn, ui),
 		EnvironFunc: os.Environ,
 	}
 
@@ -94,6 +149,128 @@ func (r *RegistryFlags) AsRegistryOpts() registry.Opts {
 	if os.Getenv("IMGPKG_ANON") == "true" {
 		opts.Anon = true
 	}
+	if len(opts.CredentialProviderConfigPath) == 0 {
+		opts.CredentialProviderConfigPath = os.Getenv("IMGPKG_REGISTRY_CREDENTIAL_PROVIDER_CONFIG")
+	}
+	if len(opts.CredentialProviderBinDir) == 0 {
+		opts.CredentialProviderBinDir = os.Getenv("IMGPKG_REGISTRY_CREDENTIAL_PROVIDER_BIN_DIR")
+	}
+	if len(opts.CertsDir) == 0 {
+		opts.CertsDir = os.Getenv("IMGPKG_REGISTRY_CERTS_DIR")
+	}
+	if len(opts.MirrorsConfigPath) == 0 {
+		opts.MirrorsConfigPath = os.Getenv("IMGPKG_REGISTRY_MIRRORS_CONFIG")
+	}
 
 	return opts
 }
+
+// AsRetryPolicy Returns the policy used to retry failed operations
+func (r *RegistryFlags) AsRetryPolicy() util.RetryPolicy {
+	retryPolicy := util.DefaultRetryPolicy()
+	// Requests are attempted once more than they are retried
+	retryPolicy.MaxAttempts = int(r.RetryCount) + 1
+	retryPolicy.InitialInterval = r.RetryInitialInterval
+	retryPolicy.MaxInterval = r.RetryMaxInterval
+	retryPolicy.MaxElapsedTime = r.RetryMaxElapsedTime
+	return retryPolicy
+}
+
+// BandwidthValue Bytes per second parsed from values such as 50MiB/s, 10M, 500KB/s or 100Mb/s
+type BandwidthValue int64
+
+var _ pflag.Value = new(BandwidthValue)
+
+var bandwidthMatcher = regexp.MustCompile(`\A(\d+(?:\.\d+)?)\s*([kKmMgGtT]?)(i?)([bB]?)(/s)?\z`)
+
+var bandwidthUnitExponent = map[string]int{"": 0, "k": 1, "m": 2, "g": 3, "t": 4}
+
+// Set parses the bandwidth, units can be decimal (KB, MB, GB, TB) or binary (KiB, MiB, GiB, TiB).
+// An uppercase B stands for bytes and a lowercase b for bits
+func (b *BandwidthValue) Set(val string) error {
+	bytesPerSecond, ok := parseBytes(val, true)
+	if !ok {
+		return fmt.Errorf("Expected bandwidth to be a number of bytes per second (examples: 50MiB/s, 500KB/s), got '%s'", val)
+	}
+
+	*b = BandwidthValue(bytesPerSecond)
+	return nil
+}
+
+// parseBytes parses a number of bytes with an optional decimal or binary unit, and an optional /s suffix when perSecond is true
+func parseBytes(val string, perSecond bool) (float64, bool) {
+	match := bandwidthMatcher.FindStringSubmatch(strings.TrimSpace(val))
+	if match == nil || (!perSecond && len(match[5]) > 0) {
+		return 0, false
+	}
+
+	amount, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	base := 1000.0
+	if match[3] == "i" {
+		if match[2] == "" {
+			return 0, false
+		}
+		base = 1024.0
+	}
+
+	bytes := amount * math.Pow(base, float64(bandwidthUnitExponent[strings.ToLower(match[2])]))
+	if match[4] == "b" {
+		bytes /= 8
+	}
+	return bytes, true
+}
+
+func (b *BandwidthValue) String() string {
+	return strconv.FormatInt(int64(*b), 10) + "B/s"
+}
+
+func (b *BandwidthValue) Type() string { return "bandwidth" }
+
+// RetryCountValue Number of times failed requests are retried, it can not be negative
+type RetryCountValue int
+
+var _ pflag.Value = new(RetryCountValue)
+
+// Set parses the number of retries
+func (c *RetryCountValue) Set(val string) error {
+	count, err := strconv.Atoi(val)
+	if err != nil || count < 0 {
+		return fmt.Errorf("Expected retry count to be 0 or greater, got '%s'", val)
+	}
+
+	*c = RetryCountValue(count)
+	return nil
+}
+
+func (c *RetryCountValue) String() string {
+	return strconv.Itoa(int(*c))
+}
+
+func (c *RetryCountValue) Type() string { return "int" }
+
+// SizeValue Bytes parsed from values such as 2GiB, 500MB or 1024
+type SizeValue int64
+
+var _ pflag.Value = new(SizeValue)
+
+// Set parses the size, units can be decimal (KB, MB, GB, TB) or binary (KiB, MiB, GiB, TiB).
+// An uppercase B stands for bytes and a lowercase b for bits
+func (s *SizeValue) Set(val string) error {
+	bytes, ok := parseBytes(val, false)
+	if !ok {
+		return fmt.Errorf("Expected size to be a number of bytes (examples: 2GiB, 500MB), got '%s'", val)
+	}
+
+	*s = SizeValue(bytes)
+	return nil
+}
+
+func (s *SizeValue) String() string {
+	return strconv.FormatInt(int64(*s), 10) + "B"
+}
+
+func (s *SizeValue) Type() string { return "size" }
@@ -0,0 +1,99 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+)
+
+func TestBandwidthValue(t *testing.T) {
+	validValues := map[string]int64{
+		"0":        0,
+		"500":      500,
+		"500B/s":   500,
+		"10KB/s":   10 * 1000,
+		"10k":      10 * 1000,
+		"1.5MB/s":  1500 * 1000,
+		"50MiB/s":  50 * 1024 * 1024,
+		"50Mi":     50 * 1024 * 1024,
+		"2GiB/s":   2 * 1024 * 1024 * 1024,
+		" 1 Gb/s ": 1000 * 1000 * 1000 / 8,
+		"1GB/s":    1000 * 1000 * 1000,
+		"8Mib/s":   1024 * 1024,
+	}
+	for val, expected := range validValues {
+		t.Run(val, func(t *testing.T) {
+			var bandwidth BandwidthValue
+			require.NoError(t, bandwidth.Set(val))
+			assert.Equal(t, expected, int64(bandwidth))
+		})
+	}
+
+	for _, val := range []string{"", "fast", "10XB/s", "-1MB/s", "10iB/s", "10MB/h"} {
+		t.Run("invalid "+val, func(t *testing.T) {
+			var bandwidth BandwidthValue
+			err := bandwidth.Set(val)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "Expected bandwidth to be a number of bytes per second")
+		})
+	}
+}
+
+func TestSizeValue(t *testing.T) {
+	var size SizeValue
+	require.NoError(t, size.Set("2GiB"))
+	assert.Equal(t, int64(2*1024*1024*1024), int64(size))
+
+	err := size.Set("2GiB/s")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Expected size to be a number of bytes")
+}
+
+func TestRegistryFlagsRetryCount(t *testing.T) {
+	parseRetryPolicy := func(t *testing.T, args ...string) (int, error) {
+		var registryFlags RegistryFlags
+		cmd := &cobra.Command{}
+		registryFlags.Set(cmd)
+		err := cmd.ParseFlags(args)
+		return registryFlags.AsRetryPolicy().MaxAttempts, err
+	}
+
+	t.Run("by default requests are retried 5 times", func(t *testing.T) {
+		maxAttempts, err := parseRetryPolicy(t)
+		require.NoError(t, err)
+		assert.Equal(t, 6, maxAttempts)
+	})
+
+	t.Run("when the retry count is 0 requests are attempted once", func(t *testing.T) {
+		maxAttempts, err := parseRetryPolicy(t, "--registry-retry-count", "0")
+		require.NoError(t, err)
+		assert.Equal(t, 1, maxAttempts)
+	})
+
+	t.Run("when the retry count is negative it errors", func(t *testing.T) {
+		_, err := parseRetryPolicy(t, "--registry-retry-count", "-1")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected retry count to be 0 or greater, got '-1'")
+	})
+}
+
+func TestRegistryFlagsCredentialProvider(t *testing.T) {
+	var registryFlags RegistryFlags
+	cmd := &cobra.Command{}
+	registryFlags.Set(cmd)
+
+	require.NoError(t, cmd.ParseFlags([]string{
+		"--registry-credential-provider-config", "/tmp/credential-provider-config.yml",
+		"--registry-credential-provider-bin-dir", "/tmp/plugins",
+	}))
+
+	opts := registryFlags.AsRegistryOpts(&bundlefakes.FakeUI{})
+	assert.Equal(t, "/tmp/credential-provider-config.yml", opts.CredentialProviderConfigPath)
+	assert.Equal(t, "/tmp/plugins", opts.CredentialProviderBinDir)
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// repeatableStringValue Flag that can be provided multiple times, the first value is stored in first
+// and the following ones in rest, so that commands that only expect one value can keep using first
+type repeatableStringValue struct {
+	first *string
+	rest  *[]string
+}
+
+var _ pflag.Value = repeatableStringValue{}
+
+func newRepeatableStringValue(first *string, rest *[]string) repeatableStringValue {
+	return repeatableStringValue{first: first, rest: rest}
+}
+
+// Set Stores the value in first the first time, and appends it to rest afterwards
+func (r repeatableStringValue) Set(val string) error {
+	if *r.first == "" {
+		*r.first = val
+		return nil
+	}
+	*r.rest = append(*r.rest, val)
+	return nil
+}
+
+// String Returns every value provided
+func (r repeatableStringValue) String() string {
+	if *r.first == "" {
+		return ""
+	}
+	return strings.Join(append([]string{*r.first}, *r.rest...), ",")
+}
+
+// Type Returns the type of the flag value
+func (r repeatableStringValue) Type() string {
+	return "string"
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
+)
+
+// cosignPasswordEnv is the environment variable cosign reads the private key password from
+const cosignPasswordEnv = "COSIGN_PASSWORD"
+
+type SignFlags struct {
+	Sign    bool
+	KeyPath string
+}
+
+func (s *SignFlags) Set(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&s.Sign, "sign", false, "Sign the pushed image or bundle with cosign, storing the signature as the sha256-<digest>.sig image")
+	cmd.Flags().StringVar(&s.KeyPath, "key", "", "Path to the cosign private key used with --sign (password is read from $"+cosignPasswordEnv+")")
+}
+
+// Validate Checks that --sign and --key are provided together
+func (s SignFlags) Validate() error {
+	if !s.Sign {
+		if len(s.KeyPath) > 0 {
+			return fmt.Errorf("Expected --sign to be provided when using --key")
+		}
+		return nil
+	}
+	if len(s.KeyPath) == 0 {
+		return fmt.Errorf("Expected --key to be provided when using --sign")
+	}
+	return nil
+}
+
+// Signer Returns a signer that uses the private key, or nil when signing was not requested
+func (s SignFlags) Signer(reg signature.SignatureWriter, ui ui.UI) (*signature.CosignSigner, error) {
+	err := s.Validate()
+	if err != nil || !s.Sign {
+		return nil, err
+	}
+
+	key, err := loadPrivateKey(s.KeyPath, ui)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature.NewCosignSigner(reg, key), nil
+}
+
+// loadPrivateKey Loads the cosign private key, reading its password from $COSIGN_PASSWORD or asking for it
+func loadPrivateKey(path string, ui ui.UI) (*ecdsa.PrivateKey, error) {
+	password, found := os.LookupEnv(cosignPasswordEnv)
+	if !found {
+		var err error
+		password, err = ui.AskForPassword("Enter password for private key")
+		if err != nil {
+			return nil, fmt.Errorf("Reading private key password: %s", err)
+		}
+	}
+
+	return cosign.LoadPrivateKey(path, []byte(password))
+}
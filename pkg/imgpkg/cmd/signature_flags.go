@@ -3,12 +3,47 @@
 
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+)
 
 type SignatureFlags struct {
-	CopyCosignSignatures bool
+	CopyCosignSignatures   bool
+	CopyCosignAttestations bool
+	CopyCosignSBOMs        bool
+
+	IncludeReferrers       bool
+	ReferrersArtifactTypes []string
+
+	PolicyPath string
 }
 
 func (s *SignatureFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&s.CopyCosignSignatures, "cosign-signatures", false, "Find and copy cosign signatures for images")
+	cmd.Flags().BoolVar(&s.CopyCosignAttestations, "cosign-attestations", false, "Find and copy cosign attestations (.att) for images")
+	cmd.Flags().BoolVar(&s.CopyCosignSBOMs, "cosign-sboms", false, "Find and copy cosign SBOMs (.sbom) for images")
+	cmd.Flags().BoolVar(&s.IncludeReferrers, "include-referrers", false, "Find and copy artifacts that refer to images through their subject (e.g. notation signatures, SBOMs)")
+	cmd.Flags().StringVar(&s.PolicyPath, "signature-policy", "", "Path to a SignaturePolicy file, images that do not comply with it are not copied")
+	cmd.Flags().StringSliceVar(&s.ReferrersArtifactTypes, "referrers-artifact-type", nil, "Only copy referrers with this artifactType, implies --include-referrers (can be specified multiple times)")
+}
+
+// ArtifactKinds Returns the kinds of cosign artifacts selected to be copied
+func (s SignatureFlags) ArtifactKinds() []signature.ArtifactKind {
+	var kinds []signature.ArtifactKind
+	if s.CopyCosignSignatures {
+		kinds = append(kinds, signature.SignatureArtifact)
+	}
+	if s.CopyCosignAttestations {
+		kinds = append(kinds, signature.AttestationArtifact)
+	}
+	if s.CopyCosignSBOMs {
+		kinds = append(kinds, signature.SBOMArtifact)
+	}
+	return kinds
+}
+
+// IncludesReferrers Returns true when artifacts referring to images should be copied
+func (s SignatureFlags) IncludesReferrers() bool {
+	return s.IncludeReferrers || len(s.ReferrersArtifactTypes) > 0
 }
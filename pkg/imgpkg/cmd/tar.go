@@ -0,0 +1,32 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func NewTarCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tar",
+		Short: "Tar",
+	}
+	return cmd
+}
+
+// tarPathFromArgs returns the tar file provided either as the argument of the command or with --tar
+func tarPathFromArgs(tarFlag string, args []string) (string, error) {
+	switch {
+	case len(args) > 0 && tarFlag != "":
+		return "", fmt.Errorf("Expected the tar file to be provided either as an argument or with --tar, not both")
+	case len(args) > 0:
+		return args[0], nil
+	case tarFlag != "":
+		return tarFlag, nil
+	default:
+		return "", fmt.Errorf("Expected the tar file to be provided as an argument or with --tar")
+	}
+}
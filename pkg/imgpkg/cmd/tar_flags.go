@@ -4,18 +4,74 @@
 package cmd
 
 import (
+	"crypto/ecdsa"
+
+	"filippo.io/age"
+	"github.com/cppforlife/go-cli-ui/ui"
 	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
 )
 
 type TarFlags struct {
 	TarSrc string
 	TarDst string
+
+	EncryptRecipients []string
+	DecryptIdentities []string
+
+	SigningKeyPath string
+	TrustedKeyPath string
 }
 
 func (t *TarFlags) Set(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&t.TarDst, "to-tar", "", "Location to write a tar file containing assets")
 	cmd.Flags().StringVar(&t.TarSrc, "tar", "", "Path to tar file which contains assets to be copied to a registry")
+	cmd.Flags().StringSliceVar(&t.EncryptRecipients, "encrypt-recipient", nil, "Encrypt the tar file to an age public key (age1...) or to the public keys listed in a file (can be specified multiple times)")
+	t.setDecryptIdentity(cmd)
+	cmd.Flags().StringVar(&t.SigningKeyPath, "tar-signing-key", "", "Path to the cosign private key used to sign the contents of the tar file (password is read from $"+cosignPasswordEnv+")")
+	t.setTrustedKey(cmd)
 }
 
 func (t TarFlags) IsSrc() bool { return t.TarSrc != "" }
 func (t TarFlags) IsDst() bool { return t.TarDst != "" }
+
+func (t *TarFlags) SetPull(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&t.TarSrc, "tar", "", "Path to tar file, created by copy --to-tar, to pull the bundle from (use with --bundle auto to pull the root bundle)")
+	t.setDecryptIdentity(cmd)
+	t.setTrustedKey(cmd)
+}
+
+func (t *TarFlags) setTrustedKey(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&t.TrustedKeyPath, "tar-trusted-key", "", "Path to the cosign public key the tar file is expected to be signed with, the tar file is rejected when the signature does not verify")
+}
+
+func (t *TarFlags) setDecryptIdentity(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&t.DecryptIdentities, "decrypt-identity", nil, "Path to an age identity file used to decrypt an encrypted tar file (can be specified multiple times)")
+}
+
+// Recipients Parses the age recipients the tar file is encrypted to
+func (t TarFlags) Recipients() ([]age.Recipient, error) {
+	return imagetar.ParseRecipients(t.EncryptRecipients)
+}
+
+// SigningKey Loads the private key the tar file is signed with, or nil when the tar file is not signed
+func (t TarFlags) SigningKey(ui ui.UI) (*ecdsa.PrivateKey, error) {
+	if t.SigningKeyPath == "" {
+		return nil, nil
+	}
+	return loadPrivateKey(t.SigningKeyPath, ui)
+}
+
+// TrustedKey Loads the public key the tar file is expected to be signed with, or nil when it is not verified
+func (t TarFlags) TrustedKey() (*ecdsa.PublicKey, error) {
+	if t.TrustedKeyPath == "" {
+		return nil, nil
+	}
+	return cosign.LoadPublicKey(t.TrustedKeyPath)
+}
+
+// Identities Parses the age identities the tar file is decrypted with
+func (t TarFlags) Identities() ([]age.Identity, error) {
+	return imagetar.ParseIdentities(t.DecryptIdentities)
+}
@@ -0,0 +1,236 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
+)
+
+// TarListOptions Command that lists the contents of a tarball created by copy --to-tar
+type TarListOptions struct {
+	ui ui.UI
+
+	TarPath           string
+	DecryptIdentities []string
+}
+
+// NewTarListOptions constructor for building a TarListOptions, holding values derived via flags
+func NewTarListOptions(ui ui.UI) *TarListOptions {
+	return &TarListOptions{ui: ui}
+}
+
+// NewTarListCmd constructor for the tar list command
+func NewTarListCmd(o *TarListOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list [TAR_FILE]",
+		Aliases: []string{"ls"},
+		Short:   "List images and bundles in a tarball without importing it",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			tarPath, err := tarPathFromArgs(o.TarPath, args)
+			if err != nil {
+				return err
+			}
+			o.TarPath = tarPath
+			return o.Run()
+		},
+		Example: `
+  # List the images in the tarball /Volumes/app1-bundle.tar
+  imgpkg tar ls /Volumes/app1-bundle.tar
+
+  # List the images in the tarball /Volumes/app1-bundle.tar as JSON
+  imgpkg tar ls /Volumes/app1-bundle.tar --json`,
+	}
+	cmd.Flags().StringVar(&o.TarPath, "tar", "", "Path to tar file created by copy --to-tar")
+	cmd.Flags().StringSliceVar(&o.DecryptIdentities, "decrypt-identity", nil, "Path to an age identity file used to decrypt an encrypted tar file (can be specified multiple times)")
+	return cmd
+}
+
+// tarListSummary Totals of the images listed
+type tarListSummary struct {
+	images        int
+	indexes       int
+	bytes         int64
+	omittedLayers int
+}
+
+// Run Lists the images of the tarball, reading only its manifest.json, and the layers
+// that were not written to it when some layers are non-distributable
+func (o *TarListOptions) Run() error {
+	if o.TarPath == "" {
+		return fmt.Errorf("Expected the tar file to be provided as an argument or with --tar")
+	}
+
+	identities, err := imagetar.ParseIdentities(o.DecryptIdentities)
+	if err != nil {
+		return err
+	}
+
+	tarReader := imagetar.NewTarReader(o.TarPath).WithIdentities(identities...)
+
+	ids, err := tarReader.Descriptors()
+	if err != nil {
+		return fmt.Errorf("Reading tar file '%s': %s", o.TarPath, err)
+	}
+
+	includedLayers, err := o.includedLayers(tarReader, ids)
+	if err != nil {
+		return fmt.Errorf("Reading tar file '%s': %s", o.TarPath, err)
+	}
+
+	table := uitable.Table{
+		Title:   "Images",
+		Content: "images",
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Refs"),
+			uitable.NewHeader("Tag"),
+			uitable.NewHeader("Type"),
+			uitable.NewHeader("Root Bundle"),
+			uitable.NewHeader("Labels"),
+			uitable.NewHeader("Layers"),
+			uitable.NewHeader("Size"),
+			uitable.NewHeader("Omitted Layers"),
+		},
+
+		SortBy: []uitable.ColumnSort{
+			{Column: 0, Asc: true},
+		},
+	}
+
+	summary := tarListSummary{}
+	countedLayers := map[string]struct{}{}
+
+	for _, td := range ids.Descriptors() {
+		var refs []string
+		var tag, kind string
+		var labels map[string]string
+		var layers []imagedesc.ImageLayerDescriptor
+
+		switch {
+		case td.Image != nil:
+			refs, tag, labels, kind = td.Image.Refs, td.Image.Tag, td.Image.Labels, "image"
+			layers = td.Image.Layers
+			summary.images++
+		case td.ImageIndex != nil:
+			refs, tag, labels, kind = td.ImageIndex.Refs, td.ImageIndex.Tag, td.ImageIndex.Labels, "index"
+			layers = indexLayers(*td.ImageIndex)
+			summary.indexes++
+		default:
+			panic("Unknown item")
+		}
+
+		var size int64
+		var omitted []string
+		for _, layer := range layers {
+			if _, found := includedLayers[layer.Digest]; !found && !layer.IsDistributable() {
+				omitted = append(omitted, layer.Digest)
+				continue
+			}
+			size += layer.Size
+
+			if _, found := countedLayers[layer.Digest]; !found {
+				countedLayers[layer.Digest] = struct{}{}
+				summary.bytes += layer.Size
+			}
+		}
+		summary.omittedLayers += len(omitted)
+
+		_, isRootBundle := labels[rootBundleLabelKey]
+
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueStrings(refs),
+			uitable.NewValueString(tag),
+			uitable.NewValueString(kind),
+			uitable.NewValueBool(isRootBundle),
+			uitable.NewValueStrings(formatLabels(labels)),
+			uitable.NewValueInt(len(layers)),
+			uitable.NewValueString(humanizeBytes(size)),
+			uitable.NewValueStrings(omitted),
+		})
+	}
+
+	table.Notes = []string{fmt.Sprintf("%d images, %d image indexes, %s of layers, %d non-distributable layers omitted",
+		summary.images, summary.indexes, humanizeBytes(summary.bytes), summary.omittedLayers)}
+
+	format, err := tarReader.Format()
+	if err != nil {
+		return fmt.Errorf("Reading tar file '%s': %s", o.TarPath, err)
+	}
+	if format != nil {
+		table.Notes = append(table.Notes, fmt.Sprintf("Format version %d, created by imgpkg %s at %s",
+			format.Version, format.ImgpkgVersion, format.CreatedAt.Format(time.RFC3339)))
+	}
+
+	o.ui.PrintTable(table)
+
+	return nil
+}
+
+// includedLayers returns the layers written to the tarball, the tar entries are only
+// read when some layers are non-distributable since every other layer is always written
+func (o *TarListOptions) includedLayers(tarReader imagetar.TarReader, ids *imagedesc.ImageRefDescriptors) (map[string]struct{}, error) {
+	for _, td := range ids.Descriptors() {
+		var layers []imagedesc.ImageLayerDescriptor
+		if td.Image != nil {
+			layers = td.Image.Layers
+		} else if td.ImageIndex != nil {
+			layers = indexLayers(*td.ImageIndex)
+		}
+
+		for _, layer := range layers {
+			if !layer.IsDistributable() {
+				return tarReader.IncludedLayers()
+			}
+		}
+	}
+	return map[string]struct{}{}, nil
+}
+
+func indexLayers(td imagedesc.ImageIndexDescriptor) []imagedesc.ImageLayerDescriptor {
+	var layers []imagedesc.ImageLayerDescriptor
+	for _, idx := range td.Indexes {
+		layers = append(layers, indexLayers(idx)...)
+	}
+	for _, img := range td.Images {
+		layers = append(layers, img.Layers...)
+	}
+	return layers
+}
+
+func formatLabels(labels map[string]string) []string {
+	var result []string
+	for key, value := range labels {
+		if value == "" {
+			result = append(result, key)
+			continue
+		}
+		result = append(result, key+"="+value)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// humanizeBytes formats the size with binary units (example: 1.5 MiB)
+func humanizeBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestTarList(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	appImage := fakeRegistry.WithRandomImage("library/app").WithNonDistributableLayer()
+	reg := fakeRegistry.Build()
+
+	bundleDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(bundleDir, bundle.ImgpkgDir), 0700))
+	imagesLock := lockconfig.ImagesLock{
+		LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.ImagesLockAPIVersion, Kind: lockconfig.ImagesLockKind},
+		Images:      []lockconfig.ImageRef{{Image: appImage.RefDigest}},
+	}
+	require.NoError(t, imagesLock.WriteToPath(filepath.Join(bundleDir, bundle.ImgpkgDir, bundle.ImagesLockFile)))
+	uploadRef, err := name.NewTag(fakeRegistry.ReferenceOnTestServer("library/app-bundle:v1"))
+	require.NoError(t, err)
+	bundleRef, err := bundle.NewContents([]string{bundleDir}, nil).Push(uploadRef, reg, &bundlefakes.FakeUI{})
+	require.NoError(t, err)
+
+	tarFile := filepath.Join(t.TempDir(), "bundle.tar")
+	bundleSrc := subject
+	bundleSrc.BundleFlags = BundleFlags{bundleRef}
+	bundleSrc.registry = reg
+	require.NoError(t, bundleSrc.CopyToTar(tarFile))
+
+	t.Run("it lists the images with their refs, labels and layers", func(t *testing.T) {
+		fakeUI := &bundlefakes.FakeUI{}
+		tarList := NewTarListOptions(fakeUI)
+		tarList.TarPath = tarFile
+		require.NoError(t, tarList.Run())
+
+		require.Equal(t, 1, fakeUI.PrintTableCallCount())
+		table := fakeUI.PrintTableArgsForCall(0)
+		require.Len(t, table.Rows, 2)
+
+		rows := map[string][]string{}
+		for _, row := range table.Rows {
+			var values []string
+			for _, value := range row {
+				values = append(values, value.String())
+			}
+			rows[values[0]] = values
+		}
+
+		bundleRow, found := rows[bundleRef]
+		require.Truef(t, found, "expected bundle %s to be listed in %v", bundleRef, rows)
+		assert.Equal(t, "image", bundleRow[2])
+		assert.Equal(t, "true", bundleRow[3])
+		assert.Contains(t, bundleRow[4], rootBundleLabelKey)
+		assert.Equal(t, "", bundleRow[7])
+
+		appRow, found := rows[appImage.RefDigest]
+		require.Truef(t, found, "expected image %s to be listed in %v", appImage.RefDigest, rows)
+		assert.Equal(t, "false", appRow[3])
+		assert.Regexp(t, `^\d+(\.\d)? (B|KiB|MiB)$`, appRow[6])
+		layers, err := appImage.Image.Layers()
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%d", len(layers)), appRow[5])
+		restrictedDigest, err := layers[len(layers)-1].Digest()
+		require.NoError(t, err)
+		assert.Equal(t, restrictedDigest.String(), appRow[7])
+
+		require.Len(t, table.Notes, 2)
+		assert.True(t, strings.HasSuffix(table.Notes[0], "1 non-distributable layers omitted"), table.Notes[0])
+		assert.True(t, strings.HasPrefix(table.Notes[1], "Format version 1, created by imgpkg"), table.Notes[1])
+	})
+
+	t.Run("when the tarball is provided as an argument it lists the images", func(t *testing.T) {
+		fakeUI := &bundlefakes.FakeUI{}
+		tarListCmd := NewTarListCmd(NewTarListOptions(fakeUI))
+		tarListCmd.SetArgs([]string{tarFile})
+		require.NoError(t, tarListCmd.Execute())
+
+		require.Equal(t, 1, fakeUI.PrintTableCallCount())
+		assert.Len(t, fakeUI.PrintTableArgsForCall(0).Rows, 2)
+	})
+
+	t.Run("when the tarball is provided both as an argument and with --tar it errors", func(t *testing.T) {
+		tarListCmd := NewTarListCmd(NewTarListOptions(&bundlefakes.FakeUI{}))
+		tarListCmd.SetArgs([]string{tarFile, "--tar", tarFile})
+		tarListCmd.SilenceUsage = true
+		err := tarListCmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not both")
+	})
+
+	t.Run("when the tarball is not provided it errors", func(t *testing.T) {
+		err := NewTarListOptions(&bundlefakes.FakeUI{}).Run()
+		require.Error(t, err)
+		assert.Equal(t, "Expected the tar file to be provided as an argument or with --tar", err.Error())
+	})
+}
+
+func TestHumanizeBytes(t *testing.T) {
+	assert.Equal(t, "0 B", humanizeBytes(0))
+	assert.Equal(t, "1023 B", humanizeBytes(1023))
+	assert.Equal(t, "1.0 KiB", humanizeBytes(1024))
+	assert.Equal(t, "1.5 MiB", humanizeBytes(1024*1024*3/2))
+	assert.Equal(t, "2.0 GiB", humanizeBytes(2*1024*1024*1024))
+}
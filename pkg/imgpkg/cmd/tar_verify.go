@@ -0,0 +1,107 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cppforlife/go-cli-ui/ui"
+	uitable "github.com/cppforlife/go-cli-ui/ui/table"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
+)
+
+// TarVerifyOptions Command that checks the contents of a tarball created by copy --to-tar
+type TarVerifyOptions struct {
+	ui ui.UI
+
+	TarPath           string
+	DecryptIdentities []string
+}
+
+// NewTarVerifyOptions constructor for building a TarVerifyOptions, holding values derived via flags
+func NewTarVerifyOptions(ui ui.UI) *TarVerifyOptions {
+	return &TarVerifyOptions{ui: ui}
+}
+
+// NewTarVerifyCmd constructor for the tar verify command
+func NewTarVerifyCmd(o *TarVerifyOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify [TAR_FILE]",
+		Short: "Verify the digest and size of every manifest and layer in a tarball",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			tarPath, err := tarPathFromArgs(o.TarPath, args)
+			if err != nil {
+				return err
+			}
+			o.TarPath = tarPath
+			return o.Run()
+		},
+		Example: `
+  # Verify the tarball /Volumes/app1-bundle.tar before importing it
+  imgpkg tar verify /Volumes/app1-bundle.tar`,
+	}
+	cmd.Flags().StringVar(&o.TarPath, "tar", "", "Path to tar file created by copy --to-tar")
+	cmd.Flags().StringSliceVar(&o.DecryptIdentities, "decrypt-identity", nil, "Path to an age identity file used to decrypt an encrypted tar file (can be specified multiple times)")
+	return cmd
+}
+
+// Run Verifies the tarball and errors when any of its contents do not match manifest.json,
+// non-distributable layers that were not included in the tarball are reported without failing
+func (o *TarVerifyOptions) Run() error {
+	if o.TarPath == "" {
+		return fmt.Errorf("Expected the tar file to be provided as an argument or with --tar")
+	}
+
+	identities, err := imagetar.ParseIdentities(o.DecryptIdentities)
+	if err != nil {
+		return err
+	}
+
+	result, err := imagetar.NewTarReader(o.TarPath).WithIdentities(identities...).Verify()
+	if err != nil {
+		return fmt.Errorf("Reading tar file '%s': %s", o.TarPath, err)
+	}
+
+	if len(result.OmittedLayers) > 0 {
+		o.printProblems("Omitted non-distributable layers", "omitted layers", result.OmittedLayers,
+			"Non-distributable layers are only included when copying with --include-non-distributable-layers")
+	}
+
+	if len(result.Problems) > 0 {
+		o.printProblems("Problems", "problems", result.Problems)
+		return fmt.Errorf("Expected tar file '%s' to match its manifest.json, but found %d problems", o.TarPath, len(result.Problems))
+	}
+
+	o.ui.BeginLinef("Verified %d manifests and configs, and %d layers in tar file '%s'\n",
+		result.VerifiedManifests, result.VerifiedLayers, o.TarPath)
+
+	return nil
+}
+
+func (o *TarVerifyOptions) printProblems(title, content string, problems []imagetar.VerifyProblem, notes ...string) {
+	table := uitable.Table{
+		Title:   title,
+		Content: content,
+
+		Header: []uitable.Header{
+			uitable.NewHeader("Digest"),
+			uitable.NewHeader("Refs"),
+			uitable.NewHeader("Problem"),
+		},
+
+		Notes: notes,
+	}
+
+	for _, problem := range problems {
+		table.Rows = append(table.Rows, []uitable.Value{
+			uitable.NewValueString(problem.Digest),
+			uitable.NewValueStrings(problem.Refs),
+			uitable.NewValueString(problem.Message),
+		})
+	}
+
+	o.ui.PrintTable(table)
+}
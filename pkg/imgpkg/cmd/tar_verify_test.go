@@ -0,0 +1,131 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/bundle/bundlefakes"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestTarVerify(t *testing.T) {
+	fakeRegistry := helpers.NewFakeRegistry(t, &helpers.Logger{LogLevel: helpers.LogDebug})
+	defer fakeRegistry.CleanUp()
+	appImage := fakeRegistry.WithRandomImage("library/app")
+	restrictedImage := fakeRegistry.WithRandomImage("library/restricted").WithNonDistributableLayer()
+	reg := fakeRegistry.Build()
+
+	copyToTar := func(t *testing.T, image string) string {
+		tarFile := filepath.Join(t.TempDir(), "images.tar")
+		imageSrc := subject
+		imageSrc.ImageFlags = ImageFlags{image}
+		imageSrc.registry = reg
+		require.NoError(t, imageSrc.CopyToTar(tarFile))
+		return tarFile
+	}
+
+	t.Run("when the tarball matches its manifest.json it succeeds", func(t *testing.T) {
+		fakeUI := &bundlefakes.FakeUI{}
+		tarVerify := NewTarVerifyOptions(fakeUI)
+		tarVerify.TarPath = copyToTar(t, appImage.RefDigest)
+		require.NoError(t, tarVerify.Run())
+		assert.Equal(t, 0, fakeUI.PrintTableCallCount())
+	})
+
+	t.Run("when a layer is corrupted it errors and reports the layer", func(t *testing.T) {
+		tarFile := copyToTar(t, appImage.RefDigest)
+		corruptedLayer := corruptFirstLayer(t, tarFile)
+
+		fakeUI := &bundlefakes.FakeUI{}
+		tarVerify := NewTarVerifyOptions(fakeUI)
+		tarVerify.TarPath = tarFile
+		err := tarVerify.Run()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found 1 problems")
+
+		require.Equal(t, 1, fakeUI.PrintTableCallCount())
+		table := fakeUI.PrintTableArgsForCall(0)
+		require.Len(t, table.Rows, 1)
+		assert.Equal(t, corruptedLayer, table.Rows[0][0].String())
+		assert.Contains(t, table.Rows[0][2].String(), "error verifying sha256 checksum")
+	})
+
+	t.Run("when the tarball is provided as an argument it verifies it", func(t *testing.T) {
+		tarFile := copyToTar(t, appImage.RefDigest)
+		corruptFirstLayer(t, tarFile)
+
+		tarVerifyCmd := NewTarVerifyCmd(NewTarVerifyOptions(&bundlefakes.FakeUI{}))
+		tarVerifyCmd.SetArgs([]string{tarFile})
+		tarVerifyCmd.SilenceUsage = true
+		err := tarVerifyCmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found 1 problems")
+	})
+
+	t.Run("when the tarball is not provided it errors", func(t *testing.T) {
+		err := NewTarVerifyOptions(&bundlefakes.FakeUI{}).Run()
+		require.Error(t, err)
+		assert.Equal(t, "Expected the tar file to be provided as an argument or with --tar", err.Error())
+	})
+
+	t.Run("when non-distributable layers were not included it reports them without failing", func(t *testing.T) {
+		fakeUI := &bundlefakes.FakeUI{}
+		tarVerify := NewTarVerifyOptions(fakeUI)
+		tarVerify.TarPath = copyToTar(t, restrictedImage.RefDigest)
+		require.NoError(t, tarVerify.Run())
+
+		require.Equal(t, 1, fakeUI.PrintTableCallCount())
+		table := fakeUI.PrintTableArgsForCall(0)
+		assert.Equal(t, "Omitted non-distributable layers", table.Title)
+		require.Len(t, table.Rows, 1)
+	})
+}
+
+// corruptFirstLayer rewrites the tarball flipping the first byte of its first layer, and returns the digest of that layer
+func corruptFirstLayer(t *testing.T, tarFile string) string {
+	src, err := os.Open(tarFile)
+	require.NoError(t, err)
+	defer src.Close()
+
+	corruptedFile := tarFile + ".corrupted"
+	dst, err := os.Create(corruptedFile)
+	require.NoError(t, err)
+	defer dst.Close()
+
+	var corruptedLayer string
+	tarReader := tar.NewReader(src)
+	tarWriter := tar.NewWriter(dst)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		contents, err := ioutil.ReadAll(tarReader)
+		require.NoError(t, err)
+		if corruptedLayer == "" && strings.HasSuffix(hdr.Name, ".tar.gz") {
+			contents[0] ^= 0xff
+			corruptedLayer = strings.Replace(strings.TrimSuffix(hdr.Name, ".tar.gz"), "-", ":", 1)
+		}
+
+		require.NoError(t, tarWriter.WriteHeader(hdr))
+		_, err = tarWriter.Write(contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NotEmpty(t, corruptedLayer)
+
+	require.NoError(t, os.Rename(corruptedFile, tarFile))
+	return corruptedLayer
+}
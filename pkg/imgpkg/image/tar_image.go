@@ -5,6 +5,7 @@ package image
 
 import (
 	"archive/tar"
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,13 +15,20 @@ import (
 )
 
 type TarImage struct {
-	files        []string
-	excludePaths []string
-	infoLog      io.Writer
+	files         []string
+	excludePaths  []string
+	infoLog       io.Writer
+	replacedFiles map[string][]byte
 }
 
 func NewTarImage(files []string, excludePaths []string, infoLog io.Writer) *TarImage {
-	return &TarImage{files, excludePaths, infoLog}
+	return &TarImage{files: files, excludePaths: excludePaths, infoLog: infoLog}
+}
+
+// WithReplacedFiles Uses the provided contents, keyed by absolute path, instead of the contents of the files on disk
+func (i *TarImage) WithReplacedFiles(replacedFiles map[string][]byte) *TarImage {
+	i.replacedFiles = replacedFiles
+	return i
 }
 
 func (i *TarImage) AsFileImage(labels map[string]string) (*FileImage, error) {
@@ -121,16 +129,29 @@ func (i *TarImage) addFileToTar(fullPath, relPath string, info os.FileInfo, tarW
 
 	i.infoLog.Write([]byte(fmt.Sprintf("file: %s\n", relPath)))
 
-	file, err := os.Open(fullPath)
+	var file io.Reader
+	size := info.Size()
+
+	absPath, err := filepath.Abs(fullPath)
 	if err != nil {
 		return err
 	}
+	if contents, found := i.replacedFiles[absPath]; found {
+		file = bytes.NewReader(contents)
+		size = int64(len(contents))
+	} else {
+		osFile, err := os.Open(fullPath)
+		if err != nil {
+			return err
+		}
 
-	defer file.Close()
+		defer osFile.Close()
+		file = osFile
+	}
 
 	header := &tar.Header{
 		Name:     relPath,
-		Size:     info.Size(),
+		Size:     size,
 		Mode:     int64(info.Mode() & 0700), // static
 		ModTime:  time.Time{},               // static
 		Typeflag: tar.TypeReg,
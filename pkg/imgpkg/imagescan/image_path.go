@@ -0,0 +1,140 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagescan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ImagePath Location of image references in the manifests of a kind, or of every kind when Kind is empty
+type ImagePath struct {
+	Kind     string
+	segments []pathSegment
+}
+
+type pathSegment struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// NewImagePath Parses an image path in the format [kind:]jsonpath (examples: spec.image, App:$.spec.components[*].image).
+// Only fields, array indexes and the [*] wildcard are supported
+func NewImagePath(value string) (ImagePath, error) {
+	var kind string
+	path := value
+	if idx := strings.Index(value, ":"); idx >= 0 {
+		kind, path = value[:idx], value[idx+1:]
+		if len(kind) == 0 {
+			return ImagePath{}, fmt.Errorf("Expected image path '%s' to have a kind before ':'", value)
+		}
+	}
+
+	segments, err := parsePath(path)
+	if err != nil {
+		return ImagePath{}, fmt.Errorf("Parsing image path '%s': %s", value, err)
+	}
+	return ImagePath{Kind: kind, segments: segments}, nil
+}
+
+func mustNewImagePath(kind, path string) ImagePath {
+	segments, err := parsePath(path)
+	if err != nil {
+		panic(fmt.Sprintf("Parsing image path '%s': %s", path, err))
+	}
+	return ImagePath{Kind: kind, segments: segments}
+}
+
+func parsePath(path string) ([]pathSegment, error) {
+	path = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(path), "{"), "}")
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if len(path) == 0 {
+		return nil, fmt.Errorf("Expected path to not be empty")
+	}
+
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		field := part
+		var brackets string
+		if idx := strings.Index(part, "["); idx >= 0 {
+			field, brackets = part[:idx], part[idx:]
+		}
+		if len(field) == 0 && (len(segments) == 0 || len(brackets) == 0) {
+			return nil, fmt.Errorf("Expected field name in '%s'", part)
+		}
+		if len(field) > 0 {
+			segments = append(segments, pathSegment{field: field})
+		}
+
+		for len(brackets) > 0 {
+			end := strings.Index(brackets, "]")
+			if !strings.HasPrefix(brackets, "[") || end < 0 {
+				return nil, fmt.Errorf("Expected '%s' to be in the form [*] or [index]", brackets)
+			}
+			value := brackets[1:end]
+			brackets = brackets[end+1:]
+
+			if value == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+				continue
+			}
+			index, err := strconv.Atoi(value)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("Expected '[%s]' to be in the form [*] or [index]", value)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+		}
+	}
+	return segments, nil
+}
+
+// foundValue value found at a concrete path (example: spec.containers[0].image)
+type foundValue struct {
+	path  string
+	value interface{}
+}
+
+// find returns the values that the path points to in the document, missing fields are skipped
+func (p ImagePath) find(doc interface{}, prefix string) []foundValue {
+	nodes := []foundValue{{path: prefix, value: doc}}
+
+	for _, segment := range p.segments {
+		var next []foundValue
+		for _, node := range nodes {
+			switch {
+			case len(segment.field) > 0:
+				obj, ok := node.value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				value, found := obj[segment.field]
+				if !found {
+					continue
+				}
+				path := segment.field
+				if len(node.path) > 0 {
+					path = node.path + "." + segment.field
+				}
+				next = append(next, foundValue{path: path, value: value})
+
+			default:
+				items, ok := node.value.([]interface{})
+				if !ok {
+					continue
+				}
+				for i, item := range items {
+					if segment.isIndex && segment.index != i {
+						continue
+					}
+					next = append(next, foundValue{path: fmt.Sprintf("%s[%d]", node.path, i), value: item})
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
@@ -0,0 +1,239 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagescan
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SourceFileAnnotation Annotation that records the files an image was found in
+	SourceFileAnnotation = "imgpkg.carvel.dev/source-file"
+	// SourcePathAnnotation Annotation that records the path of the field an image was found at, in the same order as SourceFileAnnotation
+	SourcePathAnnotation = "imgpkg.carvel.dev/source-path"
+)
+
+// podSpecPaths path of the pod spec in the standard workload kinds
+var podSpecPaths = map[string]string{
+	"Pod":                   "spec",
+	"PodTemplate":           "template.spec",
+	"Deployment":            "spec.template.spec",
+	"StatefulSet":           "spec.template.spec",
+	"DaemonSet":             "spec.template.spec",
+	"ReplicaSet":            "spec.template.spec",
+	"ReplicationController": "spec.template.spec",
+	"Job":                   "spec.template.spec",
+	"CronJob":               "spec.jobTemplate.spec.template.spec",
+}
+
+// DefaultImagePaths Image paths of the containers, init containers and ephemeral containers of the standard workload kinds
+func DefaultImagePaths() []ImagePath {
+	var paths []ImagePath
+	for kind, podSpecPath := range podSpecPaths {
+		for _, containers := range []string{"containers", "initContainers", "ephemeralContainers"} {
+			paths = append(paths, mustNewImagePath(kind, podSpecPath+"."+containers+"[*].image"))
+		}
+	}
+	return paths
+}
+
+// FoundImage Image reference found in a manifest
+type FoundImage struct {
+	Image string
+	File  string
+	Path  string
+}
+
+// Scanner Finds image references in Kubernetes manifests
+type Scanner struct {
+	imagePaths []ImagePath
+}
+
+// NewScanner Builds a Scanner that looks for images in the provided image paths
+func NewScanner(imagePaths []ImagePath) Scanner {
+	return Scanner{imagePaths: imagePaths}
+}
+
+// ScanPaths Finds images in the YAML and JSON files of the paths, in the order the files are walked.
+// Files provided directly are scanned regardless of their extension
+func (s Scanner) ScanPaths(paths []string, excludedPaths []string) ([]FoundImage, error) {
+	var result []FoundImage
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			found, err := s.scanFile(path)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, found...)
+			continue
+		}
+
+		// Walk is deterministic according to https://golang.org/pkg/path/filepath/#Walk
+		err = filepath.Walk(path, func(walkedPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			relPath, err := filepath.Rel(path, walkedPath)
+			if err != nil {
+				return err
+			}
+			if isExcluded(relPath, excludedPaths) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || !isManifestFile(walkedPath) {
+				return nil
+			}
+
+			found, err := s.scanFile(walkedPath)
+			if err != nil {
+				return err
+			}
+			result = append(result, found...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Scanning '%s': %s", path, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s Scanner) scanFile(path string) ([]FoundImage, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	found, err := s.Scan(path, bs)
+	if err != nil {
+		return nil, fmt.Errorf("Scanning '%s': %s", path, err)
+	}
+	return found, nil
+}
+
+var yamlDocumentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// Scan Finds images in the documents of a YAML or JSON file
+func (s Scanner) Scan(file string, data []byte) ([]FoundImage, error) {
+	var result []FoundImage
+
+	for i, docBytes := range yamlDocumentSeparator.Split(string(data), -1) {
+		var doc interface{}
+		err := yaml.Unmarshal([]byte(docBytes), &doc)
+		if err != nil {
+			return nil, fmt.Errorf("Parsing document %d: %s", i+1, err)
+		}
+
+		found, err := s.scanDocument(file, doc, "")
+		if err != nil {
+			return nil, fmt.Errorf("Document %d: %s", i+1, err)
+		}
+		result = append(result, found...)
+	}
+
+	return result, nil
+}
+
+func (s Scanner) scanDocument(file string, doc interface{}, prefix string) ([]FoundImage, error) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	kind, _ := obj["kind"].(string)
+
+	// Resources of a List are scanned as if they were separate documents
+	if items, ok := obj["items"].([]interface{}); ok && kind == "List" {
+		var result []FoundImage
+		for i, item := range items {
+			found, err := s.scanDocument(file, item, fmt.Sprintf("%sitems[%d]", prefix, i))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, found...)
+		}
+		return result, nil
+	}
+
+	var result []FoundImage
+	for _, imagePath := range s.imagePaths {
+		if len(imagePath.Kind) > 0 && imagePath.Kind != kind {
+			continue
+		}
+
+		for _, value := range imagePath.find(doc, prefix) {
+			image, ok := value.value.(string)
+			if !ok {
+				return nil, fmt.Errorf("Expected value at '%s' to be an image reference", value.path)
+			}
+			if len(image) == 0 {
+				continue
+			}
+			result = append(result, FoundImage{Image: image, File: file, Path: value.path})
+		}
+	}
+	return result, nil
+}
+
+// NewImagesLock Returns an images lock with the found images, in the order they were first found, annotated
+// with the files and paths they were found at. Images referenced by tag need to be resolved before the lock is valid
+func NewImagesLock(foundImages []FoundImage) lockconfig.ImagesLock {
+	lock := lockconfig.NewEmptyImagesLock()
+
+	imageIdx := map[string]int{}
+	var files, paths [][]string
+	for _, found := range foundImages {
+		idx, ok := imageIdx[found.Image]
+		if !ok {
+			idx = len(lock.Images)
+			imageIdx[found.Image] = idx
+			lock.Images = append(lock.Images, lockconfig.ImageRef{Image: found.Image, Annotations: map[string]string{}})
+			files = append(files, nil)
+			paths = append(paths, nil)
+		}
+		files[idx] = append(files[idx], found.File)
+		paths[idx] = append(paths[idx], found.Path)
+	}
+
+	for i := range lock.Images {
+		lock.Images[i].Annotations[SourceFileAnnotation] = strings.Join(files[i], ", ")
+		lock.Images[i].Annotations[SourcePathAnnotation] = strings.Join(paths[i], ", ")
+	}
+	return lock
+}
+
+func isManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml", ".json":
+		return true
+	default:
+		return false
+	}
+}
+
+func isExcluded(relPath string, excludedPaths []string) bool {
+	for _, path := range excludedPaths {
+		if path == relPath {
+			return true
+		}
+	}
+	return false
+}
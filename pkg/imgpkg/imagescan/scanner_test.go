@@ -0,0 +1,172 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagescan_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagescan"
+)
+
+const workloadsYAML = `apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+spec:
+  initContainers:
+  - name: init
+    image: busybox:1.36
+  containers:
+  - name: app
+    image: nginx:1.25
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx:1.25
+      - name: sidecar
+        image: envoyproxy/envoy:v1.28.0
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cronjob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: job
+            image: alpine:3.18
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-a-workload
+data:
+  image: redis:7
+`
+
+func TestScanner_Scan(t *testing.T) {
+	t.Run("finds the images of the standard workloads", func(t *testing.T) {
+		found, err := imagescan.NewScanner(imagescan.DefaultImagePaths()).Scan("config.yml", []byte(workloadsYAML))
+		require.NoError(t, err)
+
+		assert.Equal(t, []imagescan.FoundImage{
+			{Image: "nginx:1.25", File: "config.yml", Path: "spec.containers[0].image"},
+			{Image: "busybox:1.36", File: "config.yml", Path: "spec.initContainers[0].image"},
+			{Image: "nginx:1.25", File: "config.yml", Path: "spec.template.spec.containers[0].image"},
+			{Image: "envoyproxy/envoy:v1.28.0", File: "config.yml", Path: "spec.template.spec.containers[1].image"},
+			{Image: "alpine:3.18", File: "config.yml", Path: "spec.jobTemplate.spec.template.spec.containers[0].image"},
+		}, found)
+	})
+
+	t.Run("finds the images of the resources in a JSON List", func(t *testing.T) {
+		list := `{"apiVersion": "v1", "kind": "List", "items": [{"kind": "Pod", "spec": {"containers": [{"image": "nginx:1.25"}]}}]}`
+
+		found, err := imagescan.NewScanner(imagescan.DefaultImagePaths()).Scan("list.json", []byte(list))
+		require.NoError(t, err)
+
+		assert.Equal(t, []imagescan.FoundImage{
+			{Image: "nginx:1.25", File: "list.json", Path: "items[0].spec.containers[0].image"},
+		}, found)
+	})
+
+	t.Run("finds the images in the provided image paths", func(t *testing.T) {
+		app := `kind: App
+spec:
+  components:
+  - image: nginx:1.25
+  - image: redis:7
+`
+		appPath, err := imagescan.NewImagePath("App:$.spec.components[*].image")
+		require.NoError(t, err)
+		otherKindPath, err := imagescan.NewImagePath("Other:spec.components[0].image")
+		require.NoError(t, err)
+		anyKindPath, err := imagescan.NewImagePath("{.spec.components[1].image}")
+		require.NoError(t, err)
+
+		found, err := imagescan.NewScanner([]imagescan.ImagePath{appPath, otherKindPath, anyKindPath}).Scan("app.yml", []byte(app))
+		require.NoError(t, err)
+
+		assert.Equal(t, []imagescan.FoundImage{
+			{Image: "nginx:1.25", File: "app.yml", Path: "spec.components[0].image"},
+			{Image: "redis:7", File: "app.yml", Path: "spec.components[1].image"},
+			{Image: "redis:7", File: "app.yml", Path: "spec.components[1].image"},
+		}, found)
+	})
+
+	t.Run("when the image path points to a value that is not a string it errors", func(t *testing.T) {
+		imagePath, err := imagescan.NewImagePath("spec")
+		require.NoError(t, err)
+
+		_, err = imagescan.NewScanner([]imagescan.ImagePath{imagePath}).Scan("app.yml", []byte("spec:\n  image: nginx\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected value at 'spec' to be an image reference")
+	})
+
+	t.Run("when a document is not valid YAML it errors", func(t *testing.T) {
+		_, err := imagescan.NewScanner(imagescan.DefaultImagePaths()).Scan("config.yml", []byte("kind: Pod\n---\nkind: [Pod\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Parsing document 2")
+	})
+}
+
+func TestNewImagePath(t *testing.T) {
+	for _, value := range []string{"", ":spec.image", "spec.containers[a].image", "spec.containers[0.image", "spec..image"} {
+		t.Run(value, func(t *testing.T) {
+			_, err := imagescan.NewImagePath(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestScanner_ScanPaths(t *testing.T) {
+	configDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "workloads.yml"), []byte(workloadsYAML), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "README.md"), []byte("kind: Pod\nspec:\n  containers:\n  - image: ignored:1\n"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(configDir, "excluded"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "excluded", "pod.yml"), []byte("kind: Pod\nspec:\n  containers:\n  - image: excluded:1\n"), 0600))
+
+	found, err := imagescan.NewScanner(imagescan.DefaultImagePaths()).ScanPaths([]string{configDir}, []string{"excluded"})
+	require.NoError(t, err)
+
+	var images []string
+	for _, foundImage := range found {
+		assert.Equal(t, filepath.Join(configDir, "workloads.yml"), foundImage.File)
+		images = append(images, foundImage.Image)
+	}
+	assert.Equal(t, []string{"nginx:1.25", "busybox:1.36", "nginx:1.25", "envoyproxy/envoy:v1.28.0", "alpine:3.18"}, images)
+}
+
+func TestNewImagesLock(t *testing.T) {
+	lock := imagescan.NewImagesLock([]imagescan.FoundImage{
+		{Image: "nginx:1.25", File: "a.yml", Path: "spec.containers[0].image"},
+		{Image: "redis:7", File: "a.yml", Path: "spec.containers[1].image"},
+		{Image: "nginx:1.25", File: "b.yml", Path: "spec.template.spec.containers[0].image"},
+	})
+
+	require.Len(t, lock.Images, 2)
+	assert.Equal(t, "nginx:1.25", lock.Images[0].Image)
+	assert.Equal(t, map[string]string{
+		imagescan.SourceFileAnnotation: "a.yml, b.yml",
+		imagescan.SourcePathAnnotation: "spec.containers[0].image, spec.template.spec.containers[0].image",
+	}, lock.Images[0].Annotations)
+	assert.Equal(t, "redis:7", lock.Images[1].Image)
+	assert.Equal(t, map[string]string{
+		imagescan.SourceFileAnnotation: "a.yml",
+		imagescan.SourcePathAnnotation: "spec.containers[1].image",
+	}, lock.Images[1].Annotations)
+}
@@ -4,30 +4,71 @@
 package imageset
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"io"
 	"os"
 
+	"filippo.io/age"
 	goui "github.com/cppforlife/go-cli-ui/ui"
 	regname "github.com/google/go-containerregistry/pkg/name"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagetar"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
 
 type TarImageSet struct {
 	imageSet    ImageSet
 	concurrency int
+	retryPolicy util.RetryPolicy
 	ui          goui.UI
+
+	recipients []age.Recipient
+	identities []age.Identity
+	signingKey *ecdsa.PrivateKey
+	trustedKey *ecdsa.PublicKey
+
+	imgpkgVersion string
 }
 
 // NewTarImageSet provides export/import operations on a tarball for a set of images
-func NewTarImageSet(imageSet ImageSet, concurrency int, ui goui.UI) TarImageSet {
-	return TarImageSet{imageSet, concurrency, ui}
+func NewTarImageSet(imageSet ImageSet, concurrency int, retryPolicy util.RetryPolicy, ui goui.UI) TarImageSet {
+	return TarImageSet{imageSet: imageSet, concurrency: concurrency, retryPolicy: retryPolicy, ui: ui}
+}
+
+// WithRecipients Encrypts the tarballs that are exported to the recipients
+func (i TarImageSet) WithRecipients(recipients ...age.Recipient) TarImageSet {
+	i.recipients = recipients
+	return i
+}
+
+// WithIdentities Decrypts the tarballs that are imported with the identities
+func (i TarImageSet) WithIdentities(identities ...age.Identity) TarImageSet {
+	i.identities = identities
+	return i
+}
+
+// WithImgpkgVersion Records the version of imgpkg in the format of the tarballs that are exported
+func (i TarImageSet) WithImgpkgVersion(version string) TarImageSet {
+	i.imgpkgVersion = version
+	return i
+}
+
+// WithSigningKey Signs the tarballs that are exported with the key
+func (i TarImageSet) WithSigningKey(key *ecdsa.PrivateKey) TarImageSet {
+	i.signingKey = key
+	return i
+}
+
+// WithTrustedKey Only imports the tarballs that were signed by the key
+func (i TarImageSet) WithTrustedKey(key *ecdsa.PublicKey) TarImageSet {
+	i.trustedKey = key
+	return i
 }
 
-// Export Creates a Tar with the provided Images
-func (i TarImageSet) Export(foundImages *UnprocessedImageRefs, outputPath string, registry registry.ImagesReaderWriter, imageLayerWriterCheck imagetar.ImageLayerWriterFilter) (*imagedesc.ImageRefDescriptors, error) {
+// Export Creates a Tar with the provided Images, and the catalog listing the root bundles
+func (i TarImageSet) Export(foundImages *UnprocessedImageRefs, catalog imagetar.Catalog, outputPath string, registry registry.ImagesReaderWriter, imageLayerWriterCheck imagetar.ImageLayerWriterFilter) (*imagedesc.ImageRefDescriptors, error) {
 	ids, err := i.imageSet.Export(foundImages, registry)
 	if err != nil {
 		return nil, err
@@ -44,22 +85,52 @@ func (i TarImageSet) Export(foundImages *UnprocessedImageRefs, outputPath string
 	}
 
 	outputFileOpener := func() (io.WriteCloser, error) {
-		return os.OpenFile(outputPath, os.O_RDWR, 0755)
+		outputFile, err := os.OpenFile(outputPath, os.O_RDWR, 0755)
+		if err != nil || len(i.recipients) == 0 {
+			return outputFile, err
+		}
+		return imagetar.NewEncryptedWriter(outputFile, i.recipients)
 	}
 
 	i.ui.BeginLinef("writing layers...\n")
 
-	opts := imagetar.TarWriterOpts{Concurrency: i.concurrency}
+	opts := imagetar.TarWriterOpts{Concurrency: i.concurrency, RetryPolicy: i.retryPolicy, ImgpkgVersion: i.imgpkgVersion}
 
-	return ids, imagetar.NewTarWriter(ids, outputFileOpener, opts, i.ui, imageLayerWriterCheck).Write()
+	return ids, imagetar.NewTarWriter(ids, outputFileOpener, opts, i.ui, imageLayerWriterCheck).
+		WithCatalog(catalog).WithSigningKey(i.signingKey).Write()
 }
 
 // Import Copy tar with Images to the Registry
 func (i *TarImageSet) Import(path string, importRepo regname.Repository, registry registry.ImagesReaderWriter) (*ProcessedImages, error) {
-	imgOrIndexes, err := imagetar.NewTarReader(path).Read()
+	return i.ImportSelected(path, nil, importRepo, registry)
+}
+
+// ImportSelected Copy the Images of the tar chosen by the selector to the Registry, every Image is copied when the selector is nil
+func (i *TarImageSet) ImportSelected(path string, selector func(imagetar.TarReader, []imagedesc.ImageOrIndex) ([]imagedesc.ImageOrIndex, error), importRepo regname.Repository, registry registry.ImagesReaderWriter) (*ProcessedImages, error) {
+	tarReader, err := imagetar.NewTarReader(path).WithIdentities(i.identities...).Decrypt()
 	if err != nil {
 		return nil, err
 	}
+	defer tarReader.Close()
+
+	if i.trustedKey != nil {
+		tarReader, err = tarReader.VerifySignature(i.trustedKey)
+		if err != nil {
+			return nil, fmt.Errorf("Verifying signature of tar file '%s': %s", path, err)
+		}
+	}
+
+	imgOrIndexes, err := tarReader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	if selector != nil {
+		imgOrIndexes, err = selector(tarReader, imgOrIndexes)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	processedImages, err := i.imageSet.Import(imgOrIndexes, importRepo, registry)
 	if err != nil {
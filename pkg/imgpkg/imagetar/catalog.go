@@ -0,0 +1,60 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CatalogFile Name of the tar entry that lists the root bundles copied into the tarball
+const CatalogFile = "catalog.json"
+
+// Catalog Lists the bundles that were provided as a source when the tarball was created
+type Catalog struct {
+	Bundles []CatalogBundle `json:"bundles"`
+}
+
+// CatalogBundle Root bundle copied into the tarball
+type CatalogBundle struct {
+	Image string `json:"image"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// Contains Checks if the bundle is listed in the catalog
+func (c Catalog) Contains(image string) bool {
+	for _, bundle := range c.Bundles {
+		if bundle.Image == image {
+			return true
+		}
+	}
+	return false
+}
+
+// AsBytes Serializes the catalog to be written to the tarball
+func (c Catalog) AsBytes() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// Catalog Reads the catalog of the tarball, returns nil when the tarball was created without one
+func (r TarReader) Catalog() (*Catalog, error) {
+	file := r.file()
+
+	found, err := file.HasChunk(CatalogFile)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	catalogBytes, err := file.readChunk(CatalogFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog Catalog
+	err = json.Unmarshal(catalogBytes, &catalog)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling %s: %s", CatalogFile, err)
+	}
+	return &catalog, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// encryptedTarHeader First line of every file encrypted with age
+const encryptedTarHeader = "age-encryption.org/"
+
+// ParseRecipients Parses the age recipients a tarball is encrypted to, each value is either
+// an age public key (age1...) or the path to a file listing one public key per line
+func ParseRecipients(values []string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, value := range values {
+		if strings.HasPrefix(value, "age1") {
+			recipient, err := age.ParseX25519Recipient(value)
+			if err != nil {
+				return nil, fmt.Errorf("Parsing recipient '%s': %s", value, err)
+			}
+			recipients = append(recipients, recipient)
+			continue
+		}
+
+		file, err := os.Open(value)
+		if err != nil {
+			return nil, fmt.Errorf("Opening recipients file: %s", err)
+		}
+		fileRecipients, err := age.ParseRecipients(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Parsing recipients file '%s': %s", value, err)
+		}
+		recipients = append(recipients, fileRecipients...)
+	}
+	return recipients, nil
+}
+
+// ParseIdentities Parses the age identities files used to decrypt a tarball
+func ParseIdentities(paths []string) ([]age.Identity, error) {
+	var identities []age.Identity
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("Opening identity file: %s", err)
+		}
+		fileIdentities, err := age.ParseIdentities(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Parsing identity file '%s': %s", path, err)
+		}
+		identities = append(identities, fileIdentities...)
+	}
+	return identities, nil
+}
+
+// encryptedWriteCloser Encrypts everything written to the file, closing it flushes
+// and authenticates the last chunk before closing the file
+type encryptedWriteCloser struct {
+	io.WriteCloser
+	file io.Closer
+}
+
+// NewEncryptedWriter Encrypts and authenticates everything written to the destination with age
+func NewEncryptedWriter(dst io.WriteCloser, recipients []age.Recipient) (io.WriteCloser, error) {
+	writer, err := age.Encrypt(dst, recipients...)
+	if err != nil {
+		dst.Close()
+		return nil, fmt.Errorf("Encrypting tar file: %s", err)
+	}
+	return encryptedWriteCloser{writer, dst}, nil
+}
+
+// Close Finishes the encryption and closes the file
+func (w encryptedWriteCloser) Close() error {
+	err := w.WriteCloser.Close()
+	if err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// decryptedReadCloser Decrypts the file while it is read
+type decryptedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// openDecrypted opens the file, decrypting it when identities are provided.
+// A tarball that is encrypted but no identities were provided for is rejected early
+func openDecrypted(path string, identities []age.Identity) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plain tarballs are returned as is, so that the tar reader can seek over the entries it skips
+	header := make([]byte, len(encryptedTarHeader))
+	n, _ := file.ReadAt(header, 0)
+	isEncrypted := bytes.Equal(header[:n], []byte(encryptedTarHeader))
+
+	switch {
+	case isEncrypted && len(identities) == 0:
+		file.Close()
+		return nil, fmt.Errorf("Expected an identity to decrypt the encrypted tar file (hint: Provide it with --decrypt-identity)")
+
+	case !isEncrypted && len(identities) > 0:
+		file.Close()
+		return nil, fmt.Errorf("Expected tar file to be encrypted when an identity to decrypt it is provided")
+
+	case isEncrypted:
+		decrypted, err := age.Decrypt(file, identities...)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("Decrypting tar file: %s", err)
+		}
+		return decryptedReadCloser{decrypted, file}, nil
+
+	default:
+		return file, nil
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarReaderDecrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	encryptedPath := filepath.Join(t.TempDir(), "encrypted.tar")
+	file, err := os.Create(encryptedPath)
+	require.NoError(t, err)
+	encryptedWriter, err := NewEncryptedWriter(file, []age.Recipient{identity.Recipient()})
+	require.NoError(t, err)
+
+	tarWriter := tar.NewWriter(encryptedWriter)
+	for name, contents := range map[string]string{"manifest.json": "[]", "sha256-abc.tar.gz": "layer"} {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Size: int64(len(contents)), Mode: 0644, Typeflag: tar.TypeReg}))
+		_, err := tarWriter.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, encryptedWriter.Close())
+
+	t.Run("when the tar is not decrypted first reading its images errors", func(t *testing.T) {
+		_, err := NewTarReader(encryptedPath).WithIdentities(identity).Read()
+		require.Error(t, err)
+		assert.Equal(t, "Expected encrypted tar file to be decrypted before reading its images", err.Error())
+	})
+
+	t.Run("the tar is decrypted once and its entries are read from the decrypted copy", func(t *testing.T) {
+		copiedPath := filepath.Join(t.TempDir(), "copied.tar")
+		contents, err := os.ReadFile(encryptedPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(copiedPath, contents, 0600))
+
+		tarReader, err := NewTarReader(copiedPath).WithIdentities(identity).Decrypt()
+		require.NoError(t, err)
+
+		// The encrypted tar is no longer needed once decrypted
+		require.NoError(t, os.Remove(copiedPath))
+
+		_, err = tarReader.Read()
+		require.NoError(t, err)
+		layer, err := tarReader.file().readChunk("sha256-abc.tar.gz")
+		require.NoError(t, err)
+		assert.Equal(t, "layer", string(layer))
+
+		require.NoError(t, tarReader.Close())
+		assert.NoFileExists(t, tarReader.decryptedPath)
+	})
+
+	t.Run("when the tar is not encrypted it is read in place", func(t *testing.T) {
+		plainPath := filepath.Join(t.TempDir(), "plain.tar")
+		plainFile, err := os.Create(plainPath)
+		require.NoError(t, err)
+		tarWriter := tar.NewWriter(plainFile)
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "manifest.json", Size: 2, Mode: 0644, Typeflag: tar.TypeReg}))
+		_, err = tarWriter.Write([]byte("[]"))
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, plainFile.Close())
+
+		tarReader, err := NewTarReader(plainPath).Decrypt()
+		require.NoError(t, err)
+		defer tarReader.Close()
+		assert.Empty(t, tarReader.decryptedPath)
+
+		_, err = tarReader.Read()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FormatFile Name of the tar entry that describes the format of the tarball, it is the first entry of the tarball
+const FormatFile = "format.json"
+
+// FormatVersion Version of the format of the tarballs written, and the latest version that can be read.
+// Tarballs created before the format was versioned do not contain FormatFile
+const FormatVersion = 1
+
+const (
+	// FeatureCatalog The tarball lists its root bundles in CatalogFile
+	FeatureCatalog = "catalog"
+	// FeatureSignature The contents of the tarball are signed in SignatureFile
+	FeatureSignature = "signature"
+	// FeatureOmittedNonDistributableLayers Non-distributable layers were not written to the tarball
+	FeatureOmittedNonDistributableLayers = "omitted-non-distributable-layers"
+)
+
+var supportedFeatures = map[string]struct{}{
+	FeatureCatalog:                       {},
+	FeatureSignature:                     {},
+	FeatureOmittedNonDistributableLayers: {},
+}
+
+// Format Describes how the tarball was written, so that readers can reject tarballs they do not understand
+type Format struct {
+	Version       int       `json:"version"`
+	ImgpkgVersion string    `json:"imgpkgVersion,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	RootBundles   []string  `json:"rootBundles,omitempty"`
+	Features      []string  `json:"features,omitempty"`
+}
+
+// AsBytes Serializes the format to be written to the tarball
+func (f Format) AsBytes() ([]byte, error) {
+	return json.Marshal(f)
+}
+
+// HasFeature Checks if the tarball was written with the feature
+func (f Format) HasFeature(feature string) bool {
+	for _, f := range f.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate Checks that the version and the features of the tarball can be read
+func (f Format) Validate() error {
+	hint := "upgrade imgpkg to read it"
+	if f.ImgpkgVersion != "" {
+		hint = fmt.Sprintf("the tar file was created by imgpkg %s, upgrade imgpkg to read it", f.ImgpkgVersion)
+	}
+
+	if f.Version > FormatVersion {
+		return fmt.Errorf("Expected tar file format version to be at most %d, but was %d (hint: %s)", FormatVersion, f.Version, hint)
+	}
+
+	var unsupported []string
+	for _, feature := range f.Features {
+		if _, found := supportedFeatures[feature]; !found {
+			unsupported = append(unsupported, feature)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("Expected tar file to only use supported features, but it uses: %s (hint: %s)", strings.Join(unsupported, ", "), hint)
+	}
+
+	return nil
+}
+
+// Format Reads the format of the tarball, returns nil when the tarball was created before the format was versioned.
+// Only the first entry of the tarball is read
+func (r TarReader) Format() (*Format, error) {
+	file, err := r.file().open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tf := tar.NewReader(file)
+	hdr, err := tf.Next()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Name != FormatFile {
+		return nil, nil
+	}
+
+	var formatFile io.Reader = tf
+	if r.signedEntries != nil {
+		formatFile, err = verifySignedEntry(r.signedEntries, FormatFile, ioutil.NopCloser(tf))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	formatBytes, err := ioutil.ReadAll(formatFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var format Format
+	err = json.Unmarshal(formatBytes, &format)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling %s: %s", FormatFile, err)
+	}
+	return &format, nil
+}
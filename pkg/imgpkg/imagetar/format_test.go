@@ -0,0 +1,81 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarReaderFormat(t *testing.T) {
+	writeTar := func(t *testing.T, entries ...[2]string) string {
+		path := filepath.Join(t.TempDir(), "images.tar")
+		file, err := os.Create(path)
+		require.NoError(t, err)
+		defer file.Close()
+
+		tarWriter := tar.NewWriter(file)
+		for _, entry := range entries {
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: entry[0], Size: int64(len(entry[1])), Mode: 0644, Typeflag: tar.TypeReg}))
+			_, err := tarWriter.Write([]byte(entry[1]))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tarWriter.Close())
+		return path
+	}
+
+	t.Run("when the tar was created before the format was versioned it is read", func(t *testing.T) {
+		tarReader := NewTarReader(writeTar(t, [2]string{"manifest.json", "[]"}))
+
+		format, err := tarReader.Format()
+		require.NoError(t, err)
+		assert.Nil(t, format)
+
+		ids, err := tarReader.Descriptors()
+		require.NoError(t, err)
+		assert.Empty(t, ids.Descriptors())
+	})
+
+	t.Run("when the tar uses a supported format it is read", func(t *testing.T) {
+		tarReader := NewTarReader(writeTar(t,
+			[2]string{FormatFile, `{"version":1,"imgpkgVersion":"0.30.0","createdAt":"2021-01-01T00:00:00Z","rootBundles":["registry.io/bundle@sha256:abc"],"features":["catalog"]}`},
+			[2]string{"manifest.json", "[]"}))
+
+		format, err := tarReader.Format()
+		require.NoError(t, err)
+		require.NotNil(t, format)
+		assert.Equal(t, 1, format.Version)
+		assert.Equal(t, "0.30.0", format.ImgpkgVersion)
+		assert.Equal(t, []string{"registry.io/bundle@sha256:abc"}, format.RootBundles)
+		assert.True(t, format.HasFeature(FeatureCatalog))
+
+		_, err = tarReader.Descriptors()
+		require.NoError(t, err)
+	})
+
+	t.Run("when the tar uses a newer format version it errors before reading manifest.json", func(t *testing.T) {
+		tarReader := NewTarReader(writeTar(t,
+			[2]string{FormatFile, `{"version":2,"imgpkgVersion":"1.0.0","createdAt":"2021-01-01T00:00:00Z"}`},
+			[2]string{"manifest.json", `{"images":{}}`}))
+
+		_, err := tarReader.Read()
+		require.Error(t, err)
+		assert.Equal(t, "Expected tar file format version to be at most 1, but was 2 (hint: the tar file was created by imgpkg 1.0.0, upgrade imgpkg to read it)", err.Error())
+	})
+
+	t.Run("when the tar uses unknown features it errors", func(t *testing.T) {
+		tarReader := NewTarReader(writeTar(t,
+			[2]string{FormatFile, `{"version":1,"createdAt":"2021-01-01T00:00:00Z","features":["catalog","delta-export"]}`},
+			[2]string{"manifest.json", "[]"}))
+
+		_, err := tarReader.Descriptors()
+		require.Error(t, err)
+		assert.Equal(t, "Expected tar file to only use supported features, but it uses: delta-export (hint: upgrade imgpkg to read it)", err.Error())
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageutils/verify"
+)
+
+// SignatureFile Name of the tar entry that holds the signature of the tarball contents
+const SignatureFile = "signature.json"
+
+// SignedContents Digests of every entry of the tarball, the digests of manifest.json and catalog.json
+// are calculated from their contents and the layers are named after their digest
+type SignedContents struct {
+	Entries map[string]string `json:"entries"`
+}
+
+// TarSignature Signed contents of the tarball, the signature is an ECDSA signature of the sha256 of the payload
+type TarSignature struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// NewTarSignature Signs the contents of the tarball with the key
+func NewTarSignature(contents SignedContents, key *ecdsa.PrivateKey) (TarSignature, error) {
+	payload, err := json.Marshal(contents)
+	if err != nil {
+		return TarSignature{}, err
+	}
+
+	payloadDigest := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, payloadDigest[:])
+	if err != nil {
+		return TarSignature{}, fmt.Errorf("Signing tar file contents: %s", err)
+	}
+
+	return TarSignature{Payload: payload, Signature: signature}, nil
+}
+
+// AsBytes Serializes the signature to be written to the tarball
+func (s TarSignature) AsBytes() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// VerifySignature Checks that the tarball was signed by the trusted key, that every entry of the tarball was signed
+// and that manifest.json and catalog.json were not modified after being signed. The returned reader checks again
+// the contents of every entry, layers included, against its signed digest while the entry is read, so the bytes
+// that are imported are the bytes that were verified even if the file changes in between
+func (r TarReader) VerifySignature(trustedKey *ecdsa.PublicKey) (TarReader, error) {
+	file := r.file()
+
+	found, err := file.HasChunk(SignatureFile)
+	if err != nil {
+		return r, err
+	}
+	if !found {
+		return r, fmt.Errorf("Expected tar file to be signed, but it does not contain %s (hint: Sign it with copy --to-tar --tar-signing-key)", SignatureFile)
+	}
+
+	signatureBytes, err := file.readChunk(SignatureFile)
+	if err != nil {
+		return r, err
+	}
+
+	var signature TarSignature
+	err = json.Unmarshal(signatureBytes, &signature)
+	if err != nil {
+		return r, fmt.Errorf("Unmarshaling %s: %s", SignatureFile, err)
+	}
+
+	payloadDigest := sha256.Sum256(signature.Payload)
+	if !ecdsa.VerifyASN1(trustedKey, payloadDigest[:], signature.Signature) {
+		return r, fmt.Errorf("Expected tar file to be signed by the trusted key")
+	}
+
+	var contents SignedContents
+	err = json.Unmarshal(signature.Payload, &contents)
+	if err != nil {
+		return r, fmt.Errorf("Unmarshaling signed contents: %s", err)
+	}
+
+	names, err := file.chunkNames()
+	if err != nil {
+		return r, err
+	}
+
+	missingEntries := map[string]struct{}{}
+	for name := range contents.Entries {
+		missingEntries[name] = struct{}{}
+	}
+
+	for _, name := range names {
+		if name == SignatureFile {
+			continue
+		}
+		expectedDigest, signed := contents.Entries[name]
+		if !signed {
+			return r, fmt.Errorf("Expected tar file entry '%s' to be signed", name)
+		}
+		delete(missingEntries, name)
+
+		// Layers are large and are checked against their signed digest when they are imported
+		if strings.HasSuffix(name, layerFileSuffix) {
+			continue
+		}
+
+		entryBytes, err := file.readChunk(name)
+		if err != nil {
+			return r, err
+		}
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(entryBytes))
+		if digest != expectedDigest {
+			return r, fmt.Errorf("Expected tar file entry '%s' to have signed digest '%s' but was '%s'", name, expectedDigest, digest)
+		}
+	}
+
+	if len(missingEntries) > 0 {
+		var missing []string
+		for name := range missingEntries {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return r, fmt.Errorf("Expected tar file to contain the signed entries: %s", strings.Join(missing, ", "))
+	}
+
+	r.signedEntries = contents.Entries
+	return r, nil
+}
+
+// signedEntryReadCloser reads a tar entry, failing when its contents do not match the signed digest
+type signedEntryReadCloser struct {
+	name     string
+	verified io.ReadCloser
+}
+
+// verifySignedEntry wraps the contents of the entry to be checked against its signed digest while they are read
+func verifySignedEntry(signedEntries map[string]string, name string, contents io.ReadCloser) (io.ReadCloser, error) {
+	signedDigest, signed := signedEntries[name]
+	if !signed {
+		return nil, fmt.Errorf("Expected tar file entry '%s' to be signed", name)
+	}
+
+	hash, err := regv1.NewHash(signedDigest)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing signed digest of tar file entry '%s': %s", name, err)
+	}
+
+	verified, err := verify.ReadCloser(contents, hash)
+	if err != nil {
+		return nil, err
+	}
+	return signedEntryReadCloser{name, verified}, nil
+}
+
+func (s signedEntryReadCloser) Read(p []byte) (int, error) {
+	n, err := s.verified.Read(p)
+	if err != nil && err != io.EOF {
+		return n, fmt.Errorf("Reading signed tar file entry '%s': %s", s.name, err)
+	}
+	return n, err
+}
+
+func (s signedEntryReadCloser) Close() error {
+	return s.verified.Close()
+}
@@ -7,16 +7,23 @@ import (
 	"archive/tar"
 	"fmt"
 	"io"
-	"os"
+	"io/ioutil"
 	"strings"
 
+	"filippo.io/age"
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
 
+// layerFileSuffix is the extension of the tar entries of the layers, which are named after their digest
+const layerFileSuffix = ".tar.gz"
+
 type tarFile struct {
-	path string
+	path       string
+	identities []age.Identity
+	// signedEntries, when set, are the digests every entry is checked against while it is read
+	signedEntries map[string]string
 }
 
 var _ imagedesc.LayerProvider = tarFile{}
@@ -34,16 +41,85 @@ type tarFileChunkReadCloser struct {
 	io.Closer
 }
 
+// open opens the tar, decrypting it while it is read when it is encrypted
+func (f tarFile) open() (io.ReadCloser, error) {
+	return openDecrypted(f.path, f.identities)
+}
+
 func (f tarFile) Chunk(path string) tarFileChunk {
 	return tarFileChunk{f, path}
 }
 
+// HasChunk checks if the tar contains an entry with the path
+func (f tarFile) HasChunk(path string) (bool, error) {
+	file, err := f.open()
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	tf := tar.NewReader(file)
+	for {
+		hdr, err := tf.Next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if hdr.Name == path {
+			return true, nil
+		}
+	}
+}
+
+// readChunk reads the whole contents of the entry, used for the small json entries of the tar
+func (f tarFile) readChunk(path string) ([]byte, error) {
+	chunk, err := f.Chunk(path).Open()
+	if err != nil {
+		return nil, err
+	}
+	defer chunk.Close()
+
+	return ioutil.ReadAll(chunk)
+}
+
+func (f tarFile) chunkNames() ([]string, error) {
+	file, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var names []string
+	tf := tar.NewReader(file)
+	for {
+		hdr, err := tf.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, hdr.Name)
+	}
+}
+
 func (f tarFile) FindLayer(layerTD imagedesc.ImageLayerDescriptor) (imagedesc.LayerContents, error) {
-	digest, err := regv1.NewHash(layerTD.Digest)
+	name, err := layerFileName(layerTD.Digest)
 	if err != nil {
 		return nil, err
 	}
-	return tarFileChunk{f, digest.Algorithm + "-" + digest.Hex + ".tar.gz"}, nil
+	return tarFileChunk{f, name}, nil
+}
+
+// layerFileName returns the name of the tar entry of the layer
+func layerFileName(layerDigest string) (string, error) {
+	digest, err := regv1.NewHash(layerDigest)
+	if err != nil {
+		return "", err
+	}
+	return digest.Algorithm + "-" + digest.Hex + layerFileSuffix, nil
 }
 
 func (f tarFileChunk) Open() (io.ReadCloser, error) {
@@ -51,7 +127,7 @@ func (f tarFileChunk) Open() (io.ReadCloser, error) {
 }
 
 func (f tarFile) openChunk(path string) (io.ReadCloser, error) {
-	file, err := os.Open(f.path)
+	file, err := f.open()
 	if err != nil {
 		return nil, err
 	}
@@ -65,9 +141,19 @@ func (f tarFile) openChunk(path string) (io.ReadCloser, error) {
 			return nil, err
 		}
 		if hdr.Name == path {
-			return tarFileChunkReadCloser{
+			chunk := tarFileChunkReadCloser{
 				DebugID: fmt.Sprintf("%s/%p", path, tf),
-				Reader:  tf, Closer: file}, nil
+				Reader:  tf, Closer: file}
+			if f.signedEntries == nil {
+				return chunk, nil
+			}
+
+			verifiedChunk, err := verifySignedEntry(f.signedEntries, path, chunk)
+			if err != nil {
+				file.Close()
+				return nil, err
+			}
+			return verifiedChunk, nil
 		}
 	}
 	return nil, util.NonRetryableError{Message: fmt.Sprintf("file %s not found in tar (hint: This may be because when copying to a tarball, the --include-non-distributable-layers flag should have been provided.)", path)}
@@ -0,0 +1,157 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"fmt"
+	"net/http"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	regtran "github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+)
+
+// TarImagesMetadata provides the images stored in a tarball created by copy --to-tar,
+// reading the layers from the tarball only when their contents are needed
+type TarImagesMetadata struct {
+	imgOrIndexes []imagedesc.ImageOrIndex
+}
+
+// NewTarImagesMetadata creates a TarImagesMetadata from the images read from a tarball
+func NewTarImagesMetadata(imgOrIndexes []imagedesc.ImageOrIndex) TarImagesMetadata {
+	return TarImagesMetadata{imgOrIndexes}
+}
+
+// Get returns the descriptor of the image or image index that matches the reference.
+// Images are matched by digest, independently of the repository they were copied from
+func (m TarImagesMetadata) Get(ref regname.Reference) (*regremote.Descriptor, error) {
+	imgOrIndex, err := m.find(ref, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest []byte
+	var desc regv1.Descriptor
+
+	switch {
+	case imgOrIndex.Image != nil:
+		manifest, err = (*imgOrIndex.Image).RawManifest()
+		if err != nil {
+			return nil, err
+		}
+		desc.MediaType, err = (*imgOrIndex.Image).MediaType()
+		if err != nil {
+			return nil, err
+		}
+	case imgOrIndex.Index != nil:
+		manifest, err = (*imgOrIndex.Index).RawManifest()
+		if err != nil {
+			return nil, err
+		}
+		desc.MediaType, err = (*imgOrIndex.Index).MediaType()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	desc.Digest, err = imgOrIndex.Digest()
+	if err != nil {
+		return nil, err
+	}
+	desc.Size = int64(len(manifest))
+
+	return &regremote.Descriptor{Descriptor: desc, Manifest: manifest}, nil
+}
+
+// Image returns the image that matches the reference
+func (m TarImagesMetadata) Image(ref regname.Reference) (regv1.Image, error) {
+	imgOrIndex, err := m.find(ref, false)
+	if err != nil {
+		return nil, err
+	}
+	if imgOrIndex.Image == nil {
+		return nil, fmt.Errorf("Expected '%s' to be an image but found an image index", ref.Name())
+	}
+	return *imgOrIndex.Image, nil
+}
+
+// LocalImage returns the image that matches the reference
+func (m TarImagesMetadata) LocalImage(ref regname.Reference) (regv1.Image, error) {
+	return m.Image(ref)
+}
+
+// Index returns the image index that matches the reference
+func (m TarImagesMetadata) Index(ref regname.Reference) (regv1.ImageIndex, error) {
+	imgOrIndex, err := m.find(ref, false)
+	if err != nil {
+		return nil, err
+	}
+	if imgOrIndex.Index == nil {
+		return nil, fmt.Errorf("Expected '%s' to be an image index but found an image", ref.Name())
+	}
+	return *imgOrIndex.Index, nil
+}
+
+// Digest returns the digest of the image or image index that matches the reference.
+// Only images copied from the repository in the reference are found, this way images lock
+// files are never updated to point to a location where the image was never present
+func (m TarImagesMetadata) Digest(ref regname.Reference) (regv1.Hash, error) {
+	imgOrIndex, err := m.find(ref, true)
+	if err != nil {
+		return regv1.Hash{}, err
+	}
+	return imgOrIndex.Digest()
+}
+
+// FirstImageExists returns the first of the provided references present in the tarball
+func (m TarImagesMetadata) FirstImageExists(digests []string) (string, error) {
+	var err error
+	for _, img := range digests {
+		ref, parseErr := regname.NewDigest(img)
+		if parseErr != nil {
+			return "", parseErr
+		}
+		_, err = m.find(ref, false)
+		if err == nil {
+			return img, nil
+		}
+	}
+	return "", fmt.Errorf("Checking image existence: %s", err)
+}
+
+func (m TarImagesMetadata) find(ref regname.Reference, sameRepo bool) (imagedesc.ImageOrIndex, error) {
+	for _, imgOrIndex := range m.imgOrIndexes {
+		tarRef, err := regname.NewDigest(imgOrIndex.Ref())
+		if err != nil {
+			return imagedesc.ImageOrIndex{}, err
+		}
+
+		switch typedRef := ref.(type) {
+		case regname.Digest:
+			if typedRef.DigestStr() != tarRef.DigestStr() {
+				continue
+			}
+		case regname.Tag:
+			if typedRef.TagStr() != imgOrIndex.Tag() || typedRef.Context().Name() != tarRef.Context().Name() {
+				continue
+			}
+		}
+
+		if sameRepo && ref.Context().Name() != tarRef.Context().Name() {
+			continue
+		}
+
+		return imgOrIndex, nil
+	}
+
+	return imagedesc.ImageOrIndex{}, &regtran.Error{
+		StatusCode: http.StatusNotFound,
+		Errors: []regtran.Diagnostic{{
+			Code:    regtran.ManifestUnknownErrorCode,
+			Message: fmt.Sprintf("Image '%s' not found in tarball", ref.Name()),
+		}},
+	}
+}
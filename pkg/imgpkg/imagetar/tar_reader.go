@@ -4,21 +4,83 @@
 package imagetar
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"strings"
 
+	"filippo.io/age"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 )
 
 type TarReader struct {
-	path string
+	path       string
+	identities []age.Identity
+
+	// decryptedPath is the temporary plain copy of an encrypted tarball, created by Decrypt
+	decryptedPath string
+	// signedEntries are the digests of the entries, set by VerifySignature, that the entries are checked against when read
+	signedEntries map[string]string
 }
 
 func NewTarReader(path string) TarReader {
-	return TarReader{path}
+	return TarReader{path: path}
+}
+
+// WithIdentities Decrypts the tarball, while it is read, with the identities
+func (r TarReader) WithIdentities(identities ...age.Identity) TarReader {
+	r.identities = identities
+	return r
+}
+
+// Decrypt Decrypts an encrypted tarball once, in a single sequential pass, to a temporary file that the layers
+// are then read from, since the encrypted stream can not be seeked. Plain tarballs are read in place.
+// Close removes the temporary file
+func (r TarReader) Decrypt() (TarReader, error) {
+	if len(r.identities) == 0 || r.decryptedPath != "" {
+		return r, nil
+	}
+
+	decrypted, err := openDecrypted(r.path, r.identities)
+	if err != nil {
+		return r, err
+	}
+	defer decrypted.Close()
+
+	tempFile, err := ioutil.TempFile("", "imgpkg-tar-")
+	if err != nil {
+		return r, fmt.Errorf("Creating temporary file for the decrypted tar file: %s", err)
+	}
+	defer tempFile.Close()
+
+	r.decryptedPath = tempFile.Name()
+
+	_, err = io.Copy(tempFile, decrypted)
+	if err != nil {
+		r.Close()
+		return r, fmt.Errorf("Decrypting tar file: %s", err)
+	}
+
+	return r, tempFile.Close()
+}
+
+// Close Removes the temporary file created by Decrypt
+func (r TarReader) Close() error {
+	if r.decryptedPath == "" {
+		return nil
+	}
+	return os.Remove(r.decryptedPath)
 }
 
+// Read Reads the images of the tarball, the layers are read from the tarball when the images are copied,
+// so encrypted tarballs need to be decrypted with Decrypt first
 func (r TarReader) Read() ([]imagedesc.ImageOrIndex, error) {
-	file := tarFile{r.path}
+	if len(r.identities) > 0 && r.decryptedPath == "" {
+		return nil, fmt.Errorf("Expected encrypted tar file to be decrypted before reading its images")
+	}
+
+	file := r.file()
 
 	ids, err := r.getIdsFromManifest(file)
 	if err != nil {
@@ -28,7 +90,46 @@ func (r TarReader) Read() ([]imagedesc.ImageOrIndex, error) {
 	return imagedesc.NewDescribedReader(ids, file).Read(), nil
 }
 
+// Descriptors Reads the description of the images in the tarball from manifest.json, without reading any layer
+func (r TarReader) Descriptors() (*imagedesc.ImageRefDescriptors, error) {
+	return r.getIdsFromManifest(r.file())
+}
+
+// IncludedLayers Returns the digests of the layers written to the tarball, only the headers of the tar entries are read
+func (r TarReader) IncludedLayers() (map[string]struct{}, error) {
+	names, err := r.file().chunkNames()
+	if err != nil {
+		return nil, err
+	}
+
+	layers := map[string]struct{}{}
+	for _, name := range names {
+		if strings.HasSuffix(name, layerFileSuffix) {
+			layers[strings.Replace(strings.TrimSuffix(name, layerFileSuffix), "-", ":", 1)] = struct{}{}
+		}
+	}
+	return layers, nil
+}
+
+func (r TarReader) file() tarFile {
+	if r.decryptedPath != "" {
+		return tarFile{path: r.decryptedPath, signedEntries: r.signedEntries}
+	}
+	return tarFile{r.path, r.identities, r.signedEntries}
+}
+
 func (r TarReader) getIdsFromManifest(file tarFile) (*imagedesc.ImageRefDescriptors, error) {
+	format, err := r.Format()
+	if err != nil {
+		return nil, err
+	}
+	if format != nil {
+		err := format.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	manifestFile, err := file.Chunk("manifest.json").Open()
 	if err != nil {
 		return nil, err
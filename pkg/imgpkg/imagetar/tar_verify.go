@@ -0,0 +1,193 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package imagetar
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageutils/verify"
+)
+
+// VerifyProblem Content of the tarball that does not match manifest.json
+type VerifyProblem struct {
+	Digest  string
+	Refs    []string
+	Message string
+}
+
+// VerifyResult Outcome of checking the tarball against manifest.json
+type VerifyResult struct {
+	VerifiedLayers    int
+	VerifiedManifests int
+
+	// Problems contains the contents of the tarball that are corrupted or missing
+	Problems []VerifyProblem
+	// OmittedLayers contains the non-distributable layers that were not written to the tarball,
+	// which is expected when it was created without --include-non-distributable-layers
+	OmittedLayers []VerifyProblem
+}
+
+// verifiedLayer Layer described in manifest.json and the images it belongs to
+type verifiedLayer struct {
+	desc imagedesc.ImageLayerDescriptor
+	refs []string
+	seen bool
+}
+
+// Verify Checks the digest of the manifests and configs in manifest.json against their contents,
+// and streams every layer of the tarball to check its digest and size
+func (r TarReader) Verify() (VerifyResult, error) {
+	result := VerifyResult{}
+
+	ids, err := r.Descriptors()
+	if err != nil {
+		return result, err
+	}
+
+	layers := map[string]*verifiedLayer{}
+	var layerDigests []string
+
+	addLayers := func(refs []string, layerDescs []imagedesc.ImageLayerDescriptor) {
+		for _, layerDesc := range layerDescs {
+			layer, found := layers[layerDesc.Digest]
+			if !found {
+				layer = &verifiedLayer{desc: layerDesc}
+				layers[layerDesc.Digest] = layer
+				layerDigests = append(layerDigests, layerDesc.Digest)
+			}
+			layer.refs = append(layer.refs, refs...)
+		}
+	}
+
+	var verifyImage func(refs []string, td imagedesc.ImageDescriptor)
+	verifyImage = func(refs []string, td imagedesc.ImageDescriptor) {
+		verifyRaw(&result, refs, td.Manifest.Digest, td.Manifest.Raw, "manifest")
+		verifyRaw(&result, refs, td.Config.Digest, td.Config.Raw, "config")
+		addLayers(refs, td.Layers)
+	}
+
+	var verifyIndex func(refs []string, td imagedesc.ImageIndexDescriptor)
+	verifyIndex = func(refs []string, td imagedesc.ImageIndexDescriptor) {
+		verifyRaw(&result, refs, td.Digest, td.Raw, "index")
+		for _, idx := range td.Indexes {
+			verifyIndex(refs, idx)
+		}
+		for _, img := range td.Images {
+			verifyImage(refs, img)
+		}
+	}
+
+	for _, td := range ids.Descriptors() {
+		switch {
+		case td.Image != nil:
+			verifyImage(td.Image.Refs, *td.Image)
+		case td.ImageIndex != nil:
+			verifyIndex(td.ImageIndex.Refs, *td.ImageIndex)
+		default:
+			panic("Unknown item")
+		}
+	}
+
+	err = r.verifyLayers(&result, layers)
+	if err != nil {
+		return result, err
+	}
+
+	for _, digest := range layerDigests {
+		layer := layers[digest]
+		if layer.seen {
+			continue
+		}
+
+		problem := VerifyProblem{Digest: digest, Refs: layer.refs, Message: "Layer not found in tarball"}
+		if !layer.desc.IsDistributable() {
+			result.OmittedLayers = append(result.OmittedLayers, problem)
+			continue
+		}
+		result.Problems = append(result.Problems, problem)
+	}
+
+	return result, nil
+}
+
+// verifyRaw checks that the digest recorded in manifest.json matches the content recorded with it
+func verifyRaw(result *VerifyResult, refs []string, digest, raw, kind string) {
+	err := verifyContents(ioutil.NopCloser(strings.NewReader(raw)), digest)
+	if err != nil {
+		result.Problems = append(result.Problems, VerifyProblem{
+			Digest: digest, Refs: refs, Message: fmt.Sprintf("Verifying %s: %s", kind, err)})
+		return
+	}
+	result.VerifiedManifests++
+}
+
+// verifyLayers reads the tarball once, checking the digest and the size of every layer entry
+func (r TarReader) verifyLayers(result *VerifyResult, layers map[string]*verifiedLayer) error {
+	file, err := r.file().open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tf := tar.NewReader(file)
+	for {
+		hdr, err := tf.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Layers that were not read yet are reported as missing
+			result.Problems = append(result.Problems, VerifyProblem{Message: fmt.Sprintf("Reading tarball: %s", err)})
+			return nil
+		}
+
+		if !strings.HasSuffix(hdr.Name, layerFileSuffix) {
+			continue
+		}
+
+		digest := strings.Replace(strings.TrimSuffix(hdr.Name, layerFileSuffix), "-", ":", 1)
+		layer, found := layers[digest]
+		if !found {
+			continue
+		}
+		layer.seen = true
+
+		problem := VerifyProblem{Digest: digest, Refs: layer.refs}
+		if hdr.Size != layer.desc.Size {
+			problem.Message = fmt.Sprintf("Expected layer size to be %d bytes but was %d bytes", layer.desc.Size, hdr.Size)
+			result.Problems = append(result.Problems, problem)
+			continue
+		}
+
+		err = verifyContents(ioutil.NopCloser(tf), digest)
+		if err != nil {
+			problem.Message = fmt.Sprintf("Verifying layer: %s", err)
+			result.Problems = append(result.Problems, problem)
+			continue
+		}
+		result.VerifiedLayers++
+	}
+}
+
+func verifyContents(contents io.ReadCloser, digest string) error {
+	hash, err := regv1.NewHash(digest)
+	if err != nil {
+		return err
+	}
+
+	verifiedContents, err := verify.ReadCloser(contents, hash)
+	if err != nil {
+		return err
+	}
+	defer verifiedContents.Close()
+
+	_, err = io.Copy(ioutil.Discard, verifiedContents)
+	return err
+}
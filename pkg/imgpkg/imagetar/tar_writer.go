@@ -6,6 +6,8 @@ package imagetar
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
@@ -13,7 +15,6 @@ import (
 	"time"
 
 	goui "github.com/cppforlife/go-cli-ui/ui"
-	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imagedesc"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
@@ -24,6 +25,9 @@ type Logger interface {
 
 type TarWriterOpts struct {
 	Concurrency int
+	RetryPolicy util.RetryPolicy
+	// ImgpkgVersion is the version of imgpkg recorded in the format of the tarball
+	ImgpkgVersion string
 }
 
 type TarWriter struct {
@@ -33,6 +37,9 @@ type TarWriter struct {
 	dst           io.WriteCloser
 	tf            *tar.Writer
 	layersToWrite []imagedesc.ImageLayerDescriptor
+	catalog       *Catalog
+	signingKey    *ecdsa.PrivateKey
+	omittedLayers bool
 
 	opts                  TarWriterOpts
 	ui                    goui.UI
@@ -44,6 +51,18 @@ func NewTarWriter(ids *imagedesc.ImageRefDescriptors, dstOpener func() (io.Write
 	return &TarWriter{ids: ids, dstOpener: dstOpener, opts: opts, ui: ui, imageLayerWriterCheck: imageLayerWriterCheck}
 }
 
+// WithCatalog Writes the catalog, listing the root bundles, to the tarball
+func (w *TarWriter) WithCatalog(catalog Catalog) *TarWriter {
+	w.catalog = &catalog
+	return w
+}
+
+// WithSigningKey Signs the digests of every entry of the tarball with the key, storing the signature in the tarball
+func (w *TarWriter) WithSigningKey(key *ecdsa.PrivateKey) *TarWriter {
+	w.signingKey = key
+	return w
+}
+
 func (w *TarWriter) Write() error {
 	var err error
 
@@ -56,16 +75,7 @@ func (w *TarWriter) Write() error {
 	w.tf = tar.NewWriter(w.dst)
 	defer w.tf.Close()
 
-	idsBytes, err := w.ids.AsBytes()
-	if err != nil {
-		return err
-	}
-
-	err = w.writeTarEntry(w.tf, "manifest.json", bytes.NewReader(idsBytes), int64(len(idsBytes)))
-	if err != nil {
-		return err
-	}
-
+	// Layers are selected first, the format lists whether any layer was omitted
 	for _, td := range w.ids.Descriptors() {
 		switch {
 		case td.Image != nil:
@@ -85,9 +95,102 @@ func (w *TarWriter) Write() error {
 		}
 	}
 
+	formatBytes, err := w.format().AsBytes()
+	if err != nil {
+		return err
+	}
+
+	err = w.writeTarEntry(w.tf, FormatFile, bytes.NewReader(formatBytes), int64(len(formatBytes)))
+	if err != nil {
+		return err
+	}
+
+	idsBytes, err := w.ids.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	err = w.writeTarEntry(w.tf, "manifest.json", bytes.NewReader(idsBytes), int64(len(idsBytes)))
+	if err != nil {
+		return err
+	}
+
+	signedContents := SignedContents{Entries: map[string]string{
+		FormatFile:      fmt.Sprintf("sha256:%x", sha256.Sum256(formatBytes)),
+		"manifest.json": fmt.Sprintf("sha256:%x", sha256.Sum256(idsBytes)),
+	}}
+
+	if w.catalog != nil {
+		catalogBytes, err := w.catalog.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		err = w.writeTarEntry(w.tf, CatalogFile, bytes.NewReader(catalogBytes), int64(len(catalogBytes)))
+		if err != nil {
+			return err
+		}
+
+		signedContents.Entries[CatalogFile] = fmt.Sprintf("sha256:%x", sha256.Sum256(catalogBytes))
+	}
+
+	if w.signingKey != nil {
+		err := w.writeSignature(signedContents)
+		if err != nil {
+			return err
+		}
+	}
+
 	return w.writeLayers()
 }
 
+// format describes the tarball being written
+func (w *TarWriter) format() Format {
+	format := Format{
+		Version:       FormatVersion,
+		ImgpkgVersion: w.opts.ImgpkgVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if w.catalog != nil {
+		format.Features = append(format.Features, FeatureCatalog)
+		for _, bundle := range w.catalog.Bundles {
+			format.RootBundles = append(format.RootBundles, bundle.Image)
+		}
+	}
+	if w.signingKey != nil {
+		format.Features = append(format.Features, FeatureSignature)
+	}
+	if w.omittedLayers {
+		format.Features = append(format.Features, FeatureOmittedNonDistributableLayers)
+	}
+
+	return format
+}
+
+// writeSignature signs the entries written so far together with the layers that will be written
+func (w *TarWriter) writeSignature(signedContents SignedContents) error {
+	for _, imgLayer := range w.layersToWrite {
+		name, err := layerFileName(imgLayer.Digest)
+		if err != nil {
+			return err
+		}
+		signedContents.Entries[name] = imgLayer.Digest
+	}
+
+	signature, err := NewTarSignature(signedContents, w.signingKey)
+	if err != nil {
+		return err
+	}
+
+	signatureBytes, err := signature.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	return w.writeTarEntry(w.tf, SignatureFile, bytes.NewReader(signatureBytes), int64(len(signatureBytes)))
+}
+
 func (w *TarWriter) writeImageIndex(td imagedesc.ImageIndexDescriptor) error {
 	for _, idx := range td.Indexes {
 		err := w.writeImageIndex(idx)
@@ -114,6 +217,8 @@ func (w *TarWriter) writeImage(td imagedesc.ImageDescriptor) error {
 		}
 		if shouldLayerBeIncluded {
 			w.layersToWrite = append(w.layersToWrite, imgLayer)
+		} else {
+			w.omittedLayers = true
 		}
 	}
 	return nil
@@ -137,13 +242,11 @@ func (w *TarWriter) writeLayers() error {
 
 	// Inflate tar file so that multiple writes can happen in parallel
 	for _, imgLayer := range w.layersToWrite {
-		digest, err := regv1.NewHash(imgLayer.Digest)
+		name, err := layerFileName(imgLayer.Digest)
 		if err != nil {
 			return err
 		}
 
-		name := digest.Algorithm + "-" + digest.Hex + ".tar.gz"
-
 		// Dedup layers
 		if _, found := writtenLayers[name]; found {
 			continue
@@ -225,7 +328,7 @@ func (w *TarWriter) fillInLayers(writtenLayers map[string]writtenLayer) error {
 			writeThrottle.Take()
 			defer writeThrottle.Done()
 
-			errCh <- util.Retry(func() error {
+			errCh <- w.opts.RetryPolicy.Retry(func() error {
 				return w.fillInLayer(writtenLayer)
 			})
 		}()
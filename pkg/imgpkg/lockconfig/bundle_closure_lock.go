@@ -0,0 +1,177 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	BundleClosureLockKind       = "BundleClosureLock"
+	BundleClosureLockAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+)
+
+// BundleClosureLock Lock of a bundle that, besides the root bundle, records every nested bundle and image
+// the bundle references, where each of them is located and which images every bundle contains
+type BundleClosureLock struct {
+	LockVersion
+	Bundle  BundleRef          `json:"bundle"`           // This generated yaml, but due to lib we need to use `json`
+	Bundles []ClosureBundleRef `json:"bundles"`          // This generated yaml, but due to lib we need to use `json`
+	Images  []ClosureImageRef  `json:"images,omitempty"` // This generated yaml, but due to lib we need to use `json`
+}
+
+// ClosureBundleRef Bundle, including the root bundle, that is part of the closure
+type ClosureBundleRef struct {
+	// Image Location of the bundle
+	Image string `json:"image"` // This generated yaml, but due to lib we need to use `json`
+	// Images References of the images and bundles contained in the bundle, as they are written in its images lock
+	Images []string `json:"images,omitempty"` // This generated yaml, but due to lib we need to use `json`
+}
+
+// ClosureImageRef Image, that is not a bundle, that is part of the closure
+type ClosureImageRef struct {
+	// Image Location of the image
+	Image string `json:"image"` // This generated yaml, but due to lib we need to use `json`
+}
+
+func NewEmptyBundleClosureLock() BundleClosureLock {
+	return BundleClosureLock{
+		LockVersion: LockVersion{
+			APIVersion: BundleClosureLockAPIVersion,
+			Kind:       BundleClosureLockKind,
+		},
+	}
+}
+
+func NewBundleClosureLockFromPath(path string) (BundleClosureLock, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return BundleClosureLock{}, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	return NewBundleClosureLockFromBytes(bs)
+}
+
+func NewBundleClosureLockFromBytes(data []byte) (BundleClosureLock, error) {
+	var lock BundleClosureLock
+
+	err := yaml.UnmarshalStrict(data, &lock)
+	if err != nil {
+		return lock, fmt.Errorf("Unmarshaling bundle closure lock: %s", err)
+	}
+
+	err = lock.Validate()
+	if err != nil {
+		return lock, fmt.Errorf("Validating bundle closure lock: %s", err)
+	}
+
+	return lock, nil
+}
+
+func (b BundleClosureLock) Validate() error {
+	if b.APIVersion != BundleClosureLockAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", BundleClosureLockAPIVersion)
+	}
+	if b.Kind != BundleClosureLockKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", BundleClosureLockKind)
+	}
+	if _, err := regname.NewDigest(b.Bundle.Image); err != nil {
+		return fmt.Errorf("Expected ref to be in digest form, got '%s'", b.Bundle.Image)
+	}
+
+	for _, bundle := range b.Bundles {
+		if _, err := regname.NewDigest(bundle.Image); err != nil {
+			return fmt.Errorf("Expected ref to be in digest form, got '%s'", bundle.Image)
+		}
+	}
+	for _, image := range b.Images {
+		if _, err := regname.NewDigest(image.Image); err != nil {
+			return fmt.Errorf("Expected ref to be in digest form, got '%s'", image.Image)
+		}
+	}
+
+	if _, found := b.FindBundle(b.Bundle.Image); !found {
+		return fmt.Errorf("Expected bundle '%s' to be part of the bundles", b.Bundle.Image)
+	}
+
+	for _, bundle := range b.Bundles {
+		for _, ref := range bundle.Images {
+			if _, err := regname.NewDigest(ref); err != nil {
+				return fmt.Errorf("Expected ref to be in digest form, got '%s'", ref)
+			}
+			if _, _, found := b.Find(ref); !found {
+				return fmt.Errorf("Expected image '%s' of bundle '%s' to be part of the bundles or images", ref, bundle.Image)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Find Returns the location of the bundle or image with the same digest as the provided reference
+// and if it is a bundle
+func (b BundleClosureLock) Find(ref string) (string, bool, bool) {
+	if bundle, found := b.FindBundle(ref); found {
+		return bundle.Image, true, true
+	}
+
+	digest := digestOf(ref)
+	for _, image := range b.Images {
+		if digestOf(image.Image) == digest {
+			return image.Image, false, true
+		}
+	}
+	return "", false, false
+}
+
+// FindBundle Returns the bundle with the same digest as the provided reference
+func (b BundleClosureLock) FindBundle(ref string) (ClosureBundleRef, bool) {
+	digest := digestOf(ref)
+	for _, bundle := range b.Bundles {
+		if digestOf(bundle.Image) == digest {
+			return bundle, true
+		}
+	}
+	return ClosureBundleRef{}, false
+}
+
+func (b BundleClosureLock) AsBytes() ([]byte, error) {
+	err := b.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("Validating bundle closure lock: %s", err)
+	}
+
+	bs, err := yaml.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("Marshaling config: %s", err)
+	}
+
+	return []byte(fmt.Sprintf("---\n%s", bs)), nil
+}
+
+func (b BundleClosureLock) WriteToPath(path string) error {
+	bs, err := b.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path, bs, 0600)
+	if err != nil {
+		return fmt.Errorf("Writing bundle closure lock: %s", err)
+	}
+
+	return nil
+}
+
+func digestOf(ref string) string {
+	digest, err := regname.NewDigest(ref)
+	if err != nil {
+		return ""
+	}
+	return digest.DigestStr()
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+const (
+	closureRootDigest   = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	closureNestedDigest = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	closureImageDigest  = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+)
+
+func TestBundleClosureLockRoundTrip(t *testing.T) {
+	data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleClosureLock
+bundle:
+  image: registry.io/relocated/root@` + closureRootDigest + `
+  tag: v1.0.0
+bundles:
+- image: registry.io/relocated/root@` + closureRootDigest + `
+  images:
+  - original.io/nested@` + closureNestedDigest + `
+- image: registry.io/relocated/root@` + closureNestedDigest + `
+  images:
+  - original.io/app@` + closureImageDigest + `
+images:
+- image: registry.io/relocated/root@` + closureImageDigest + `
+`
+
+	lock, err := lockconfig.NewBundleClosureLockFromBytes([]byte(data))
+	require.NoError(t, err)
+
+	location, isBundle, found := lock.Find("original.io/nested@" + closureNestedDigest)
+	assert.True(t, found)
+	assert.True(t, isBundle)
+	assert.Equal(t, "registry.io/relocated/root@"+closureNestedDigest, location)
+
+	location, isBundle, found = lock.Find("original.io/app@" + closureImageDigest)
+	assert.True(t, found)
+	assert.False(t, isBundle)
+	assert.Equal(t, "registry.io/relocated/root@"+closureImageDigest, location)
+
+	lockPath := filepath.Join(t.TempDir(), "lock.yml")
+	require.NoError(t, lock.WriteToPath(lockPath))
+
+	bundleLock, closureLock, imagesLock, err := lockconfig.NewLockFromPath(lockPath)
+	require.NoError(t, err)
+	assert.Nil(t, bundleLock)
+	assert.Nil(t, imagesLock)
+	require.NotNil(t, closureLock)
+	assert.Equal(t, lock, *closureLock)
+}
+
+func TestBundleClosureLockValidation(t *testing.T) {
+	t.Run("root bundle not part of the bundles", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleClosureLock
+bundle:
+  image: registry.io/root@` + closureRootDigest + `
+bundles:
+- image: registry.io/nested@` + closureNestedDigest + `
+`
+		_, err := lockconfig.NewBundleClosureLockFromBytes([]byte(data))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected bundle 'registry.io/root@"+closureRootDigest+"' to be part of the bundles")
+	})
+
+	t.Run("image of a bundle not part of the closure", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleClosureLock
+bundle:
+  image: registry.io/root@` + closureRootDigest + `
+bundles:
+- image: registry.io/root@` + closureRootDigest + `
+  images:
+  - registry.io/app@` + closureImageDigest + `
+`
+		_, err := lockconfig.NewBundleClosureLockFromBytes([]byte(data))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected image 'registry.io/app@"+closureImageDigest+"' of bundle 'registry.io/root@"+closureRootDigest+"' to be part of the bundles or images")
+	})
+
+	t.Run("image not in digest form", func(t *testing.T) {
+		data := `
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleClosureLock
+bundle:
+  image: registry.io/root@` + closureRootDigest + `
+bundles:
+- image: registry.io/root@` + closureRootDigest + `
+images:
+- image: registry.io/app:v1
+`
+		_, err := lockconfig.NewBundleClosureLockFromBytes([]byte(data))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected ref to be in digest form, got 'registry.io/app:v1'")
+	})
+}
+
+func TestNewBundleLockOrClosureLockFromPathReportsErrorOfDeclaredKind(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "lock.yml")
+	require.NoError(t, os.WriteFile(lockPath, []byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleClosureLock
+bundle:
+  image: registry.io/root@`+closureRootDigest+`
+bundles: []
+`), 0600))
+
+	_, _, err := lockconfig.NewBundleLockOrClosureLockFromPath(lockPath)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "Unmarshaling bundle lock")
+	assert.Contains(t, err.Error(), "Validating bundle closure lock: Expected bundle 'registry.io/root@"+closureRootDigest+"' to be part of the bundles")
+}
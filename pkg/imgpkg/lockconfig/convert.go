@@ -0,0 +1,79 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lockConversion converts a lock from the previous apiVersion of its kind to apiVersion
+type lockConversion struct {
+	apiVersion string
+	convert    func(Lock) (Lock, error)
+}
+
+// lockConversions apiVersions of every kind of lock, from the oldest to the newest.
+// When a new apiVersion is introduced a conversion from the previous one is appended
+var lockConversions = map[string][]lockConversion{
+	BundleLockKind:        {{apiVersion: BundleLockAPIVersion}},
+	BundleClosureLockKind: {{apiVersion: BundleClosureLockAPIVersion}},
+	ImagesLockKind:        {{apiVersion: ImagesLockAPIVersion}},
+}
+
+// Convert Returns the lock using the provided apiVersion, or the newest apiVersion of its kind when empty.
+// Only conversions to newer apiVersions are supported
+func Convert(lock Lock, apiVersion string) (Lock, error) {
+	version := VersionOf(lock)
+	conversions := lockConversions[version.Kind]
+
+	var knownVersions []string
+	fromIdx, toIdx := -1, len(conversions)-1
+	for i, conversion := range conversions {
+		knownVersions = append(knownVersions, conversion.apiVersion)
+		if conversion.apiVersion == version.APIVersion {
+			fromIdx = i
+		}
+		if conversion.apiVersion == apiVersion {
+			toIdx = i
+		}
+	}
+
+	if fromIdx < 0 {
+		return nil, fmt.Errorf("Converting %s: Unknown version '%s' (known: %s)",
+			version.Kind, version.APIVersion, strings.Join(knownVersions, ", "))
+	}
+	if len(apiVersion) > 0 && conversions[toIdx].apiVersion != apiVersion {
+		return nil, fmt.Errorf("Converting %s: Unknown version '%s' (known: %s)",
+			version.Kind, apiVersion, strings.Join(knownVersions, ", "))
+	}
+	if toIdx < fromIdx {
+		return nil, fmt.Errorf("Converting %s: Expected version '%s' to be newer than '%s'",
+			version.Kind, apiVersion, version.APIVersion)
+	}
+
+	for _, conversion := range conversions[fromIdx+1 : toIdx+1] {
+		var err error
+		lock, err = conversion.convert(lock)
+		if err != nil {
+			return nil, fmt.Errorf("Converting %s to version '%s': %s", version.Kind, conversion.apiVersion, err)
+		}
+	}
+
+	return lock, nil
+}
+
+// VersionOf Returns the apiVersion and kind of the lock
+func VersionOf(lock Lock) LockVersion {
+	switch typedLock := lock.(type) {
+	case BundleLock:
+		return typedLock.LockVersion
+	case BundleClosureLock:
+		return typedLock.LockVersion
+	case ImagesLock:
+		return typedLock.LockVersion
+	default:
+		panic(fmt.Sprintf("Unknown lock type %T", lock))
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestConvert(t *testing.T) {
+	lock := lockconfig.NewEmptyImagesLock()
+
+	t.Run("when no apiVersion is provided it converts to the newest one", func(t *testing.T) {
+		converted, err := lockconfig.Convert(lock, "")
+		require.NoError(t, err)
+		assert.Equal(t, lockconfig.ImagesLockAPIVersion, lockconfig.VersionOf(converted).APIVersion)
+	})
+
+	t.Run("when the apiVersion is unknown it errors", func(t *testing.T) {
+		_, err := lockconfig.Convert(lock, "imgpkg.carvel.dev/v1")
+		require.EqualError(t, err, "Converting ImagesLock: Unknown version 'imgpkg.carvel.dev/v1' (known: imgpkg.carvel.dev/v1alpha1)")
+	})
+}
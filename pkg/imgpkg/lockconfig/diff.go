@@ -0,0 +1,120 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LockChange Value of a field that is different in two locks.
+// From is empty when the value was added and To is empty when the value was removed
+type LockChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// Diff Returns the changes needed to go from one lock to the other, sorted by field
+func Diff(from, to Lock) []LockChange {
+	fromFields := lockFields(from)
+	toFields := lockFields(to)
+
+	var fields []string
+	for field := range fromFields {
+		fields = append(fields, field)
+	}
+	for field := range toFields {
+		if _, found := fromFields[field]; !found {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []LockChange
+	for _, field := range fields {
+		fromValues, toValues := fromFields[field], toFields[field]
+
+		// Fields with a single value on both sides changed value, otherwise values were added or removed
+		if len(fromValues) == 1 && len(toValues) == 1 {
+			if fromValues[0] != toValues[0] {
+				changes = append(changes, LockChange{Field: field, From: fromValues[0], To: toValues[0]})
+			}
+			continue
+		}
+
+		for _, value := range subtract(fromValues, toValues) {
+			changes = append(changes, LockChange{Field: field, From: value})
+		}
+		for _, value := range subtract(toValues, fromValues) {
+			changes = append(changes, LockChange{Field: field, To: value})
+		}
+	}
+	return changes
+}
+
+// lockFields returns the values of every field in the lock, keyed by the path of the field
+func lockFields(lock Lock) map[string][]string {
+	fields := map[string][]string{}
+	add := func(field string, values ...string) {
+		for _, value := range values {
+			if len(value) > 0 {
+				fields[field] = append(fields[field], value)
+			}
+		}
+	}
+
+	switch typedLock := lock.(type) {
+	case BundleLock:
+		add("apiVersion", typedLock.APIVersion)
+		add("kind", typedLock.Kind)
+		add("bundle.image", typedLock.Bundle.Image)
+		add("bundle.tag", typedLock.Bundle.Tag)
+
+	case BundleClosureLock:
+		add("apiVersion", typedLock.APIVersion)
+		add("kind", typedLock.Kind)
+		add("bundle.image", typedLock.Bundle.Image)
+		add("bundle.tag", typedLock.Bundle.Tag)
+		for _, bundle := range typedLock.Bundles {
+			add("bundles", bundle.Image)
+			add(fmt.Sprintf("bundles[%s].images", bundle.Image), bundle.Images...)
+		}
+		for _, image := range typedLock.Images {
+			add("images", image.Image)
+		}
+
+	case ImagesLock:
+		add("apiVersion", typedLock.APIVersion)
+		add("kind", typedLock.Kind)
+		for _, image := range typedLock.Images {
+			add("images", image.Image)
+			for key, value := range image.Annotations {
+				add(fmt.Sprintf("images[%s].annotations.%s", image.Image, key), value)
+			}
+		}
+
+	default:
+		panic(fmt.Sprintf("Unknown lock type %T", lock))
+	}
+
+	return fields
+}
+
+// subtract returns the values that are not part of others
+func subtract(values, others []string) []string {
+	found := map[string]struct{}{}
+	for _, other := range others {
+		found[other] = struct{}{}
+	}
+
+	var result []string
+	for _, value := range values {
+		if _, ok := found[value]; !ok {
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
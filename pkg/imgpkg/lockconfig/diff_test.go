@@ -0,0 +1,60 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestDiff(t *testing.T) {
+	app := "registry.io/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"
+	db := "registry.io/db@sha256:cf31af331f38d1d7158470e095b132acd126a7180a54f263d386da88eb681d93"
+	cache := "registry.io/cache@sha256:be154cc2b1211a9f98f4d708f4266650c9129784d0485d4507d9b0fa05d928b6"
+
+	t.Run("lists the added, removed and changed values", func(t *testing.T) {
+		from := lockconfig.NewEmptyImagesLock()
+		from.Images = []lockconfig.ImageRef{
+			{Image: app, Annotations: map[string]string{"team": "a"}},
+			{Image: db},
+		}
+		to := lockconfig.NewEmptyImagesLock()
+		to.Images = []lockconfig.ImageRef{
+			{Image: app, Annotations: map[string]string{"team": "b"}},
+			{Image: cache, Annotations: map[string]string{"team": "c"}},
+		}
+
+		assert.Equal(t, []lockconfig.LockChange{
+			{Field: "images", From: db},
+			{Field: "images", To: cache},
+			{Field: "images[" + app + "].annotations.team", From: "a", To: "b"},
+			{Field: "images[" + cache + "].annotations.team", To: "c"},
+		}, lockconfig.Diff(from, to))
+	})
+
+	t.Run("when the locks are the same it returns no changes", func(t *testing.T) {
+		lock := lockconfig.BundleLock{
+			LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.BundleLockAPIVersion, Kind: lockconfig.BundleLockKind},
+			Bundle:      lockconfig.BundleRef{Image: app, Tag: "v1"},
+		}
+		assert.Empty(t, lockconfig.Diff(lock, lock))
+	})
+
+	t.Run("when the locks have different kinds it lists the fields of both", func(t *testing.T) {
+		bundleLock := lockconfig.BundleLock{
+			LockVersion: lockconfig.LockVersion{APIVersion: lockconfig.BundleLockAPIVersion, Kind: lockconfig.BundleLockKind},
+			Bundle:      lockconfig.BundleRef{Image: app},
+		}
+		imagesLock := lockconfig.NewEmptyImagesLock()
+		imagesLock.Images = []lockconfig.ImageRef{{Image: db}}
+
+		assert.Equal(t, []lockconfig.LockChange{
+			{Field: "bundle.image", From: app},
+			{Field: "images", To: db},
+			{Field: "kind", From: lockconfig.BundleLockKind, To: lockconfig.ImagesLockKind},
+		}, lockconfig.Diff(bundleLock, imagesLock))
+	})
+}
@@ -5,6 +5,9 @@ package lockconfig
 
 import (
 	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
 )
 
 type LockVersion struct {
@@ -12,14 +15,81 @@ type LockVersion struct {
 	Kind       string `json:"kind"`       // This generated yaml, but due to lib we need to use `json`
 }
 
-func NewLockFromPath(path string) (*BundleLock, *ImagesLock, error) {
-	bundleLock, err := NewBundleLockFromPath(path)
-	if err == nil {
-		return &bundleLock, nil, nil
+// Lock Lock file of any kind: BundleLock, BundleClosureLock or ImagesLock
+type Lock interface {
+	Validate() error
+	AsBytes() ([]byte, error)
+	WriteToPath(path string) error
+}
+
+var _ Lock = BundleLock{}
+var _ Lock = BundleClosureLock{}
+var _ Lock = ImagesLock{}
+
+// NewLockFromBytes Reads the lock of the kind declared in the file
+func NewLockFromBytes(data []byte) (Lock, error) {
+	var version LockVersion
+
+	err := yaml.Unmarshal(data, &version)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling lock: %s", err)
+	}
+
+	switch version.Kind {
+	case BundleLockKind:
+		return NewBundleLockFromBytes(data)
+	case BundleClosureLockKind:
+		return NewBundleClosureLockFromBytes(data)
+	case ImagesLockKind:
+		return NewImagesLockFromBytes(data)
+	default:
+		return nil, fmt.Errorf("Validating kind: Unknown kind '%s' (known: %s, %s, %s)",
+			version.Kind, BundleLockKind, BundleClosureLockKind, ImagesLockKind)
+	}
+}
+
+// NewAnyLockFromPath Reads the lock of the kind declared in the file
+func NewAnyLockFromPath(path string) (Lock, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Reading path %s: %s", path, err)
 	}
-	imagesLock, err := NewImagesLockFromPath(path)
-	if err == nil {
-		return nil, &imagesLock, nil
+
+	return NewLockFromBytes(bs)
+}
+
+func NewLockFromPath(path string) (*BundleLock, *BundleClosureLock, *ImagesLock, error) {
+	lock, err := NewAnyLockFromPath(path)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Trying to read bundle, bundle closure or images lock file: %s", err)
+	}
+
+	switch typedLock := lock.(type) {
+	case BundleLock:
+		return &typedLock, nil, nil, nil
+	case BundleClosureLock:
+		return nil, &typedLock, nil, nil
+	case ImagesLock:
+		return nil, nil, &typedLock, nil
+	default:
+		panic(fmt.Sprintf("Unknown lock type %T", lock))
+	}
+}
+
+// NewBundleLockOrClosureLockFromPath Reads either a BundleLock or a BundleClosureLock
+func NewBundleLockOrClosureLockFromPath(path string) (*BundleLock, *BundleClosureLock, error) {
+	lock, err := NewAnyLockFromPath(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Trying to read bundle or bundle closure lock file: %s", err)
+	}
+
+	switch typedLock := lock.(type) {
+	case BundleLock:
+		return &typedLock, nil, nil
+	case BundleClosureLock:
+		return nil, &typedLock, nil
+	default:
+		return nil, nil, fmt.Errorf("Trying to read bundle or bundle closure lock file: Expected kind %s or %s",
+			BundleLockKind, BundleClosureLockKind)
 	}
-	return nil, nil, fmt.Errorf("Trying to read bundle or images lock file: %s", err)
 }
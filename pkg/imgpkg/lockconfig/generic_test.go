@@ -0,0 +1,45 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestNewLockFromBytes(t *testing.T) {
+	t.Run("reads the kind declared in the file", func(t *testing.T) {
+		lock, err := lockconfig.NewLockFromBytes([]byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: BundleLock
+bundle:
+  image: registry.io/bundle@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0
+  tag: v1
+`))
+		require.NoError(t, err)
+		require.IsType(t, lockconfig.BundleLock{}, lock)
+		assert.Equal(t, "v1", lock.(lockconfig.BundleLock).Bundle.Tag)
+	})
+
+	t.Run("when the lock is invalid it returns the error of the declared kind", func(t *testing.T) {
+		_, err := lockconfig.NewLockFromBytes([]byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: ImagesLock
+images:
+- image: nginx:v1
+`))
+		require.EqualError(t, err, "Validating images lock: Expected ref to be in digest form, got 'nginx:v1'")
+	})
+
+	t.Run("when the kind is unknown it errors", func(t *testing.T) {
+		_, err := lockconfig.NewLockFromBytes([]byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Bundle
+`))
+		require.EqualError(t, err, "Validating kind: Unknown kind 'Bundle' (known: BundleLock, BundleClosureLock, ImagesLock)")
+	})
+}
@@ -72,6 +72,30 @@ func NewImagesLockFromBytes(data []byte) (ImagesLock, error) {
 	return lock, nil
 }
 
+// NewImagesLockWithTagsFromPath Reads an images lock where images can also be referenced by tag,
+// or by a semver constraint on the tags of their repository (example: nginx:~1.25).
+// The images need to be resolved to digests before the lock is valid
+func NewImagesLockWithTagsFromPath(path string) (ImagesLock, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ImagesLock{}, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	var lock ImagesLock
+
+	err = yaml.UnmarshalStrict(bs, &lock)
+	if err != nil {
+		return lock, fmt.Errorf("Unmarshaling images lock: %s", err)
+	}
+
+	err = lock.validateVersion()
+	if err != nil {
+		return lock, fmt.Errorf("Validating images lock: %s", err)
+	}
+
+	return lock, nil
+}
+
 func (i *ImagesLock) AddImageRef(ref ImageRef) {
 	for _, image := range i.Images {
 		if image.Image == ref.Image {
@@ -82,11 +106,9 @@ func (i *ImagesLock) AddImageRef(ref ImageRef) {
 }
 
 func (i ImagesLock) Validate() error {
-	if i.APIVersion != ImagesLockAPIVersion {
-		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", ImagesLockAPIVersion)
-	}
-	if i.Kind != ImagesLockKind {
-		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", ImagesLockKind)
+	err := i.validateVersion()
+	if err != nil {
+		return err
 	}
 	for _, imageRef := range i.Images {
 		if _, err := regname.NewDigest(imageRef.Image); err != nil {
@@ -96,6 +118,16 @@ func (i ImagesLock) Validate() error {
 	return nil
 }
 
+func (i ImagesLock) validateVersion() error {
+	if i.APIVersion != ImagesLockAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", ImagesLockAPIVersion)
+	}
+	if i.Kind != ImagesLockKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", ImagesLockKind)
+	}
+	return nil
+}
+
 func (i ImagesLock) AsBytes() ([]byte, error) {
 	err := i.Validate()
 	if err != nil {
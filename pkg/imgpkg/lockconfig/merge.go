@@ -0,0 +1,77 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig
+
+import (
+	"sort"
+)
+
+// AnnotationConflict Annotation of an image that has different values in the merged locks
+type AnnotationConflict struct {
+	Image      string
+	Annotation string
+	// Values Value of the annotation by index of the lock that contains it
+	Values map[int]string
+}
+
+// MergeImagesLocks Combines the images of the locks, in the order they are first found, merging the annotations
+// of the images present in more than one lock. When an annotation has different values the value of the first lock is kept
+// and the conflict is returned
+func MergeImagesLocks(locks ...ImagesLock) (ImagesLock, []AnnotationConflict) {
+	merged := NewEmptyImagesLock()
+	conflicts := map[string]*AnnotationConflict{}
+	imageIdx := map[string]int{}
+
+	for lockIdx, lock := range locks {
+		for _, imageRef := range lock.Images {
+			idx, found := imageIdx[imageRef.Image]
+			if !found {
+				idx = len(merged.Images)
+				imageIdx[imageRef.Image] = idx
+				merged.Images = append(merged.Images, ImageRef{Image: imageRef.Image, Annotations: map[string]string{}})
+			}
+
+			for key, value := range imageRef.Annotations {
+				conflictKey := imageRef.Image + "\x00" + key
+				conflict, found := conflicts[conflictKey]
+				if !found {
+					merged.Images[idx].Annotations[key] = value
+					conflict = &AnnotationConflict{Image: imageRef.Image, Annotation: key, Values: map[int]string{}}
+					conflicts[conflictKey] = conflict
+				}
+				conflict.Values[lockIdx] = value
+			}
+		}
+	}
+
+	var result []AnnotationConflict
+	for _, conflict := range conflicts {
+		if conflict.hasDifferentValues() {
+			result = append(result, *conflict)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Image != result[j].Image {
+			return result[i].Image < result[j].Image
+		}
+		return result[i].Annotation < result[j].Annotation
+	})
+
+	return merged, result
+}
+
+func (c AnnotationConflict) hasDifferentValues() bool {
+	var first *string
+	for _, value := range c.Values {
+		value := value
+		if first == nil {
+			first = &value
+			continue
+		}
+		if *first != value {
+			return true
+		}
+	}
+	return false
+}
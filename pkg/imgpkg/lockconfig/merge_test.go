@@ -0,0 +1,60 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package lockconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
+)
+
+func TestMergeImagesLocks(t *testing.T) {
+	imagesLock := func(images ...lockconfig.ImageRef) lockconfig.ImagesLock {
+		lock := lockconfig.NewEmptyImagesLock()
+		lock.Images = images
+		return lock
+	}
+	app := "registry.io/app@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"
+	db := "registry.io/db@sha256:cf31af331f38d1d7158470e095b132acd126a7180a54f263d386da88eb681d93"
+	cache := "registry.io/cache@sha256:be154cc2b1211a9f98f4d708f4266650c9129784d0485d4507d9b0fa05d928b6"
+
+	t.Run("combines the images and their annotations", func(t *testing.T) {
+		merged, conflicts := lockconfig.MergeImagesLocks(
+			imagesLock(
+				lockconfig.ImageRef{Image: app, Annotations: map[string]string{"kbld.carvel.dev/id": "app"}},
+				lockconfig.ImageRef{Image: db},
+			),
+			imagesLock(
+				lockconfig.ImageRef{Image: db, Annotations: map[string]string{"kbld.carvel.dev/id": "db"}},
+				lockconfig.ImageRef{Image: cache},
+				lockconfig.ImageRef{Image: app, Annotations: map[string]string{"kbld.carvel.dev/id": "app", "team": "a"}},
+			),
+		)
+		assert.Empty(t, conflicts)
+		require.NoError(t, merged.Validate())
+
+		require.Len(t, merged.Images, 3)
+		assert.Equal(t, app, merged.Images[0].Image)
+		assert.Equal(t, map[string]string{"kbld.carvel.dev/id": "app", "team": "a"}, merged.Images[0].Annotations)
+		assert.Equal(t, db, merged.Images[1].Image)
+		assert.Equal(t, map[string]string{"kbld.carvel.dev/id": "db"}, merged.Images[1].Annotations)
+		assert.Equal(t, cache, merged.Images[2].Image)
+	})
+
+	t.Run("reports every annotation with different values and keeps the first value", func(t *testing.T) {
+		merged, conflicts := lockconfig.MergeImagesLocks(
+			imagesLock(lockconfig.ImageRef{Image: app, Annotations: map[string]string{"kbld.carvel.dev/id": "app", "team": "a"}}),
+			imagesLock(lockconfig.ImageRef{Image: app, Annotations: map[string]string{"kbld.carvel.dev/id": "app"}}),
+			imagesLock(lockconfig.ImageRef{Image: app, Annotations: map[string]string{"kbld.carvel.dev/id": "app-v2", "team": "b"}}),
+		)
+
+		assert.Equal(t, []lockconfig.AnnotationConflict{
+			{Image: app, Annotation: "kbld.carvel.dev/id", Values: map[int]string{0: "app", 1: "app", 2: "app-v2"}},
+			{Image: app, Annotation: "team", Values: map[int]string{0: "a", 2: "b"}},
+		}, conflicts)
+		assert.Equal(t, map[string]string{"kbld.carvel.dev/id": "app", "team": "a"}, merged.Images[0].Annotations)
+	})
+}
@@ -18,23 +18,44 @@ import (
 type Contents struct {
 	paths         []string
 	excludedPaths []string
+	signer        ImageSigner
+	replacedFiles map[string][]byte
 }
 
 type ImagesWriter interface {
 	WriteImage(regname.Reference, regv1.Image) error
 }
 
+// ImageSigner Signs an image that was pushed by digest
+type ImageSigner interface {
+	Sign(regname.Digest) (regname.Digest, error)
+}
+
 func NewContents(paths []string, excludedPaths []string) Contents {
 	return Contents{paths: paths, excludedPaths: excludedPaths}
 }
 
+// WithReplacedFiles Returns Contents that push the provided contents, keyed by absolute path,
+// instead of the contents of the files on disk
+func (i Contents) WithReplacedFiles(replacedFiles map[string][]byte) Contents {
+	i.replacedFiles = replacedFiles
+	return i
+}
+
+// WithSigner Returns Contents that sign the image before it is tagged,
+// so that the tag never points to an unsigned image
+func (i Contents) WithSigner(signer ImageSigner) Contents {
+	i.signer = signer
+	return i
+}
+
 func (i Contents) Push(uploadRef regname.Tag, labels map[string]string, writer ImagesWriter, ui ui.UI) (string, error) {
-	err := i.validate()
+	err := i.Validate()
 	if err != nil {
 		return "", err
 	}
 
-	tarImg := ctlimg.NewTarImage(i.paths, i.excludedPaths, InfoLog{ui})
+	tarImg := ctlimg.NewTarImage(i.paths, i.excludedPaths, InfoLog{ui}).WithReplacedFiles(i.replacedFiles)
 
 	img, err := tarImg.AsFileImage(labels)
 	if err != nil {
@@ -43,20 +64,36 @@ func (i Contents) Push(uploadRef regname.Tag, labels map[string]string, writer I
 
 	defer img.Remove()
 
-	err = writer.WriteImage(uploadRef, img)
+	digest, err := img.Digest()
 	if err != nil {
-		return "", fmt.Errorf("Writing '%s': %s", uploadRef.Name(), err)
+		return "", err
 	}
+	digestRef := uploadRef.Context().Digest(digest.String())
 
-	digest, err := img.Digest()
+	if i.signer != nil {
+		err = writer.WriteImage(digestRef, img)
+		if err != nil {
+			return "", fmt.Errorf("Writing '%s': %s", digestRef.Name(), err)
+		}
+
+		sigRef, err := i.signer.Sign(digestRef)
+		if err != nil {
+			return "", fmt.Errorf("Signing '%s': %s", digestRef.Name(), err)
+		}
+		ui.BeginLinef("Pushed signature '%s'\n", sigRef.Name())
+	}
+
+	// When signed, the blobs were already uploaded with the digest so only the tag is written
+	err = writer.WriteImage(uploadRef, img)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("Writing '%s': %s", uploadRef.Name(), err)
 	}
 
-	return fmt.Sprintf("%s@%s", uploadRef.Context(), digest), nil
+	return digestRef.Name(), nil
 }
 
-func (i Contents) validate() error {
+// Validate Checks that the files of the contents do not end up with the same path in the image
+func (i Contents) Validate() error {
 	return i.checkRepeatedPaths()
 }
 
@@ -18,6 +18,13 @@ type ImagesDescriptor interface {
 	Get(regname.Reference) (*regremote.Descriptor, error)
 }
 
+// LocalImagesDescriptor is implemented by ImagesDescriptor that hold the images themselves
+// (e.g. a tarball), since the descriptors they return cannot fetch images from a registry
+type LocalImagesDescriptor interface {
+	ImagesDescriptor
+	LocalImage(regname.Reference) (regv1.Image, error)
+}
+
 type PlainImage struct {
 	imagesDescriptor ImagesDescriptor
 
@@ -107,7 +114,11 @@ func (i *PlainImage) Fetch() (regv1.Image, error) {
 		return nil, notAnImageError{imgDescriptor.MediaType}
 	}
 
-	i.fetchedImage, err = imgDescriptor.Image()
+	if localImagesDescriptor, ok := i.imagesDescriptor.(LocalImagesDescriptor); ok {
+		i.fetchedImage, err = localImagesDescriptor.LocalImage(i.parsedRef)
+	} else {
+		i.fetchedImage, err = imgDescriptor.Image()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("Fetching image: %s", err)
 	}
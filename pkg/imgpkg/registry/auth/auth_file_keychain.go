@@ -0,0 +1,214 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	"sigs.k8s.io/yaml"
+)
+
+var _ regauthn.Keychain = &AuthFileKeychain{}
+
+const (
+	dockerConfigJSONSecretType = "kubernetes.io/dockerconfigjson"
+	dockerConfigJSONSecretKey  = ".dockerconfigjson"
+	dockerCfgSecretType        = "kubernetes.io/dockercfg"
+	dockerCfgSecretKey         = ".dockercfg"
+)
+
+// dockerConfigJSON Format of docker's config.json and of the .dockerconfigjson key in Kubernetes Secrets
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+// dockerConfigEntry Credentials of a registry in a docker configuration
+type dockerConfigEntry struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+	RegistryToken string `json:"registrytoken"`
+}
+
+// kubernetesSecret Fields of a Kubernetes Secret used to read image pull secrets
+type kubernetesSecret struct {
+	Kind       string            `json:"kind"`
+	Type       string            `json:"type"`
+	Data       map[string][]byte `json:"data"`
+	StringData map[string]string `json:"stringData"`
+}
+
+type authFileKeychainInfo struct {
+	URL    string
+	Config regauthn.AuthConfig
+}
+
+// AuthFileKeychain implements an authn.Keychain interface by using credentials read from files with the format of
+// docker's config.json, either as plain files or as Kubernetes image pull Secrets (kubernetes.io/dockerconfigjson)
+type AuthFileKeychain struct {
+	infos []authFileKeychainInfo
+}
+
+// NewAuthFileKeychain builder for Auth File Keychain
+// When multiple files contain credentials for the same registry, the credentials in the first file are used
+func NewAuthFileKeychain(paths []string) (*AuthFileKeychain, error) {
+	infosByURL := map[string]authFileKeychainInfo{}
+
+	for _, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Reading auth file '%s': %s", path, err)
+		}
+
+		config, err := parseAuthFile(contents)
+		if err != nil {
+			return nil, fmt.Errorf("Parsing auth file '%s': %s", path, err)
+		}
+
+		var registryURLs []string
+		for registryURL := range config.Auths {
+			registryURLs = append(registryURLs, registryURL)
+		}
+		sort.Strings(registryURLs)
+
+		for _, registryURL := range registryURLs {
+			entry := config.Auths[registryURL]
+			key, err := registryURLKey(registryURL)
+			if err != nil {
+				return nil, fmt.Errorf("Parsing auth file '%s': %s", path, err)
+			}
+
+			if _, err := newRegistryURLPattern(key); err != nil {
+				return nil, fmt.Errorf("Parsing auth file '%s': %s", path, err)
+			}
+
+			if _, found := infosByURL[key]; found {
+				continue
+			}
+
+			authConfig, err := entry.authConfig()
+			if err != nil {
+				return nil, fmt.Errorf("Parsing auth file '%s': Credentials for '%s': %s", path, registryURL, err)
+			}
+			infosByURL[key] = authFileKeychainInfo{URL: key, Config: authConfig}
+		}
+	}
+
+	var infos []authFileKeychainInfo
+	for _, info := range infosByURL {
+		infos = append(infos, info)
+	}
+
+	// Sorted so the most specific hostnames are matched first, the same way as the env keychain does
+	sort.Slice(infos, func(i, j int) bool { return moreSpecificRegistryURL(infos[i].URL, infos[j].URL) })
+
+	return &AuthFileKeychain{infos: infos}, nil
+}
+
+// Resolve looks up the most appropriate credential for the specified target.
+func (k *AuthFileKeychain) Resolve(target regauthn.Resource) (regauthn.Authenticator, error) {
+	for _, info := range k.infos {
+		registryURLMatches, err := registryURLMatches(info.URL, target.String())
+		if err != nil {
+			return nil, err
+		}
+
+		if registryURLMatches {
+			return regauthn.FromConfig(info.Config), nil
+		}
+	}
+
+	return regauthn.Anonymous, nil
+}
+
+// parseAuthFile parses a docker config.json, the contents of a .dockerconfigjson or .dockercfg key,
+// or a Kubernetes Secret (JSON or YAML) of type kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg
+func parseAuthFile(contents []byte) (dockerConfigJSON, error) {
+	var secret kubernetesSecret
+	err := yaml.Unmarshal(contents, &secret)
+	if err != nil {
+		return dockerConfigJSON{}, err
+	}
+
+	if secret.Kind != "Secret" {
+		return parseDockerConfig(contents)
+	}
+
+	switch secret.Type {
+	case dockerConfigJSONSecretType:
+		data, err := secret.value(dockerConfigJSONSecretKey)
+		if err != nil {
+			return dockerConfigJSON{}, err
+		}
+		return parseDockerConfig(data)
+
+	case dockerCfgSecretType:
+		data, err := secret.value(dockerCfgSecretKey)
+		if err != nil {
+			return dockerConfigJSON{}, err
+		}
+		var auths map[string]dockerConfigEntry
+		err = json.Unmarshal(data, &auths)
+		if err != nil {
+			return dockerConfigJSON{}, fmt.Errorf("Unmarshaling %s: %s", dockerCfgSecretKey, err)
+		}
+		return dockerConfigJSON{Auths: auths}, nil
+
+	default:
+		return dockerConfigJSON{}, fmt.Errorf("Expected Secret to be of type %s or %s, got '%s'",
+			dockerConfigJSONSecretType, dockerCfgSecretType, secret.Type)
+	}
+}
+
+func parseDockerConfig(contents []byte) (dockerConfigJSON, error) {
+	var config dockerConfigJSON
+	err := yaml.Unmarshal(contents, &config)
+	if err != nil {
+		return dockerConfigJSON{}, fmt.Errorf("Unmarshaling docker config: %s", err)
+	}
+	if config.Auths == nil {
+		return dockerConfigJSON{}, fmt.Errorf("Expected docker config to contain 'auths'")
+	}
+	return config, nil
+}
+
+func (s kubernetesSecret) value(key string) ([]byte, error) {
+	if val, found := s.StringData[key]; found {
+		return []byte(val), nil
+	}
+	if val, found := s.Data[key]; found {
+		return val, nil
+	}
+	return nil, fmt.Errorf("Expected Secret of type %s to contain key '%s'", s.Type, key)
+}
+
+func (e dockerConfigEntry) authConfig() (regauthn.AuthConfig, error) {
+	config := regauthn.AuthConfig{
+		Username:      e.Username,
+		Password:      e.Password,
+		IdentityToken: e.IdentityToken,
+		RegistryToken: e.RegistryToken,
+	}
+
+	if len(e.Auth) > 0 {
+		decoded, err := base64.StdEncoding.DecodeString(e.Auth)
+		if err != nil {
+			return regauthn.AuthConfig{}, fmt.Errorf("Decoding auth: %s", err)
+		}
+		pieces := strings.SplitN(string(decoded), ":", 2)
+		if len(pieces) != 2 {
+			return regauthn.AuthConfig{}, fmt.Errorf("Expected auth to be in the format 'username:password'")
+		}
+		config.Username, config.Password = pieces[0], pieces[1]
+	}
+
+	return config, nil
+}
@@ -4,11 +4,10 @@
 package auth
 
 import (
-	"fmt"
 	"strings"
-	"time"
 
 	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
 
 var _ regauthn.Keychain = CustomRegistryKeychain{}
@@ -19,8 +18,32 @@ type KeychainOpts struct {
 	Password string
 	Token    string
 	Anon     bool
+
+	// AuthFilePaths are docker config.json files or Kubernetes image pull Secrets used by the auth file keychain
+	AuthFilePaths []string
+
+	// CredentialProviderConfigPath points to a CredentialProviderConfig with the plugins used by the exec keychain,
+	// which are looked up in CredentialProviderBinDir
+	CredentialProviderConfigPath string
+	CredentialProviderBinDir     string
+
+	// RetryPolicy is used when resolving credentials from docker credential helpers
+	RetryPolicy util.RetryPolicy
+
+	// Logger receives the warnings of the keychains, like the errors of the credential provider plugins that are skipped.
+	// Warnings are discarded when it is not set
+	Logger Logger
+}
+
+// Logger Reports the errors the keychains recover from
+type Logger interface {
+	Warnf(msg string, args ...interface{})
 }
 
+type noopLogger struct{}
+
+func (noopLogger) Warnf(string, ...interface{}) {}
+
 // NewSingleAuthKeychain Builds a SingleAuthKeychain struct
 func NewSingleAuthKeychain(auth regauthn.Authenticator) SingleAuthKeychain {
 	return SingleAuthKeychain{auth: auth}
@@ -51,13 +74,22 @@ func (k CustomRegistryKeychain) Resolve(res regauthn.Resource) (regauthn.Authent
 	case k.Opts.Anon:
 		return regauthn.Anonymous, nil
 	default:
-		return k.retryDefaultKeychain(func() (regauthn.Authenticator, error) {
-			return regauthn.DefaultKeychain.Resolve(res)
+		var auth regauthn.Authenticator
+		err := k.Opts.RetryPolicy.Retry(func() error {
+			var err error
+			auth, err = regauthn.DefaultKeychain.Resolve(res)
+			if err != nil && k.isCredentialsNotFound(err) {
+				return util.NonRetryableError{Message: err.Error()}
+			}
+			return err
 		})
+		return auth, err
 	}
 }
 
-func (k CustomRegistryKeychain) retryDefaultKeychain(doFunc func() (regauthn.Authenticator, error)) (regauthn.Authenticator, error) {
+// isCredentialsNotFound checks if the docker credential helpers could not find credentials,
+// in which case retrying does not help
+func (k CustomRegistryKeychain) isCredentialsNotFound(err error) bool {
 	// constants copied from https://github.com/vmware-tanzu/carvel-imgpkg/blob/c8b1bc196e5f1af82e6df8c36c290940169aa896/vendor/github.com/docker/docker-credential-helpers/credentials/error.go#L4-L11
 
 	// ErrCredentialsNotFound standardizes the not found error, so every helper returns
@@ -68,20 +100,5 @@ func (k CustomRegistryKeychain) retryDefaultKeychain(doFunc func() (regauthn.Aut
 	const errCredentialsMissingServerURLMessage = "no credentials server URL"
 	const errCredentialsMissingUsernameMessage = "no credentials username"
 
-	var auth regauthn.Authenticator
-	var lastErr error
-
-	for i := 0; i < 5; i++ {
-		auth, lastErr = doFunc()
-		if lastErr == nil {
-			return auth, nil
-		}
-
-		if strings.Contains(lastErr.Error(), errCredentialsNotFoundMessage) || strings.Contains(lastErr.Error(), errCredentialsMissingUsernameMessage) || strings.Contains(lastErr.Error(), errCredentialsMissingServerURLMessage) {
-			return auth, lastErr
-		}
-
-		time.Sleep(2 * time.Second)
-	}
-	return auth, fmt.Errorf("Retried 5 times: %s", lastErr)
+	return strings.Contains(err.Error(), errCredentialsNotFoundMessage) || strings.Contains(err.Error(), errCredentialsMissingUsernameMessage) || strings.Contains(err.Error(), errCredentialsMissingServerURLMessage)
 }
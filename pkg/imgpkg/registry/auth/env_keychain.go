@@ -12,7 +12,6 @@ import (
 	"sync"
 
 	regauthn "github.com/google/go-containerregistry/pkg/authn"
-	credentialprovider "github.com/vdemeester/k8s-pkg-credentialprovider"
 )
 
 var _ regauthn.Keychain = &EnvKeychain{}
@@ -54,7 +53,7 @@ func (k *EnvKeychain) Resolve(target regauthn.Resource) (regauthn.Authenticator,
 	}
 
 	for _, info := range infos {
-		registryURLMatches, err := credentialprovider.URLsMatchStr(info.URL, target.String())
+		registryURLMatches, err := registryURLMatches(info.URL, target.String())
 		if err != nil {
 			return nil, err
 		}
@@ -79,7 +78,7 @@ func (s orderedEnvKeychainInfos) Len() int {
 }
 
 func (s orderedEnvKeychainInfos) Less(i, j int) bool {
-	return s[i].URL < s[j].URL
+	return moreSpecificRegistryURL(s[i].URL, s[j].URL)
 }
 
 func (s orderedEnvKeychainInfos) Swap(i, j int) {
@@ -104,28 +103,12 @@ func (k *EnvKeychain) collect() ([]envKeychainInfo, error) {
 
 	funcsMap := map[string]func(*envKeychainInfo, string) error{
 		"HOSTNAME": func(info *envKeychainInfo, val string) error {
-			if !strings.HasPrefix(val, "https://") && !strings.HasPrefix(val, "http://") {
-				val = "https://" + val
-			}
-			parsedURL, err := url.Parse(val)
+			key, err := registryURLKey(val)
 			if err != nil {
-				return fmt.Errorf("Parsing registry hostname: %s (e.g. gcr.io, index.docker.io)", err)
-			}
-
-			// Allows exact matches:
-			//    foo.bar.com/namespace
-			// Or hostname matches:
-			//    foo.bar.com
-			// It also considers /v2/  and /v1/ equivalent to the hostname
-			effectivePath := parsedURL.Path
-			if strings.HasPrefix(effectivePath, "/v2/") || strings.HasPrefix(effectivePath, "/v1/") {
-				effectivePath = effectivePath[3:]
+				return err
 			}
-			var key string
-			if (len(effectivePath) > 0) && (effectivePath != "/") {
-				key = parsedURL.Host + effectivePath
-			} else {
-				key = parsedURL.Host
+			if _, err := newRegistryURLPattern(key); err != nil {
+				return err
 			}
 			info.URL = key
 			return nil
@@ -195,18 +178,51 @@ func (k *EnvKeychain) collect() ([]envKeychainInfo, error) {
 	if defaultInfo != (envKeychainInfo{}) {
 		result = append(result, defaultInfo)
 	}
-	for _, info := range infos {
-		result = append(result, info)
+	var suffixes []string
+	for suffix := range infos {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Strings(suffixes)
+	for _, suffix := range suffixes {
+		result = append(result, infos[suffix])
 	}
 
 	// Update the collected auth infos used to identify which credentials to use for a given
-	// image. The info is reverse-sorted by URL so more specific paths are matched
-	// first. For example, if for the given image "quay.io/coreos/etcd",
-	// credentials for "quay.io/coreos" should match before "quay.io".
-	sort.Sort(sort.Reverse(orderedEnvKeychainInfos(result)))
+	// image. The info is sorted so the most specific hostnames are matched first.
+	// For example, if for the given image "quay.io/coreos/etcd",
+	// credentials for "quay.io/coreos" should match before "quay.io" or "*.io/coreos".
+	// When multiple infos have the same hostname the default info is used first, then the others
+	// ordered by their env variable suffix.
+	sort.Stable(orderedEnvKeychainInfos(result))
 
 	k.infos = result
 	k.collected = true
 
 	return append([]envKeychainInfo{}, k.infos...), nil
 }
+
+// registryURLKey returns the key used to match credentials against images
+// for a registry hostname with an optional path and protocol
+func registryURLKey(val string) (string, error) {
+	if !strings.HasPrefix(val, "https://") && !strings.HasPrefix(val, "http://") {
+		val = "https://" + val
+	}
+	parsedURL, err := url.Parse(val)
+	if err != nil {
+		return "", fmt.Errorf("Parsing registry hostname: %s (e.g. gcr.io, index.docker.io)", err)
+	}
+
+	// Allows exact matches:
+	//    foo.bar.com/namespace
+	// Or hostname matches:
+	//    foo.bar.com
+	// It also considers /v2/  and /v1/ equivalent to the hostname
+	effectivePath := parsedURL.Path
+	if strings.HasPrefix(effectivePath, "/v2/") || strings.HasPrefix(effectivePath, "/v1/") {
+		effectivePath = effectivePath[3:]
+	}
+	if (len(effectivePath) > 0) && (effectivePath != "/") {
+		return parsedURL.Host + effectivePath, nil
+	}
+	return parsedURL.Host, nil
+}
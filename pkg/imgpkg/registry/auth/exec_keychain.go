@@ -0,0 +1,401 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	"golang.org/x/sync/singleflight"
+	"sigs.k8s.io/yaml"
+)
+
+var _ regauthn.Keychain = &ExecKeychain{}
+
+const (
+	CredentialProviderConfigKind = "CredentialProviderConfig"
+
+	credentialProviderRequestKind  = "CredentialProviderRequest"
+	credentialProviderResponseKind = "CredentialProviderResponse"
+
+	credentialProviderCacheKeyImage    = "Image"
+	credentialProviderCacheKeyRegistry = "Registry"
+	credentialProviderCacheKeyGlobal   = "Global"
+
+	credentialProviderExecTimeout = 1 * time.Minute
+)
+
+// CredentialProviderConfigAPIVersions are the versions of the kubelet configuration accepted in CredentialProviderConfig
+var CredentialProviderConfigAPIVersions = []string{"kubelet.config.k8s.io/v1", "kubelet.config.k8s.io/v1beta1", "kubelet.config.k8s.io/v1alpha1"}
+
+// CredentialProviderAPIVersions are the versions of the exec protocol that plugins can use
+var CredentialProviderAPIVersions = []string{"credentialprovider.kubelet.k8s.io/v1", "credentialprovider.kubelet.k8s.io/v1beta1", "credentialprovider.kubelet.k8s.io/v1alpha1"}
+
+// CredentialProviderConfig Configuration of the credential provider plugins, using the same format as the kubelet
+// (https://kubernetes.io/docs/tasks/administer-cluster/kubelet-credential-provider/)
+type CredentialProviderConfig struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Providers  []CredentialProvider `json:"providers"`
+}
+
+// CredentialProvider Plugin executed to retrieve the credentials of the images matching MatchImages
+type CredentialProvider struct {
+	Name                 string                  `json:"name"`
+	MatchImages          []string                `json:"matchImages"`
+	DefaultCacheDuration string                  `json:"defaultCacheDuration,omitempty"`
+	APIVersion           string                  `json:"apiVersion"`
+	Args                 []string                `json:"args,omitempty"`
+	Env                  []CredentialProviderEnv `json:"env,omitempty"`
+}
+
+// CredentialProviderEnv Environment variable set when executing the plugin
+type CredentialProviderEnv struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type credentialProviderRequest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Image      string `json:"image"`
+}
+
+type credentialProviderResponse struct {
+	APIVersion    string                                  `json:"apiVersion"`
+	Kind          string                                  `json:"kind"`
+	CacheKeyType  string                                  `json:"cacheKeyType"`
+	CacheDuration *string                                 `json:"cacheDuration,omitempty"`
+	Auth          map[string]credentialProviderAuthConfig `json:"auth"`
+}
+
+type credentialProviderAuthConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// NewCredentialProviderConfigFromPath Reads and validates the credential provider configuration
+func NewCredentialProviderConfigFromPath(path string) (CredentialProviderConfig, error) {
+	var config CredentialProviderConfig
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	err = yaml.UnmarshalStrict(bs, &config)
+	if err != nil {
+		return config, fmt.Errorf("Unmarshaling credential provider config: %s", err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return config, fmt.Errorf("Validating credential provider config: %s", err)
+	}
+
+	return config, nil
+}
+
+// Validate Checks the providers configuration
+func (c CredentialProviderConfig) Validate() error {
+	if !containsString(CredentialProviderConfigAPIVersions, c.APIVersion) {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", strings.Join(CredentialProviderConfigAPIVersions, ", "))
+	}
+	if c.Kind != CredentialProviderConfigKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", CredentialProviderConfigKind)
+	}
+
+	for _, provider := range c.Providers {
+		if len(provider.Name) == 0 {
+			return fmt.Errorf("Expected provider name to be provided")
+		}
+		if strings.ContainsAny(provider.Name, `/\`) || provider.Name == "." || provider.Name == ".." {
+			return fmt.Errorf("Expected provider name '%s' to be a file name without a path", provider.Name)
+		}
+		if len(provider.MatchImages) == 0 {
+			return fmt.Errorf("Expected provider '%s' to have at least one matchImages pattern", provider.Name)
+		}
+		if !containsString(CredentialProviderAPIVersions, provider.APIVersion) {
+			return fmt.Errorf("Validating provider '%s' apiVersion: Unknown version (known: %s)", provider.Name, strings.Join(CredentialProviderAPIVersions, ", "))
+		}
+		if len(provider.DefaultCacheDuration) > 0 {
+			if _, err := time.ParseDuration(provider.DefaultCacheDuration); err != nil {
+				return fmt.Errorf("Parsing provider '%s' defaultCacheDuration: %s", provider.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+type execCacheEntry struct {
+	auths     []authFileKeychainInfo
+	expiresAt time.Time
+}
+
+// ExecKeychain implements an authn.Keychain interface by executing credential provider plugins that follow
+// the kubelet CredentialProviderRequest/CredentialProviderResponse exec protocol
+type ExecKeychain struct {
+	providers []CredentialProvider
+	binDir    string
+	now       func() time.Time
+	// logger receives the errors of the plugins, that are skipped when they fail
+	logger Logger
+
+	// cache contains the plugin responses by provider name and cache key
+	cache     map[string]map[string]execCacheEntry
+	cacheLock sync.Mutex
+	// execs ensures a plugin is executed only once at a time for the same provider and image
+	execs singleflight.Group
+}
+
+// NewExecKeychain builder for Exec Keychain
+// Plugins are looked up in binDir, or in $PATH when binDir is not provided. The errors of the plugins are reported to the logger
+func NewExecKeychain(configPath, binDir string, logger Logger) (*ExecKeychain, error) {
+	if logger == nil {
+		logger = noopLogger{}
+	}
+
+	keychain := &ExecKeychain{
+		binDir: binDir,
+		now:    time.Now,
+		logger: logger,
+		cache:  map[string]map[string]execCacheEntry{},
+	}
+
+	if len(configPath) == 0 {
+		return keychain, nil
+	}
+
+	config, err := NewCredentialProviderConfigFromPath(configPath)
+	if err != nil {
+		return nil, err
+	}
+	keychain.providers = config.Providers
+
+	return keychain, nil
+}
+
+// Resolve looks up the most appropriate credential for the specified target.
+// When a plugin fails the error is reported as a warning and the next providers and keychains are used
+func (k *ExecKeychain) Resolve(target regauthn.Resource) (regauthn.Authenticator, error) {
+	image := target.String()
+
+	for _, provider := range k.providers {
+		matches, err := k.matchesImages(provider, image)
+		if err != nil {
+			return nil, err
+		}
+		if !matches {
+			continue
+		}
+
+		auths, err := k.providerAuths(provider, target)
+		if err != nil {
+			k.logger.Warnf("Skipping credential provider '%s': %s\n", provider.Name, err)
+			continue
+		}
+
+		for _, info := range auths {
+			registryURLMatches, err := registryURLMatches(info.URL, image)
+			if err != nil {
+				k.logger.Warnf("Skipping credentials of credential provider '%s': %s\n", provider.Name, err)
+				continue
+			}
+			if registryURLMatches {
+				return regauthn.FromConfig(info.Config), nil
+			}
+		}
+	}
+
+	return regauthn.Anonymous, nil
+}
+
+func (k *ExecKeychain) matchesImages(provider CredentialProvider, image string) (bool, error) {
+	for _, pattern := range provider.MatchImages {
+		matches, err := registryURLMatches(pattern, image)
+		if err != nil {
+			return false, fmt.Errorf("Matching image with provider '%s' pattern '%s': %s", provider.Name, pattern, err)
+		}
+		if matches {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// providerAuths returns the credentials provided by the plugin, from the cache when a previous response has not expired.
+// The plugin is executed without holding the cache lock, so plugins for other images are not blocked while it runs
+func (k *ExecKeychain) providerAuths(provider CredentialProvider, target regauthn.Resource) ([]authFileKeychainInfo, error) {
+	cacheKeys := map[string]string{
+		credentialProviderCacheKeyImage:    credentialProviderCacheKeyImage + ":" + target.String(),
+		credentialProviderCacheKeyRegistry: credentialProviderCacheKeyRegistry + ":" + target.RegistryStr(),
+		credentialProviderCacheKeyGlobal:   credentialProviderCacheKeyGlobal,
+	}
+
+	if auths, found := k.cachedAuths(provider, cacheKeys); found {
+		return auths, nil
+	}
+
+	result, err, _ := k.execs.Do(provider.Name+"\x00"+target.String(), func() (interface{}, error) {
+		response, err := k.exec(provider, target.String())
+		if err != nil {
+			return nil, err
+		}
+
+		var auths []authFileKeychainInfo
+		for registryURL, auth := range response.Auth {
+			auths = append(auths, authFileKeychainInfo{
+				URL:    registryURL,
+				Config: regauthn.AuthConfig{Username: auth.Username, Password: auth.Password},
+			})
+		}
+		// Sorted so the most specific hostnames are matched first, the same way as the env keychain does
+		sort.Slice(auths, func(i, j int) bool { return moreSpecificRegistryURL(auths[i].URL, auths[j].URL) })
+
+		cacheDuration, err := k.cacheDuration(provider, response)
+		if err != nil {
+			return nil, err
+		}
+		if cacheDuration > 0 {
+			k.cacheLock.Lock()
+			k.providerCache(provider)[cacheKeys[response.CacheKeyType]] = execCacheEntry{
+				auths:     auths,
+				expiresAt: k.now().Add(cacheDuration),
+			}
+			k.cacheLock.Unlock()
+		}
+
+		return auths, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]authFileKeychainInfo), nil
+}
+
+// cachedAuths returns the credentials of the most specific cache entry of the provider that has not expired
+func (k *ExecKeychain) cachedAuths(provider CredentialProvider, cacheKeys map[string]string) ([]authFileKeychainInfo, bool) {
+	k.cacheLock.Lock()
+	defer k.cacheLock.Unlock()
+
+	providerCache := k.providerCache(provider)
+	for _, cacheKey := range []string{credentialProviderCacheKeyImage, credentialProviderCacheKeyRegistry, credentialProviderCacheKeyGlobal} {
+		if entry, found := providerCache[cacheKeys[cacheKey]]; found {
+			if k.now().Before(entry.expiresAt) {
+				return entry.auths, true
+			}
+			delete(providerCache, cacheKeys[cacheKey])
+		}
+	}
+	return nil, false
+}
+
+// providerCache returns the cache entries of the provider, it must be called while holding cacheLock
+func (k *ExecKeychain) providerCache(provider CredentialProvider) map[string]execCacheEntry {
+	providerCache := k.cache[provider.Name]
+	if providerCache == nil {
+		providerCache = map[string]execCacheEntry{}
+		k.cache[provider.Name] = providerCache
+	}
+	return providerCache
+}
+
+func (k *ExecKeychain) cacheDuration(provider CredentialProvider, response credentialProviderResponse) (time.Duration, error) {
+	cacheDuration := provider.DefaultCacheDuration
+	if response.CacheDuration != nil {
+		cacheDuration = *response.CacheDuration
+	}
+	if len(cacheDuration) == 0 {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(cacheDuration)
+	if err != nil {
+		return 0, fmt.Errorf("Parsing cache duration of provider '%s': %s", provider.Name, err)
+	}
+	return duration, nil
+}
+
+// exec runs the plugin sending the request via stdin and reading the response from stdout
+func (k *ExecKeychain) exec(provider CredentialProvider, image string) (credentialProviderResponse, error) {
+	var response credentialProviderResponse
+
+	pluginPath := provider.Name
+	if len(k.binDir) > 0 {
+		pluginPath = filepath.Join(k.binDir, provider.Name)
+	}
+	pluginPath, err := exec.LookPath(pluginPath)
+	if err != nil {
+		return response, fmt.Errorf("Finding credential provider '%s': %s", provider.Name, err)
+	}
+
+	request, err := json.Marshal(credentialProviderRequest{
+		APIVersion: provider.APIVersion,
+		Kind:       credentialProviderRequestKind,
+		Image:      image,
+	})
+	if err != nil {
+		return response, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialProviderExecTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pluginPath, provider.Args...)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = os.Environ()
+	for _, env := range provider.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+
+	err = cmd.Run()
+	if err != nil {
+		return response, fmt.Errorf("Executing credential provider '%s': %s (stderr: %s)", provider.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	err = json.Unmarshal(stdout.Bytes(), &response)
+	if err != nil {
+		return response, fmt.Errorf("Unmarshaling response of credential provider '%s': %s", provider.Name, err)
+	}
+
+	if response.Kind != credentialProviderResponseKind {
+		return response, fmt.Errorf("Expected credential provider '%s' response kind to be %s, got '%s'", provider.Name, credentialProviderResponseKind, response.Kind)
+	}
+	if response.APIVersion != provider.APIVersion {
+		return response, fmt.Errorf("Expected credential provider '%s' response apiVersion to be %s, got '%s'", provider.Name, provider.APIVersion, response.APIVersion)
+	}
+	switch response.CacheKeyType {
+	case credentialProviderCacheKeyImage, credentialProviderCacheKeyRegistry, credentialProviderCacheKeyGlobal:
+	default:
+		return response, fmt.Errorf("Expected credential provider '%s' response cacheKeyType to be one of %s, %s, %s, got '%s'", provider.Name,
+			credentialProviderCacheKeyImage, credentialProviderCacheKeyRegistry, credentialProviderCacheKeyGlobal, response.CacheKeyType)
+	}
+
+	return response, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
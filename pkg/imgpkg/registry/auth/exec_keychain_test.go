@@ -0,0 +1,207 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestExecKeychain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential provider stub is a shell script")
+	}
+
+	// The stub records each request it receives and answers with the response in $RESPONSE after $DELAY seconds
+	const stubPlugin = `#!/bin/sh
+sleep "${DELAY:-0}"
+cat >> "$REQUESTS_FILE"
+echo >> "$REQUESTS_FILE"
+echo "$RESPONSE"
+`
+	setup := func(t *testing.T, response string, defaultCacheDuration string) (*ExecKeychain, string) {
+		binDir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "stub-provider"), []byte(stubPlugin), 0700))
+
+		requestsFile := filepath.Join(t.TempDir(), "requests")
+		configPath := filepath.Join(t.TempDir(), "config.yml")
+		require.NoError(t, ioutil.WriteFile(configPath, []byte(fmt.Sprintf(`apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: stub-provider
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  matchImages:
+  - "*.corp.com"
+  defaultCacheDuration: %q
+  env:
+  - name: REQUESTS_FILE
+    value: %s
+  - name: RESPONSE
+    value: '%s'
+`, defaultCacheDuration, requestsFile, response)), 0600))
+
+		keychain, err := NewExecKeychain(configPath, binDir, &bufferLogger{})
+		require.NoError(t, err)
+		return keychain, requestsFile
+	}
+	requests := func(t *testing.T, requestsFile string) []string {
+		contents, err := ioutil.ReadFile(requestsFile)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		return strings.Fields(string(contents))
+	}
+	resolve := func(t *testing.T, keychain *ExecKeychain, repo string) regauthn.Authenticator {
+		resource, err := regname.NewRepository(repo)
+		require.NoError(t, err)
+		auth, err := keychain.Resolve(resource)
+		require.NoError(t, err)
+		return auth
+	}
+	response := func(cacheKeyType string, cacheDuration string) string {
+		return fmt.Sprintf(`{"apiVersion":"credentialprovider.kubelet.k8s.io/v1","kind":"CredentialProviderResponse","cacheKeyType":%q,"cacheDuration":%q,"auth":{"*.corp.com":{"username":"user","password":"pass"}}}`, cacheKeyType, cacheDuration)
+	}
+
+	t.Run("returns the credentials provided by the plugin for matching images", func(t *testing.T) {
+		keychain, requestsFile := setup(t, response("Image", "0s"), "")
+
+		auth := resolve(t, keychain, "registry.corp.com/app")
+		assert.Equal(t, regauthn.FromConfig(regauthn.AuthConfig{Username: "user", Password: "pass"}), auth)
+		assert.Equal(t, []string{`{"apiVersion":"credentialprovider.kubelet.k8s.io/v1","kind":"CredentialProviderRequest","image":"registry.corp.com/app"}`}, requests(t, requestsFile))
+	})
+
+	t.Run("does not execute the plugin for images that do not match", func(t *testing.T) {
+		keychain, requestsFile := setup(t, response("Image", "0s"), "")
+
+		auth := resolve(t, keychain, "index.docker.io/library/nginx")
+		assert.Equal(t, regauthn.Anonymous, auth)
+		assert.Empty(t, requests(t, requestsFile))
+	})
+
+	t.Run("caches the response by registry for the cacheDuration", func(t *testing.T) {
+		keychain, requestsFile := setup(t, response("Registry", "1h"), "")
+		now := time.Now()
+		keychain.now = func() time.Time { return now }
+
+		resolve(t, keychain, "registry.corp.com/app")
+		resolve(t, keychain, "registry.corp.com/other-app")
+		assert.Len(t, requests(t, requestsFile), 1)
+
+		resolve(t, keychain, "other.corp.com/app")
+		assert.Len(t, requests(t, requestsFile), 2)
+
+		now = now.Add(2 * time.Hour)
+		resolve(t, keychain, "registry.corp.com/app")
+		assert.Len(t, requests(t, requestsFile), 3)
+	})
+
+	t.Run("caches the response by image for the provider defaultCacheDuration", func(t *testing.T) {
+		keychain, requestsFile := setup(t, `{"apiVersion":"credentialprovider.kubelet.k8s.io/v1","kind":"CredentialProviderResponse","cacheKeyType":"Image","auth":{"*.corp.com":{"username":"user","password":"pass"}}}`, "10m")
+
+		resolve(t, keychain, "registry.corp.com/app")
+		resolve(t, keychain, "registry.corp.com/app")
+		assert.Len(t, requests(t, requestsFile), 1)
+
+		resolve(t, keychain, "registry.corp.com/other-app")
+		assert.Len(t, requests(t, requestsFile), 2)
+	})
+
+	t.Run("executes the plugin once when the same image is resolved concurrently", func(t *testing.T) {
+		keychain, requestsFile := setup(t, response("Image", "0s"), "")
+		t.Setenv("DELAY", "0.5")
+
+		var wg errgroup.Group
+		for i := 0; i < 5; i++ {
+			wg.Go(func() error {
+				resource, err := regname.NewRepository("registry.corp.com/app")
+				if err != nil {
+					return err
+				}
+				_, err = keychain.Resolve(resource)
+				return err
+			})
+		}
+		require.NoError(t, wg.Wait())
+		assert.Len(t, requests(t, requestsFile), 1)
+	})
+
+	t.Run("when the plugin returns an invalid response it warns and uses no credentials", func(t *testing.T) {
+		keychain, _ := setup(t, `{"apiVersion":"credentialprovider.kubelet.k8s.io/v1beta1","kind":"CredentialProviderResponse","cacheKeyType":"Image"}`, "")
+
+		auth := resolve(t, keychain, "registry.corp.com/app")
+		assert.Equal(t, regauthn.Anonymous, auth)
+		assert.Contains(t, keychain.logger.(*bufferLogger).String(), "Skipping credential provider 'stub-provider': Expected credential provider 'stub-provider' response apiVersion to be credentialprovider.kubelet.k8s.io/v1, got 'credentialprovider.kubelet.k8s.io/v1beta1'")
+	})
+
+	t.Run("when the plugin cannot be found it warns and uses no credentials", func(t *testing.T) {
+		keychain, _ := setup(t, response("Image", "0s"), "")
+		keychain.binDir = t.TempDir()
+
+		auth := resolve(t, keychain, "registry.corp.com/app")
+		assert.Equal(t, regauthn.Anonymous, auth)
+		assert.Contains(t, keychain.logger.(*bufferLogger).String(), "Skipping credential provider 'stub-provider': Finding credential provider 'stub-provider'")
+	})
+}
+
+func TestNewCredentialProviderConfigFromPath(t *testing.T) {
+	writeConfig := func(t *testing.T, contents string) string {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+
+	t.Run("when apiVersion is unknown it errors", func(t *testing.T) {
+		_, err := NewCredentialProviderConfigFromPath(writeConfig(t, `apiVersion: v1
+kind: CredentialProviderConfig
+`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Validating apiVersion: Unknown version")
+	})
+
+	t.Run("when a provider has no matchImages it errors", func(t *testing.T) {
+		_, err := NewCredentialProviderConfigFromPath(writeConfig(t, `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: provider
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected provider 'provider' to have at least one matchImages pattern")
+	})
+
+	t.Run("when a provider name is a path it errors", func(t *testing.T) {
+		_, err := NewCredentialProviderConfigFromPath(writeConfig(t, `apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: ../provider
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  matchImages: ["*.corp.com"]
+`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected provider name '../provider' to be a file name without a path")
+	})
+}
+
+// bufferLogger keeps the warnings to be asserted
+type bufferLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufferLogger) Warnf(msg string, args ...interface{}) {
+	fmt.Fprintf(&l.Buffer, msg, args...)
+}
@@ -21,6 +21,16 @@ const (
 	enableIaasAuthEnvKey = "IMGPKG_ENABLE_IAAS_AUTH"
 )
 
+// metadataRetryPolicy Policy used to look up credentials in the IaaS metadata services.
+// It is kept short, independently of the registry retry policy, since outside of an IaaS
+// every lookup fails and is retried before falling back to the other keychains
+var metadataRetryPolicy = util.RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 1 * time.Second,
+	MaxInterval:     1 * time.Second,
+	Multiplier:      1,
+}
+
 // NewIaasKeychain implements an authn.Keychain interface by using credentials provided by the iaas metadata services
 func NewIaasKeychain(ctx context.Context, environFunc func() []string) (authn.Keychain, error) {
 	if environFunc == nil {
@@ -80,7 +90,7 @@ func (lp lazyProvider) Authorization() (*authn.AuthConfig, error) {
 	var creds []credentialprovider.AuthConfig
 	var found bool
 
-	err := util.Retry(func() error {
+	err := metadataRetryPolicy.Retry(func() error {
 		creds, found = lp.kc.keyring.Lookup(lp.image)
 		if !found || len(creds) < 1 {
 			return fmt.Errorf("iaas_keychain was unable to find credentials for %q", lp.image)
@@ -0,0 +1,116 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+)
+
+// registryURLPattern Registry hostname with an optional port and repository path prefix
+// (examples: registry.corp.com, *.ecr.us-east-1.amazonaws.com, registry.corp.com/team-*)
+// Each part of the hostname and each segment of the path can contain glob wildcards (*, ? and [...])
+type registryURLPattern struct {
+	hostParts    []string
+	port         string
+	pathSegments []string
+}
+
+func newRegistryURLPattern(url string) (registryURLPattern, error) {
+	hostParts, port, pathSegments := splitRegistryURL(url)
+	pattern := registryURLPattern{hostParts, port, pathSegments}
+
+	for _, part := range append(append([]string{}, hostParts...), pathSegments...) {
+		if _, err := path.Match(part, ""); err != nil {
+			return registryURLPattern{}, fmt.Errorf("Parsing registry hostname '%s': %s", url, err)
+		}
+	}
+
+	return pattern, nil
+}
+
+// registryURLMatches checks if the target (example: registry.corp.com/team-a/app) matches the pattern,
+// the hostname and port must match and the pattern path must match the first segments of the target path
+func registryURLMatches(pattern, target string) (bool, error) {
+	if len(pattern) == 0 {
+		return false, nil
+	}
+
+	p, err := newRegistryURLPattern(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	targetHostParts, targetPort, targetPathSegments := splitRegistryURL(target)
+	if p.port != targetPort || len(p.hostParts) != len(targetHostParts) || len(p.pathSegments) > len(targetPathSegments) {
+		return false, nil
+	}
+
+	for i, part := range p.hostParts {
+		if matched, _ := path.Match(part, targetHostParts[i]); !matched {
+			return false, nil
+		}
+	}
+	for i, segment := range p.pathSegments {
+		if matched, _ := path.Match(segment, targetPathSegments[i]); !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// moreSpecificRegistryURL orders patterns so that the first pattern matching a target is the most specific one:
+//  1. patterns with fewer hostname parts containing wildcards first, so exact hostnames are preferred
+//  2. then patterns with more path segments (longest repository prefix)
+//  3. then patterns with fewer path segments containing wildcards
+//  4. then longer patterns
+//  5. and finally in alphabetical order, so the order is always the same
+func moreSpecificRegistryURL(a, b string) bool {
+	aPattern, _ := newRegistryURLPattern(a)
+	bPattern, _ := newRegistryURLPattern(b)
+
+	if aHost, bHost := wildcards(aPattern.hostParts), wildcards(bPattern.hostParts); aHost != bHost {
+		return aHost < bHost
+	}
+	if len(aPattern.pathSegments) != len(bPattern.pathSegments) {
+		return len(aPattern.pathSegments) > len(bPattern.pathSegments)
+	}
+	if aPath, bPath := wildcards(aPattern.pathSegments), wildcards(bPattern.pathSegments); aPath != bPath {
+		return aPath < bPath
+	}
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a < b
+}
+
+// wildcards counts the parts that contain glob wildcards
+func wildcards(parts []string) int {
+	var count int
+	for _, part := range parts {
+		if strings.ContainsAny(part, `*?[`) {
+			count++
+		}
+	}
+	return count
+}
+
+func splitRegistryURL(url string) ([]string, string, []string) {
+	pieces := strings.SplitN(strings.TrimSuffix(url, "/"), "/", 2)
+
+	host, port, err := net.SplitHostPort(pieces[0])
+	if err != nil {
+		host, port = pieces[0], ""
+	}
+
+	var pathSegments []string
+	if len(pieces) == 2 && len(pieces[1]) > 0 {
+		pathSegments = strings.Split(pieces[1], "/")
+	}
+
+	return strings.Split(host, "."), port, pathSegments
+}
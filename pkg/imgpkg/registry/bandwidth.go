@@ -0,0 +1,76 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// maxBandwidthBurst is the largest number of bytes read or written at once when bandwidth is limited
+const maxBandwidthBurst = 256 * 1024
+
+// newBandwidthLimiter returns nil when bytesPerSecond is 0, meaning no limit is applied
+func newBandwidthLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	burst := maxBandwidthBurst
+	if bytesPerSecond < int64(burst) {
+		burst = int(bytesPerSecond)
+	}
+
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// bandwidthLimitedTransport limits the rate at which request and response bodies are transferred
+type bandwidthLimitedTransport struct {
+	transport http.RoundTripper
+	limiter   *rate.Limiter
+}
+
+var _ http.RoundTripper = bandwidthLimitedTransport{}
+
+// RoundTrip executes the request with a limited body and limits the body of the response
+func (t bandwidthLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &bandwidthLimitedReadCloser{req.Body, t.limiter, req.Context()}
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &bandwidthLimitedReadCloser{resp.Body, t.limiter, req.Context()}
+
+	return resp, nil
+}
+
+type bandwidthLimitedReadCloser struct {
+	io.ReadCloser
+	limiter *rate.Limiter
+	ctx     context.Context
+}
+
+// Read waits until the limiter allows the bytes read to be transferred
+func (r *bandwidthLimitedReadCloser) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
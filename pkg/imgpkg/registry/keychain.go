@@ -14,11 +14,22 @@ import (
 // It enforces an order, where the keychains that contain credentials for a specific target take precedence over
 // keychains that contain credentials for 'any' target. i.e. env keychain takes precedence over the custom keychain.
 // Since env keychain contains credentials per HOSTNAME, and custom keychain doesn't.
+// The precedence is: env keychain, auth files keychain, credential provider plugins keychain, IaaS keychain and then the custom keychain.
 func Keychain(keychainOpts auth.KeychainOpts, environFunc func() []string) (regauthn.Keychain, error) {
 	iaasKeychain, err := auth.NewIaasKeychain(context.Background(), environFunc)
 	if err != nil {
 		return nil, err
 	}
 
-	return regauthn.NewMultiKeychain(auth.NewEnvKeychain(environFunc), iaasKeychain, auth.CustomRegistryKeychain{Opts: keychainOpts}), nil
+	authFileKeychain, err := auth.NewAuthFileKeychain(keychainOpts.AuthFilePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	execKeychain, err := auth.NewExecKeychain(keychainOpts.CredentialProviderConfigPath, keychainOpts.CredentialProviderBinDir, keychainOpts.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return regauthn.NewMultiKeychain(auth.NewEnvKeychain(environFunc), authFileKeychain, execKeychain, iaasKeychain, auth.CustomRegistryKeychain{Opts: keychainOpts}), nil
 }
@@ -400,8 +400,101 @@ func TestAuthProvidedViaEnvVars(t *testing.T) {
 			},
 			"user", "pass",
 		},
+		{
+			[]string{
+				"IMGPKG_REGISTRY_USERNAME_0=user-not-chosen",
+				"IMGPKG_REGISTRY_PASSWORD_0=pass-not-chosen",
+				"IMGPKG_REGISTRY_HOSTNAME_0=localhost:9999/imgpkg_*",
+				"IMGPKG_REGISTRY_USERNAME_1=user",
+				"IMGPKG_REGISTRY_PASSWORD_1=pass",
+				"IMGPKG_REGISTRY_HOSTNAME_1=localhost:9999/imgpkg_test",
+			},
+			"user", "pass",
+		},
+		{
+			[]string{
+				"IMGPKG_REGISTRY_USERNAME_0=user-not-chosen",
+				"IMGPKG_REGISTRY_PASSWORD_0=pass-not-chosen",
+				"IMGPKG_REGISTRY_HOSTNAME_0=localhost:9999/imgpkg_test",
+				"IMGPKG_REGISTRY_USERNAME_1=user",
+				"IMGPKG_REGISTRY_PASSWORD_1=pass",
+				"IMGPKG_REGISTRY_HOSTNAME_1=localhost:9999/imgpkg_*/image*",
+			},
+			"user", "pass",
+		},
+		{
+			[]string{
+				"IMGPKG_REGISTRY_USERNAME_0=user",
+				"IMGPKG_REGISTRY_PASSWORD_0=pass",
+				"IMGPKG_REGISTRY_HOSTNAME_0=localhost:9999",
+				"IMGPKG_REGISTRY_USERNAME_1=user-not-chosen",
+				"IMGPKG_REGISTRY_PASSWORD_1=pass-not-chosen",
+				"IMGPKG_REGISTRY_HOSTNAME_1=localhost:9999",
+			},
+			"user", "pass",
+		},
+	}
+
+	testCasesWithGlobs := []struct {
+		hostname string
+		image    string
+		matches  bool
+	}{
+		{"*.ecr.us-east-1.amazonaws.com", "123456789.dkr.ecr.us-east-1.amazonaws.com/app", false},
+		{"*.dkr.ecr.us-east-1.amazonaws.com", "123456789.dkr.ecr.us-east-1.amazonaws.com/app", true},
+		{"*.*.ecr.*.amazonaws.com", "123456789.dkr.ecr.eu-west-1.amazonaws.com/app", true},
+		{"registry.corp/team-*", "registry.corp/team-a/app", true},
+		{"registry.corp/team-*", "registry.corp/other-team/app", false},
+		{"registry.corp/team", "registry.corp/team-a/app", false},
+		{"registry.corp/team/app", "registry.corp/team/app", true},
+		{"registry.corp/team/app/more", "registry.corp/team/app", false},
+		{"registry.corp:5000", "registry.corp/app", false},
+		{"registry.corp:5000", "registry.corp:5000/app", true},
 	}
 
+	for _, tc := range testCasesWithGlobs {
+		t.Run(fmt.Sprintf("HOSTNAME %s matching %s is %t", tc.hostname, tc.image, tc.matches), func(t *testing.T) {
+			envVars := []string{
+				"IMGPKG_REGISTRY_USERNAME=user",
+				"IMGPKG_REGISTRY_PASSWORD=pass",
+				"IMGPKG_REGISTRY_HOSTNAME=" + tc.hostname,
+			}
+
+			keychain, err := registry.Keychain(auth.KeychainOpts{}, func() []string { return envVars })
+			require.NoError(t, err)
+
+			resource, err := name.NewRepository(tc.image)
+			require.NoError(t, err)
+
+			auth, err := keychain.Resolve(resource)
+			require.NoError(t, err)
+
+			if tc.matches {
+				assert.Equal(t, authn.FromConfig(authn.AuthConfig{Username: "user", Password: "pass"}), auth)
+			} else {
+				assert.Equal(t, authn.Anonymous, auth)
+			}
+		})
+	}
+
+	t.Run("When HOSTNAME has an invalid glob it errors", func(t *testing.T) {
+		envVars := []string{
+			"IMGPKG_REGISTRY_USERNAME=user",
+			"IMGPKG_REGISTRY_PASSWORD=pass",
+			"IMGPKG_REGISTRY_HOSTNAME=registry.corp/team-[",
+		}
+
+		keychain, err := registry.Keychain(auth.KeychainOpts{}, func() []string { return envVars })
+		require.NoError(t, err)
+
+		resource, err := name.NewRepository("registry.corp/team-a/app")
+		require.NoError(t, err)
+
+		_, err = keychain.Resolve(resource)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Parsing registry hostname 'registry.corp/team-['")
+	})
+
 	for i, tc := range testCasesSpecifyingOrder {
 		t.Run(fmt.Sprintf("ensure more specific HOSTNAME is used: %d", i), func(t *testing.T) {
 			keychain, err := registry.Keychain(auth.KeychainOpts{}, func() []string { return tc.envs })
@@ -454,6 +547,153 @@ func TestAuthProvidedViaDefaultKeychain(t *testing.T) {
 	})
 }
 
+func TestAuthProvidedViaAuthFiles(t *testing.T) {
+	writeAuthFile := func(t *testing.T, contents string) string {
+		path := filepath.Join(t.TempDir(), "auth-file")
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+		return path
+	}
+	resolve := func(t *testing.T, keychain authn.Keychain, repo string) authn.Authenticator {
+		resource, err := name.NewRepository(repo)
+		require.NoError(t, err)
+		auth, err := keychain.Resolve(resource)
+		require.NoError(t, err)
+		return auth
+	}
+	encodedAuth := base64.StdEncoding.EncodeToString([]byte("user-secret:pass-secret"))
+	dockerConfigJSON := fmt.Sprintf(`{"auths":{"https://localhost:9999/v1/":{"auth":%q}}}`, encodedAuth)
+
+	t.Run("When auth is provided via a docker config.json", func(t *testing.T) {
+		path := writeAuthFile(t, `{
+  "auths" : {
+    "localhost:9999" : {
+      "username": "user-file",
+      "password": "pass-file"
+    },
+    "localhost:9999/specific" : {
+      "identitytoken": "ID_TOKEN"
+    }
+  }
+}`)
+
+		keychain, err := registry.Keychain(auth.KeychainOpts{AuthFilePaths: []string{path}}, func() []string { return nil })
+		require.NoError(t, err)
+
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			Username: "user-file",
+			Password: "pass-file",
+		}), resolve(t, keychain, "localhost:9999/imgpkg_test"))
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			IdentityToken: "ID_TOKEN",
+		}), resolve(t, keychain, "localhost:9999/specific/imgpkg_test"))
+	})
+
+	t.Run("When auth is provided via a Kubernetes Secret", func(t *testing.T) {
+		path := writeAuthFile(t, fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: pull-secret
+type: kubernetes.io/dockerconfigjson
+data:
+  .dockerconfigjson: %s
+`, base64.StdEncoding.EncodeToString([]byte(dockerConfigJSON))))
+
+		keychain, err := registry.Keychain(auth.KeychainOpts{AuthFilePaths: []string{path}}, func() []string { return nil })
+		require.NoError(t, err)
+
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			Username: "user-secret",
+			Password: "pass-secret",
+		}), resolve(t, keychain, "localhost:9999/imgpkg_test"))
+	})
+
+	t.Run("When auth is provided via a Kubernetes Secret with stringData", func(t *testing.T) {
+		path := writeAuthFile(t, fmt.Sprintf(`apiVersion: v1
+kind: Secret
+type: kubernetes.io/dockerconfigjson
+stringData:
+  .dockerconfigjson: '%s'
+`, dockerConfigJSON))
+
+		keychain, err := registry.Keychain(auth.KeychainOpts{AuthFilePaths: []string{path}}, func() []string { return nil })
+		require.NoError(t, err)
+
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			Username: "user-secret",
+			Password: "pass-secret",
+		}), resolve(t, keychain, "localhost:9999/imgpkg_test"))
+	})
+
+	t.Run("When multiple files have credentials for the same registry, the first file is used", func(t *testing.T) {
+		firstPath := writeAuthFile(t, `{"auths":{"localhost:9999":{"username":"user-first","password":"pass-first"}}}`)
+		secondPath := writeAuthFile(t, `{"auths":{"localhost:9999":{"username":"user-second","password":"pass-second"},"localhost:1111":{"username":"user-other","password":"pass-other"}}}`)
+
+		keychain, err := registry.Keychain(auth.KeychainOpts{AuthFilePaths: []string{firstPath, secondPath}}, func() []string { return nil })
+		require.NoError(t, err)
+
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			Username: "user-first",
+			Password: "pass-first",
+		}), resolve(t, keychain, "localhost:9999/imgpkg_test"))
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			Username: "user-other",
+			Password: "pass-other",
+		}), resolve(t, keychain, "localhost:1111/imgpkg_test"))
+	})
+
+	t.Run("When env variables provide credentials for the same registry, the env variables are used", func(t *testing.T) {
+		path := writeAuthFile(t, `{"auths":{"localhost:9999":{"username":"user-file","password":"pass-file"}}}`)
+		envVars := []string{
+			"IMGPKG_REGISTRY_USERNAME=user-env",
+			"IMGPKG_REGISTRY_PASSWORD=pass-env",
+			"IMGPKG_REGISTRY_HOSTNAME=localhost:9999",
+		}
+
+		keychain, err := registry.Keychain(auth.KeychainOpts{AuthFilePaths: []string{path}}, func() []string { return envVars })
+		require.NoError(t, err)
+
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			Username: "user-env",
+			Password: "pass-env",
+		}), resolve(t, keychain, "localhost:9999/imgpkg_test"))
+	})
+
+	t.Run("When auth file provides credentials, they are used instead of the ones provided via flags", func(t *testing.T) {
+		path := writeAuthFile(t, `{"auths":{"localhost:9999":{"username":"user-file","password":"pass-file"}}}`)
+
+		keychain, err := registry.Keychain(auth.KeychainOpts{Username: "user-flag", Password: "pass-flag", AuthFilePaths: []string{path}}, func() []string { return nil })
+		require.NoError(t, err)
+
+		assert.Equal(t, authn.FromConfig(authn.AuthConfig{
+			Username: "user-file",
+			Password: "pass-file",
+		}), resolve(t, keychain, "localhost:9999/imgpkg_test"))
+		assert.Equal(t, &authn.Basic{
+			Username: "user-flag",
+			Password: "pass-flag",
+		}, resolve(t, keychain, "localhost:1111/imgpkg_test"))
+	})
+
+	t.Run("When the Secret is not an image pull secret, it errors", func(t *testing.T) {
+		path := writeAuthFile(t, `apiVersion: v1
+kind: Secret
+type: Opaque
+data:
+  password: cGFzcw==
+`)
+
+		_, err := registry.Keychain(auth.KeychainOpts{AuthFilePaths: []string{path}}, func() []string { return nil })
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected Secret to be of type kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg, got 'Opaque'")
+	})
+
+	t.Run("When the file does not exist, it errors", func(t *testing.T) {
+		_, err := registry.Keychain(auth.KeychainOpts{AuthFilePaths: []string{"/does/not/exist"}}, func() []string { return nil })
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Reading auth file '/does/not/exist'")
+	})
+}
+
 func TestOrderingOfAuthOpts(t *testing.T) {
 	t.Run("When no auth are provided, use anon", func(t *testing.T) {
 		cliOptions := auth.KeychainOpts{}
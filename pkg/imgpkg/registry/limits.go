@@ -0,0 +1,99 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// transportLimits Limits shared by every transport created for a registry,
+// including the transports of its mirrors and of the hosts with their own certificates
+type transportLimits struct {
+	bandwidth *rate.Limiter
+	hosts     *hostConcurrencyLimiter
+}
+
+func newTransportLimits(opts Opts) transportLimits {
+	return transportLimits{
+		bandwidth: newBandwidthLimiter(opts.MaxBandwidth),
+		hosts:     newHostConcurrencyLimiter(opts.MaxConcurrencyPerHost),
+	}
+}
+
+// wrap returns a transport that applies the limits to the requests sent through the provided transport
+func (l transportLimits) wrap(transport http.RoundTripper) http.RoundTripper {
+	if l.bandwidth != nil {
+		transport = bandwidthLimitedTransport{transport, l.bandwidth}
+	}
+	if l.hosts != nil {
+		transport = hostConcurrencyLimitedTransport{transport, l.hosts}
+	}
+	return transport
+}
+
+// hostConcurrencyLimiter keeps track of the requests in flight to each registry host
+type hostConcurrencyLimiter struct {
+	maxPerHost int
+	slots      map[string]chan struct{}
+	lock       sync.Mutex
+}
+
+// newHostConcurrencyLimiter returns nil when maxPerHost is 0, meaning no limit is applied
+func newHostConcurrencyLimiter(maxPerHost int) *hostConcurrencyLimiter {
+	if maxPerHost <= 0 {
+		return nil
+	}
+	return &hostConcurrencyLimiter{maxPerHost: maxPerHost, slots: map[string]chan struct{}{}}
+}
+
+func (l *hostConcurrencyLimiter) hostSlots(host string) chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	slots, found := l.slots[host]
+	if !found {
+		slots = make(chan struct{}, l.maxPerHost)
+		l.slots[host] = slots
+	}
+	return slots
+}
+
+// hostConcurrencyLimitedTransport waits for a free slot of the request host before sending the request.
+// The slot is released once the response headers arrive, so that a response body kept open while the
+// same host is written to, for example when copying a layer between repositories of the same registry,
+// does not prevent the write from getting a slot
+type hostConcurrencyLimitedTransport struct {
+	transport http.RoundTripper
+	limiter   *hostConcurrencyLimiter
+}
+
+var _ http.RoundTripper = hostConcurrencyLimitedTransport{}
+
+// RoundTrip executes the request once there is a free slot for its host
+func (t hostConcurrencyLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	slots := t.limiter.hostSlots(withoutDefaultPort(req.URL.Scheme, req.URL.Host))
+
+	select {
+	case slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-slots }()
+
+	return t.transport.RoundTrip(req)
+}
+
+// withoutDefaultPort removes the port from the host when it is the default port of the scheme,
+// so that registry.corp.com:443 and registry.corp.com are considered the same host
+func withoutDefaultPort(scheme, host string) string {
+	defaultPort := ":443"
+	if scheme == "http" {
+		defaultPort = ":80"
+	}
+	return strings.TrimSuffix(host, defaultPort)
+}
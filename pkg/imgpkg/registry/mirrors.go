@@ -0,0 +1,197 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	regauthn "github.com/google/go-containerregistry/pkg/authn"
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry/auth"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	MirrorsConfigKind       = "RegistryMirrorsConfig"
+	MirrorsConfigAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+)
+
+// MirrorsConfig Configuration of the mirrors used when reading images from a registry
+type MirrorsConfig struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Registries []RegistryMirrors `json:"registries"`
+}
+
+// RegistryMirrors Mirrors of a registry, tried in order before falling back to the registry itself
+type RegistryMirrors struct {
+	Registry string   `json:"registry"`
+	Mirrors  []Mirror `json:"mirrors"`
+}
+
+// Mirror Location of a mirror (example: mirror.corp.com/dockerhub) and the settings used to connect to it
+type Mirror struct {
+	Endpoint string `json:"endpoint"`
+
+	CACertPaths []string `json:"caCertPaths,omitempty"`
+	SkipVerify  bool     `json:"skipVerify,omitempty"`
+	Insecure    bool     `json:"insecure,omitempty"`
+
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Anon     bool   `json:"anon,omitempty"`
+}
+
+// NewMirrorsConfigFromPath Reads the mirrors configuration from a file
+func NewMirrorsConfigFromPath(path string) (MirrorsConfig, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return MirrorsConfig{}, fmt.Errorf("Reading path %s: %s", path, err)
+	}
+
+	return NewMirrorsConfigFromBytes(bs)
+}
+
+// NewMirrorsConfigFromBytes Parses and validates the mirrors configuration
+func NewMirrorsConfigFromBytes(data []byte) (MirrorsConfig, error) {
+	var config MirrorsConfig
+
+	err := yaml.UnmarshalStrict(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("Unmarshaling registry mirrors config: %s", err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return config, fmt.Errorf("Validating registry mirrors config: %s", err)
+	}
+
+	return config, nil
+}
+
+// Validate Checks that every registry and mirror endpoint is valid
+func (c MirrorsConfig) Validate() error {
+	if c.APIVersion != MirrorsConfigAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", MirrorsConfigAPIVersion)
+	}
+	if c.Kind != MirrorsConfigKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", MirrorsConfigKind)
+	}
+
+	for _, registry := range c.Registries {
+		if _, err := regname.NewRegistry(registry.Registry, regname.StrictValidation); err != nil {
+			return fmt.Errorf("Expected registry to be a hostname, got '%s'", registry.Registry)
+		}
+		for _, mirror := range registry.Mirrors {
+			if match := protocolMatcher.FindString(mirror.Endpoint); len(match) > 0 {
+				return fmt.Errorf("Mirror endpoint '%s' should not include %s protocol prefix", mirror.Endpoint, match)
+			}
+			if _, err := mirror.endpointRepository("mirror"); err != nil {
+				return fmt.Errorf("Expected mirror endpoint to be a hostname with an optional path, got '%s': %s", mirror.Endpoint, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// registryOpts returns the options used to connect to the mirror
+func (m Mirror) registryOpts(opts Opts) Opts {
+	opts.CACertPaths = append(append([]string{}, opts.CACertPaths...), m.CACertPaths...)
+	opts.VerifyCerts = opts.VerifyCerts && !m.SkipVerify
+	opts.Insecure = opts.Insecure || m.Insecure
+	opts.MirrorsConfigPath = ""
+	return opts
+}
+
+// keychain returns the keychain to use with the mirror,
+// when no credentials are configured for the mirror the provided keychain is used
+func (m Mirror) keychain(defaultKeychain regauthn.Keychain) regauthn.Keychain {
+	switch {
+	case len(m.Username) > 0:
+		return auth.NewSingleAuthKeychain(&regauthn.Basic{Username: m.Username, Password: m.Password})
+	case len(m.Token) > 0:
+		return auth.NewSingleAuthKeychain(&regauthn.Bearer{Token: m.Token})
+	case m.Anon:
+		return auth.NewSingleAuthKeychain(regauthn.Anonymous)
+	default:
+		return defaultKeychain
+	}
+}
+
+// endpointRepository returns the repository in the mirror where the provided repository is stored
+func (m Mirror) endpointRepository(repository string, opts ...regname.Option) (regname.Repository, error) {
+	endpoint := strings.TrimSuffix(m.Endpoint, "/")
+	host := strings.SplitN(endpoint, "/", 2)[0]
+	if _, err := regname.NewRegistry(host, append(opts, regname.StrictValidation)...); err != nil {
+		return regname.Repository{}, err
+	}
+	return regname.NewRepository(endpoint+"/"+repository, opts...)
+}
+
+// mirroredRegistry is a registry used to read images in place of another registry
+type mirroredRegistry struct {
+	registry string
+	mirror   Mirror
+	reg      SimpleRegistry
+}
+
+// rewrite returns the reference of the image in the mirror
+func (m mirroredRegistry) rewrite(ref regname.Reference) (regname.Reference, bool, error) {
+	if ref.Context().RegistryStr() != m.registry {
+		return nil, false, nil
+	}
+
+	mirrorRepo, err := m.mirror.endpointRepository(ref.Context().RepositoryStr(), m.reg.refOpts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch typedRef := ref.(type) {
+	case regname.Digest:
+		return mirrorRepo.Digest(typedRef.DigestStr()), true, nil
+	case regname.Tag:
+		return mirrorRepo.Tag(typedRef.TagStr()), true, nil
+	default:
+		panic(fmt.Sprintf("Unknown reference type %T", ref))
+	}
+}
+
+func newMirroredRegistries(opts Opts, keychain regauthn.Keychain, limits transportLimits, regOpts []regremote.Option) ([]mirroredRegistry, error) {
+	if len(opts.MirrorsConfigPath) == 0 {
+		return nil, nil
+	}
+
+	config, err := NewMirrorsConfigFromPath(opts.MirrorsConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var mirrors []mirroredRegistry
+	for _, registry := range config.Registries {
+		parsedRegistry, err := regname.NewRegistry(registry.Registry)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mirror := range registry.Mirrors {
+			reg, err := newSimpleRegistry(mirror.registryOpts(opts), mirror.keychain(keychain), limits, regOpts)
+			if err != nil {
+				return nil, fmt.Errorf("Creating registry for mirror '%s': %s", mirror.Endpoint, err)
+			}
+
+			mirrors = append(mirrors, mirroredRegistry{
+				registry: parsedRegistry.RegistryStr(),
+				mirror:   mirror,
+				reg:      *reg,
+			})
+		}
+	}
+
+	return mirrors, nil
+}
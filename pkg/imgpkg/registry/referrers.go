@@ -0,0 +1,176 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	regtran "github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+const (
+	ociImageIndexMediaType = "application/vnd.oci.image.index.v1+json"
+	// filtersAppliedHeader is returned by the referrers API with the filters applied by the registry
+	filtersAppliedHeader = "OCI-Filters-Applied"
+)
+
+// Referrer Descriptor of a manifest that refers to another manifest through its subject field
+type Referrer struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Referrers Manifests that refer to a manifest
+type Referrers struct {
+	Manifests []Referrer
+
+	// TagSchema is true when the registry does not support the referrers API and the referrers
+	// are listed in an index tagged with the digest of the manifest (example: sha256-<hex>) instead
+	TagSchema bool
+	// FallbackTag and FallbackDigest identify the index of the tag schema, when it exists
+	FallbackTag    string
+	FallbackDigest string
+}
+
+type referrersIndex struct {
+	Manifests []Referrer `json:"manifests"`
+}
+
+// Referrers Retrieve the manifests that refer to the provided manifest through their subject field,
+// only returning the ones with the provided artifactType when it is not empty
+// When the registry does not support the referrers API the tag schema fallback is used
+func (r SimpleRegistry) Referrers(ref regname.Digest, artifactType string) (Referrers, error) {
+	var referrers Referrers
+	err := r.readWithMirrors(ref, func(reg SimpleRegistry, ref regname.Reference) error {
+		var err error
+		referrers, err = reg.referrers(ref, artifactType)
+		return err
+	})
+	return referrers, err
+}
+
+func (r SimpleRegistry) referrers(ref regname.Reference, artifactType string) (Referrers, error) {
+	if err := r.validateRef(ref); err != nil {
+		return Referrers{}, err
+	}
+	overriddenRef, err := regname.NewDigest(ref.String(), r.refOpts...)
+	if err != nil {
+		return Referrers{}, err
+	}
+	repo := overriddenRef.Context()
+
+	imgAuth, err := r.keychain.Resolve(repo)
+	if err != nil {
+		return Referrers{}, err
+	}
+	httpTran, err := regtran.New(repo.Registry, imgAuth, r.transport, []string{repo.Scope(regtran.PullScope)})
+	if err != nil {
+		return Referrers{}, err
+	}
+
+	referrersURL := url.URL{
+		Scheme: repo.Registry.Scheme(),
+		Host:   repo.RegistryStr(),
+		Path:   fmt.Sprintf("/v2/%s/referrers/%s", repo.RepositoryStr(), overriddenRef.DigestStr()),
+	}
+	if len(artifactType) > 0 {
+		referrersURL.RawQuery = url.Values{"artifactType": []string{artifactType}}.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, referrersURL.String(), nil)
+	if err != nil {
+		return Referrers{}, err
+	}
+	req.Header.Set("Accept", ociImageIndexMediaType)
+
+	resp, err := (&http.Client{Transport: httpTran}).Do(req)
+	if err != nil {
+		return Referrers{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return r.fallbackReferrers(overriddenRef, artifactType)
+	}
+	if err := regtran.CheckError(resp, http.StatusOK); err != nil {
+		return Referrers{}, err
+	}
+
+	var index referrersIndex
+	err = json.NewDecoder(resp.Body).Decode(&index)
+	if err != nil {
+		return Referrers{}, fmt.Errorf("Unmarshaling referrers of '%s': %s", ref, err)
+	}
+
+	// Registries are allowed to ignore the artifactType filter, in which case it is applied here
+	if !filterApplied(resp.Header, "artifactType") {
+		index.Manifests = filterReferrers(index.Manifests, artifactType)
+	}
+
+	return Referrers{Manifests: index.Manifests}, nil
+}
+
+// fallbackReferrers reads the referrers from the index tagged with the digest of the manifest
+// (example: sha256-<hex>), as described by the referrers tag schema
+func (r SimpleRegistry) fallbackReferrers(ref regname.Digest, artifactType string) (Referrers, error) {
+	hash, err := regv1.NewHash(ref.DigestStr())
+	if err != nil {
+		return Referrers{}, fmt.Errorf("Converting to hash: %s", err)
+	}
+	tag := ref.Context().Tag(hash.Algorithm + "-" + hash.Hex)
+
+	desc, err := regremote.Get(tag, r.opts()...)
+	if err != nil {
+		if transportErr, ok := err.(*regtran.Error); ok && transportErr.StatusCode == http.StatusNotFound {
+			return Referrers{TagSchema: true}, nil
+		}
+		return Referrers{}, err
+	}
+
+	var index referrersIndex
+	err = json.Unmarshal(desc.Manifest, &index)
+	if err != nil {
+		return Referrers{}, fmt.Errorf("Unmarshaling referrers index '%s': %s", tag, err)
+	}
+
+	return Referrers{
+		Manifests:      filterReferrers(index.Manifests, artifactType),
+		TagSchema:      true,
+		FallbackTag:    tag.TagStr(),
+		FallbackDigest: desc.Digest.String(),
+	}, nil
+}
+
+func filterApplied(header http.Header, filter string) bool {
+	for _, applied := range strings.Split(header.Get(filtersAppliedHeader), ",") {
+		if strings.TrimSpace(applied) == filter {
+			return true
+		}
+	}
+	return false
+}
+
+func filterReferrers(referrers []Referrer, artifactType string) []Referrer {
+	if len(artifactType) == 0 {
+		return referrers
+	}
+
+	var filtered []Referrer
+	for _, referrer := range referrers {
+		if referrer.ArtifactType == artifactType {
+			filtered = append(filtered, referrer)
+		}
+	}
+	return filtered
+}
@@ -5,12 +5,9 @@ package registry
 
 import (
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"regexp"
-	"runtime"
 	"time"
 
 	regauthn "github.com/google/go-containerregistry/pkg/authn"
@@ -18,6 +15,7 @@ import (
 	regv1 "github.com/google/go-containerregistry/pkg/v1"
 	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry/auth"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
 
 type Opts struct {
@@ -25,6 +23,12 @@ type Opts struct {
 	VerifyCerts bool
 	Insecure    bool
 
+	// ClientCertPath and ClientKeyPath are the client certificate and key presented to all registries
+	ClientCertPath string
+	ClientKeyPath  string
+	// CertsDir contains certificates for specific registry hosts, using the same layout as docker's certs.d
+	CertsDir string
+
 	IncludeNonDistributableLayers bool
 
 	Username string
@@ -32,12 +36,55 @@ type Opts struct {
 	Token    string
 	Anon     bool
 
+	// AuthFilePaths are docker config.json files or Kubernetes image pull Secrets with registry credentials
+	AuthFilePaths []string
+	// CredentialProviderConfigPath points to a kubelet CredentialProviderConfig with credential provider plugins
+	CredentialProviderConfigPath string
+	CredentialProviderBinDir     string
+
 	ResponseHeaderTimeout time.Duration
-	RetryCount            int
+	// RetryPolicy configures how failed requests are retried, the zero value uses util.DefaultRetryPolicy
+	RetryPolicy util.RetryPolicy
+	// Deprecated: RetryCount is the number of attempts made for each request, use RetryPolicy.MaxAttempts instead.
+	// When set, it overrides RetryPolicy.MaxAttempts
+	RetryCount int
+
+	// MaxBandwidth limits the bytes per second sent and received from all registries, 0 means unlimited
+	MaxBandwidth int64
+	// MaxConcurrencyPerHost limits the number of requests in flight to each registry host, 0 means unlimited
+	MaxConcurrencyPerHost int
+
+	// MirrorsConfigPath points to a MirrorsConfig file used to read images from mirrors
+	MirrorsConfigPath string
+
+	// Logger receives the warnings of the operations that recover from an error, warnings are discarded when it is not set
+	Logger Logger
 
 	EnvironFunc func() []string
 }
 
+// Logger Reports the errors the registry recovers from
+type Logger interface {
+	Warnf(msg string, args ...interface{})
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Warnf(string, ...interface{}) {}
+
+// retryPolicy returns the RetryPolicy, using the defaults when it is not set and applying the deprecated RetryCount
+func (o Opts) retryPolicy() util.RetryPolicy {
+	retryPolicy := o.RetryPolicy.OrDefault()
+	if o.RetryCount > 0 {
+		retryPolicy.MaxAttempts = o.RetryCount
+	}
+	// Requests need to be attempted at least once, otherwise uploads are skipped without an error
+	if retryPolicy.MaxAttempts < 1 {
+		retryPolicy.MaxAttempts = util.DefaultRetryPolicy().MaxAttempts
+	}
+	return retryPolicy
+}
+
 // Registry Interface to access the registry
 type Registry interface {
 	Get(reference regname.Reference) (*regremote.Descriptor, error)
@@ -52,6 +99,7 @@ type Registry interface {
 	WriteTag(tag regname.Tag, taggable regremote.Taggable) error
 
 	ListTags(repo regname.Repository) ([]string, error)
+	Referrers(reference regname.Digest, artifactType string) (Referrers, error)
 
 	CloneWithSingleAuth(imageRef regname.Tag) (Registry, error)
 }
@@ -85,18 +133,20 @@ type SimpleRegistry struct {
 	remoteOpts []regremote.Option
 	refOpts    []regname.Option
 	keychain   regauthn.Keychain
+	// transport is used for the requests that go-containerregistry does not support, like the referrers API
+	transport http.RoundTripper
+
+	// mirrors are tried in order when reading images, before the registry of the image
+	mirrors []mirroredRegistry
+	// logger receives the errors of the mirrors that are skipped
+	logger Logger
 }
 
 // NewSimpleRegistry Builder for a Simple Registry
 func NewSimpleRegistry(opts Opts, regOpts ...regremote.Option) (*SimpleRegistry, error) {
-	httpTran, err := newHTTPTransport(opts)
-	if err != nil {
-		return nil, fmt.Errorf("Creating registry HTTP transport: %s", err)
-	}
-
-	var refOpts []regname.Option
-	if opts.Insecure {
-		refOpts = append(refOpts, regname.Insecure)
+	opts.RetryPolicy = opts.retryPolicy()
+	if opts.Logger == nil {
+		opts.Logger = noopLogger{}
 	}
 
 	keychain, err := Keychain(
@@ -105,6 +155,14 @@ func NewSimpleRegistry(opts Opts, regOpts ...regremote.Option) (*SimpleRegistry,
 			Password: opts.Password,
 			Token:    opts.Token,
 			Anon:     opts.Anon,
+
+			AuthFilePaths: opts.AuthFilePaths,
+
+			CredentialProviderConfigPath: opts.CredentialProviderConfigPath,
+			CredentialProviderBinDir:     opts.CredentialProviderBinDir,
+
+			RetryPolicy: opts.RetryPolicy,
+			Logger:      opts.Logger,
 		},
 		opts.EnvironFunc,
 	)
@@ -112,6 +170,32 @@ func NewSimpleRegistry(opts Opts, regOpts ...regremote.Option) (*SimpleRegistry,
 		return nil, fmt.Errorf("Creating registry keychain: %s", err)
 	}
 
+	limits := newTransportLimits(opts)
+
+	reg, err := newSimpleRegistry(opts, keychain, limits, regOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	reg.mirrors, err = newMirroredRegistries(opts, keychain, limits, regOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return reg, nil
+}
+
+func newSimpleRegistry(opts Opts, keychain regauthn.Keychain, limits transportLimits, regOpts []regremote.Option) (*SimpleRegistry, error) {
+	httpTran, err := newHTTPTransport(opts, limits)
+	if err != nil {
+		return nil, fmt.Errorf("Creating registry HTTP transport: %s", err)
+	}
+
+	var refOpts []regname.Option
+	if opts.Insecure {
+		refOpts = append(refOpts, regname.Insecure)
+	}
+
 	regRemoteOptions := []regremote.Option{
 		regremote.WithTransport(httpTran),
 	}
@@ -122,18 +206,21 @@ func NewSimpleRegistry(opts Opts, regOpts ...regremote.Option) (*SimpleRegistry,
 		regRemoteOptions = append(regRemoteOptions, regOpts...)
 	}
 
+	retryPolicy := opts.retryPolicy()
 	regRemoteOptions = append(regRemoteOptions, regremote.WithRetryBackoff(regremote.Backoff{
-		Duration: 100 * time.Millisecond,
-		Factor:   2,
-		Jitter:   0,
-		Steps:    opts.RetryCount,
-		Cap:      1 * time.Second,
-	}))
+		Duration: retryPolicy.InitialInterval,
+		Factor:   retryPolicy.Multiplier,
+		Jitter:   retryPolicy.Jitter,
+		Steps:    retryPolicy.MaxAttempts,
+		Cap:      retryPolicy.MaxInterval,
+	}), regremote.WithRetryPredicate(retryPredicate))
 
 	return &SimpleRegistry{
 		remoteOpts: regRemoteOptions,
 		refOpts:    refOpts,
 		keychain:   keychain,
+		transport:  httpTran,
+		logger:     opts.Logger,
 	}, nil
 }
 
@@ -152,6 +239,9 @@ func (r SimpleRegistry) CloneWithSingleAuth(imageRef regname.Tag) (Registry, err
 		remoteOpts: r.remoteOpts,
 		refOpts:    r.refOpts,
 		keychain:   keychain,
+		transport:  r.transport,
+		mirrors:    r.mirrors,
+		logger:     r.logger,
 	}, nil
 }
 
@@ -162,6 +252,16 @@ func (r SimpleRegistry) opts() []regremote.Option {
 
 // Get Retrieve Image descriptor for an Image reference
 func (r SimpleRegistry) Get(ref regname.Reference) (*regremote.Descriptor, error) {
+	var desc *regremote.Descriptor
+	err := r.readWithMirrors(ref, func(reg SimpleRegistry, ref regname.Reference) error {
+		var err error
+		desc, err = reg.get(ref)
+		return err
+	})
+	return desc, err
+}
+
+func (r SimpleRegistry) get(ref regname.Reference) (*regremote.Descriptor, error) {
 	if err := r.validateRef(ref); err != nil {
 		return nil, err
 	}
@@ -174,6 +274,16 @@ func (r SimpleRegistry) Get(ref regname.Reference) (*regremote.Descriptor, error
 
 // Digest Retrieve the Digest for an Image reference
 func (r SimpleRegistry) Digest(ref regname.Reference) (regv1.Hash, error) {
+	var digest regv1.Hash
+	err := r.readWithMirrors(ref, func(reg SimpleRegistry, ref regname.Reference) error {
+		var err error
+		digest, err = reg.digest(ref)
+		return err
+	})
+	return digest, err
+}
+
+func (r SimpleRegistry) digest(ref regname.Reference) (regv1.Hash, error) {
 	if err := r.validateRef(ref); err != nil {
 		return regv1.Hash{}, err
 	}
@@ -195,6 +305,16 @@ func (r SimpleRegistry) Digest(ref regname.Reference) (regv1.Hash, error) {
 
 // Image Retrieve the regv1.Image struct for an Image reference
 func (r SimpleRegistry) Image(ref regname.Reference) (regv1.Image, error) {
+	var img regv1.Image
+	err := r.readWithMirrors(ref, func(reg SimpleRegistry, ref regname.Reference) error {
+		var err error
+		img, err = reg.image(ref)
+		return err
+	})
+	return img, err
+}
+
+func (r SimpleRegistry) image(ref regname.Reference) (regv1.Image, error) {
 	if err := r.validateRef(ref); err != nil {
 		return nil, err
 	}
@@ -246,6 +366,16 @@ func (r SimpleRegistry) WriteImage(ref regname.Reference, img regv1.Image) error
 
 // Index Retrieve regv1.ImageIndex struct for an Index reference
 func (r SimpleRegistry) Index(ref regname.Reference) (regv1.ImageIndex, error) {
+	var idx regv1.ImageIndex
+	err := r.readWithMirrors(ref, func(reg SimpleRegistry, ref regname.Reference) error {
+		var err error
+		idx, err = reg.index(ref)
+		return err
+	})
+	return idx, err
+}
+
+func (r SimpleRegistry) index(ref regname.Reference) (regv1.ImageIndex, error) {
 	if err := r.validateRef(ref); err != nil {
 		return nil, err
 	}
@@ -317,43 +447,55 @@ func (r SimpleRegistry) FirstImageExists(digests []string) (string, error) {
 	return "", fmt.Errorf("Checking image existence: %s", err)
 }
 
-func newHTTPTransport(opts Opts) (*http.Transport, error) {
-	var pool *x509.CertPool
-
-	// workaround for windows not returning system certs via x509.SystemCertPool() See: https://github.com/golang/go/issues/16736
-	// instead windows lazily fetches ca certificates (over the network) as needed during cert verification time.
-	// to opt-into that tls.Config.RootCAs is set to nil on windows.
-	if runtime.GOOS != "windows" {
-		var err error
-		pool, err = x509.SystemCertPool()
+// readWithMirrors executes readFunc against each mirror of the image registry, in order,
+// falling back to the image registry when the image cannot be read from any mirror.
+// The errors of the mirrors are reported as warnings, so that a misconfigured mirror does not go unnoticed
+func (r SimpleRegistry) readWithMirrors(ref regname.Reference, readFunc func(SimpleRegistry, regname.Reference) error) error {
+	for _, mirror := range r.mirrors {
+		mirrorRef, found, err := mirror.rewrite(ref)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if !found {
+			continue
 		}
+
+		err = readFunc(mirror.reg, mirrorRef)
+		if err == nil {
+			return nil
+		}
+		r.logger.Warnf("Reading '%s' from mirror '%s', falling back to the next mirror or the registry: %s\n", ref, mirror.mirror.Endpoint, err)
 	}
 
-	if runtime.GOOS == "windows" && len(opts.CACertPaths) > 0 {
-		pool = x509.NewCertPool()
+	return readFunc(r, ref)
+}
+
+// newHTTPTransport creates the transport used to reach registries.
+// The limits are shared with every other transport created with them
+func newHTTPTransport(opts Opts, limits transportLimits) (http.RoundTripper, error) {
+	tlsConfig, hostTLSConfigs, err := newTLSConfigs(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(opts.CACertPaths) > 0 {
-		for _, path := range opts.CACertPaths {
-			if certs, err := ioutil.ReadFile(path); err != nil {
-				return nil, fmt.Errorf("Reading CA certificates from '%s': %s", path, err)
-			} else if ok := pool.AppendCertsFromPEM(certs); !ok {
-				return nil, fmt.Errorf("Adding CA certificates from '%s': failed", path)
-			}
-		}
+	newTransport := func(tlsConfig *tls.Config) *http.Transport {
+		clonedDefaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+		clonedDefaultTransport.ForceAttemptHTTP2 = false
+		clonedDefaultTransport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+		clonedDefaultTransport.TLSClientConfig = tlsConfig
+		return clonedDefaultTransport
 	}
 
-	clonedDefaultTransport := http.DefaultTransport.(*http.Transport).Clone()
-	clonedDefaultTransport.ForceAttemptHTTP2 = false
-	clonedDefaultTransport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
-	clonedDefaultTransport.TLSClientConfig = &tls.Config{
-		RootCAs:            pool,
-		InsecureSkipVerify: opts.VerifyCerts == false,
+	var httpTran http.RoundTripper = newTransport(tlsConfig)
+	if len(hostTLSConfigs) > 0 {
+		hostTransports := map[string]http.RoundTripper{}
+		for host, hostTLSConfig := range hostTLSConfigs {
+			hostTransports[host] = newTransport(hostTLSConfig)
+		}
+		httpTran = perHostTransport{httpTran, hostTransports}
 	}
 
-	return clonedDefaultTransport, nil
+	return retryAfterTransport{limits.wrap(httpTran), opts.retryPolicy()}, nil
 }
 
 var protocolMatcher = regexp.MustCompile(`\Ahttps?://`)
@@ -4,18 +4,40 @@
 package registry_test
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestRegistry_Digest(t *testing.T) {
@@ -100,3 +122,647 @@ func TestRegistry_Get(t *testing.T) {
 	})
 
 }
+
+func TestRegistry_Mirrors(t *testing.T) {
+	expectedDigest := "sha256:477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65"
+
+	var upstreamPaths, mirrorPaths []string
+	upstream := createServer(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPaths = append(upstreamPaths, r.URL.Path)
+		w.Header().Set("Docker-Content-Digest", expectedDigest)
+	})
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	mirrorHasImage := true
+	mirror := createServer(func(w http.ResponseWriter, r *http.Request) {
+		mirrorPaths = append(mirrorPaths, r.URL.Path)
+		if !mirrorHasImage {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", expectedDigest)
+	})
+	defer mirror.Close()
+	mirrorURL, err := url.Parse(mirror.URL)
+	require.NoError(t, err)
+
+	mirrorsConfigPath := filepath.Join(t.TempDir(), "mirrors.yml")
+	require.NoError(t, os.WriteFile(mirrorsConfigPath, []byte(fmt.Sprintf(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: RegistryMirrorsConfig
+registries:
+- registry: %s
+  mirrors:
+  - endpoint: %s/upstream-mirror
+    insecure: true
+`, upstreamURL.Host, mirrorURL.Host)), 0600))
+
+	logger := &bufferLogger{}
+	subject, err := registry.NewSimpleRegistry(registry.Opts{Insecure: true, MirrorsConfigPath: mirrorsConfigPath, Logger: logger})
+	require.NoError(t, err)
+
+	imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo@%s", upstreamURL.Host, expectedDigest))
+	require.NoError(t, err)
+
+	t.Run("reads the image from the mirror", func(t *testing.T) {
+		upstreamPaths, mirrorPaths = nil, nil
+		mirrorHasImage = true
+
+		digest, err := subject.Digest(imgRef)
+		require.NoError(t, err)
+		assert.Equal(t, expectedDigest, digest.String())
+		assert.Equal(t, []string{"/v2/upstream-mirror/repo/manifests/" + expectedDigest}, mirrorPaths)
+		assert.Empty(t, upstreamPaths)
+		assert.Empty(t, logger.String())
+	})
+
+	t.Run("falls back to the registry when the image is not in the mirror and warns about it", func(t *testing.T) {
+		upstreamPaths, mirrorPaths = nil, nil
+		mirrorHasImage = false
+		logger.Reset()
+
+		digest, err := subject.Digest(imgRef)
+		require.NoError(t, err)
+		assert.Equal(t, expectedDigest, digest.String())
+		assert.NotEmpty(t, mirrorPaths)
+		assert.Equal(t, []string{"/v2/repo/manifests/" + expectedDigest}, upstreamPaths)
+		assert.Contains(t, logger.String(), fmt.Sprintf("Reading '%s' from mirror '%s/upstream-mirror', falling back to the next mirror or the registry: ", imgRef, mirrorURL.Host))
+	})
+
+	t.Run("does not use the mirror for other registries", func(t *testing.T) {
+		upstreamPaths, mirrorPaths = nil, nil
+		mirrorHasImage = true
+
+		otherRef, err := name.ParseReference(fmt.Sprintf("%s/repo@%s", mirrorURL.Host, expectedDigest))
+		require.NoError(t, err)
+
+		_, err = subject.Digest(otherRef)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/v2/repo/manifests/" + expectedDigest}, mirrorPaths)
+		assert.Empty(t, upstreamPaths)
+	})
+}
+
+func TestNewMirrorsConfigFromBytes(t *testing.T) {
+	t.Run("when the mirror endpoint includes protocol it errors", func(t *testing.T) {
+		_, err := registry.NewMirrorsConfigFromBytes([]byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: RegistryMirrorsConfig
+registries:
+- registry: docker.io
+  mirrors:
+  - endpoint: https://mirror.corp.com
+`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Mirror endpoint 'https://mirror.corp.com' should not include https:// protocol prefix")
+	})
+
+	t.Run("when the kind is unknown it errors", func(t *testing.T) {
+		_, err := registry.NewMirrorsConfigFromBytes([]byte(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: Mirrors
+`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Validating kind: Unknown kind (known: RegistryMirrorsConfig)")
+	})
+}
+
+func TestRegistry_MaxConcurrencyPerHost(t *testing.T) {
+	expectedDigest := "sha256:477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65"
+
+	var inFlightLock sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := createServer(func(w http.ResponseWriter, r *http.Request) {
+		inFlightLock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		inFlightLock.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Docker-Content-Digest", expectedDigest)
+
+		inFlightLock.Lock()
+		inFlight--
+		inFlightLock.Unlock()
+	})
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	subject, err := registry.NewSimpleRegistry(registry.Opts{MaxConcurrencyPerHost: 2})
+	require.NoError(t, err)
+
+	var wg errgroup.Group
+	for i := 0; i < 6; i++ {
+		i := i
+		wg.Go(func() error {
+			imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo%d:latest", u.Host, i))
+			if err != nil {
+				return err
+			}
+			_, err = subject.Digest(imgRef)
+			return err
+		})
+	}
+	require.NoError(t, wg.Wait())
+
+	assert.LessOrEqual(t, maxInFlight, 2)
+}
+
+func TestRegistry_MaxConcurrencyPerHostCopyingWithinTheSameHost(t *testing.T) {
+	registryHandler := ggcrregistry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Blobs are shared between the repositories of the test registry, reporting them as missing
+		// in dst forces every layer to be read from src while it is uploaded to dst
+		if r.Method == http.MethodHead && strings.HasPrefix(r.URL.Path, "/v2/dst/blobs/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		registryHandler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	srcRef, err := name.ParseReference(u.Host + "/src:latest")
+	require.NoError(t, err)
+	dstRef, err := name.ParseReference(u.Host + "/dst:latest")
+	require.NoError(t, err)
+
+	img, err := random.Image(1024, 4)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(srcRef, img))
+
+	subject, err := registry.NewSimpleRegistry(registry.Opts{MaxConcurrencyPerHost: 1})
+	require.NoError(t, err)
+
+	srcImg, err := subject.Image(srcRef)
+	require.NoError(t, err)
+	srcLayers, err := srcImg.Layers()
+	require.NoError(t, err)
+
+	// Hiding the remote layers prevents mounting them from src
+	var layers []regv1.Layer
+	for _, layer := range srcLayers {
+		layers = append(layers, struct{ regv1.Layer }{layer})
+	}
+	dstImg, err := mutate.AppendLayers(empty.Image, layers...)
+	require.NoError(t, err)
+
+	copied := make(chan error, 1)
+	go func() {
+		copied <- subject.MultiWrite(map[name.Reference]remote.Taggable{dstRef: dstImg}, 4, nil)
+	}()
+
+	select {
+	case err := <-copied:
+		require.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		t.Fatalf("Expected copy within the same host to finish with --max-concurrency-per-host 1")
+	}
+}
+
+func TestRegistry_MaxConcurrencyPerHostIsSharedWithMirrors(t *testing.T) {
+	expectedDigest := "sha256:477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65"
+
+	var inFlightLock sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := createServer(func(w http.ResponseWriter, r *http.Request) {
+		inFlightLock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		inFlightLock.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Docker-Content-Digest", expectedDigest)
+
+		inFlightLock.Lock()
+		inFlight--
+		inFlightLock.Unlock()
+	})
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	mirrorsConfigPath := filepath.Join(t.TempDir(), "mirrors.yml")
+	require.NoError(t, os.WriteFile(mirrorsConfigPath, []byte(fmt.Sprintf(`
+apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: RegistryMirrorsConfig
+registries:
+- registry: registry.example.com
+  mirrors:
+  - endpoint: %s/mirror
+    insecure: true
+`, u.Host)), 0600))
+
+	subject, err := registry.NewSimpleRegistry(registry.Opts{MaxConcurrencyPerHost: 2, MirrorsConfigPath: mirrorsConfigPath})
+	require.NoError(t, err)
+
+	var wg errgroup.Group
+	for i := 0; i < 6; i++ {
+		registryHost := u.Host
+		if i%2 == 0 {
+			registryHost = "registry.example.com"
+		}
+		ref := fmt.Sprintf("%s/repo%d@%s", registryHost, i, expectedDigest)
+		wg.Go(func() error {
+			imgRef, err := name.ParseReference(ref)
+			if err != nil {
+				return err
+			}
+			_, err = subject.Digest(imgRef)
+			return err
+		})
+	}
+	require.NoError(t, wg.Wait())
+
+	assert.LessOrEqual(t, maxInFlight, 2)
+}
+
+func TestRegistry_MaxBandwidth(t *testing.T) {
+	blob := bytes.Repeat([]byte("a"), 64*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", string(types.DockerManifestSchema2))
+		w.Write(blob)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	subject, err := registry.NewSimpleRegistry(registry.Opts{MaxBandwidth: 32 * 1024})
+	require.NoError(t, err)
+
+	imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", u.Host))
+	require.NoError(t, err)
+
+	start := time.Now()
+	desc, err := subject.Get(imgRef)
+	require.NoError(t, err)
+	assert.Len(t, desc.Manifest, len(blob))
+
+	// the first 32KiB are allowed right away and the remaining 32KiB take a second
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestRegistry_WriteImageWithoutRetryAttempts(t *testing.T) {
+	server := httptest.NewServer(ggcrregistry.New())
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	subject, err := registry.NewSimpleRegistry(registry.Opts{RetryPolicy: util.RetryPolicy{Multiplier: 2}})
+	require.NoError(t, err)
+
+	img, err := random.Image(500, 2)
+	require.NoError(t, err)
+	imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", u.Host))
+	require.NoError(t, err)
+
+	require.NoError(t, subject.WriteImage(imgRef, img))
+
+	_, err = subject.Image(imgRef)
+	require.NoError(t, err, "Expected image to be uploaded")
+}
+
+func TestRegistry_RetryAfter(t *testing.T) {
+	expectedDigest := "sha256:477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65"
+
+	t.Run("when the registry returns 429 it waits the time in Retry-After and retries", func(t *testing.T) {
+		requests := 0
+		server := createServer(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", expectedDigest)
+		})
+		defer server.Close()
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{RetryPolicy: util.RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}})
+		require.NoError(t, err)
+
+		imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", u.Host))
+		require.NoError(t, err)
+
+		start := time.Now()
+		digest, err := subject.Digest(imgRef)
+		require.NoError(t, err)
+		assert.Equal(t, expectedDigest, digest.String())
+		assert.Equal(t, 2, requests)
+		assert.GreaterOrEqual(t, time.Since(start), 1*time.Second)
+	})
+
+	t.Run("when the registry keeps returning 503 it stops after the configured attempts", func(t *testing.T) {
+		requests := 0
+		server := createServer(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		defer server.Close()
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{RetryPolicy: util.RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}})
+		require.NoError(t, err)
+
+		imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", u.Host))
+		require.NoError(t, err)
+
+		_, err = subject.Get(imgRef)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "503")
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("when the deprecated RetryCount is set it overrides the attempts of the retry policy", func(t *testing.T) {
+		requests := 0
+		server := createServer(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		defer server.Close()
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{RetryCount: 2, RetryPolicy: util.RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}})
+		require.NoError(t, err)
+
+		imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", u.Host))
+		require.NoError(t, err)
+
+		_, err = subject.Get(imgRef)
+		require.Error(t, err)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("when Retry-After exceeds the max elapsed time it does not wait", func(t *testing.T) {
+		requests := 0
+		server := createServer(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		defer server.Close()
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{RetryPolicy: util.RetryPolicy{MaxAttempts: 3, MaxElapsedTime: time.Minute}})
+		require.NoError(t, err)
+
+		imgRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", u.Host))
+		require.NoError(t, err)
+
+		_, err = subject.Get(imgRef)
+		require.Error(t, err)
+		assert.Equal(t, 1, requests)
+	})
+}
+
+func TestRegistry_Referrers(t *testing.T) {
+	imageDigest := "sha256:477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65"
+	referrersIndex := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[
+{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:1111111111111111111111111111111111111111111111111111111111111111","size":10,"artifactType":"application/vnd.cncf.notary.signature"},
+{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:2222222222222222222222222222222222222222222222222222222222222222","size":20,"artifactType":"application/spdx+json","annotations":{"org.opencontainers.image.created":"2022-01-01T00:00:00Z"}}]}`
+
+	createReferrersServer := func(handler func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, name.Digest) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v2/" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			handler(w, r)
+		}))
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		imgRef, err := name.NewDigest(fmt.Sprintf("%s/repo@%s", u.Host, imageDigest))
+		require.NoError(t, err)
+		return server, imgRef
+	}
+
+	t.Run("when the registry supports the referrers API it returns the referrers with the artifactType", func(t *testing.T) {
+		var requestedURL string
+		server, imgRef := createReferrersServer(func(w http.ResponseWriter, r *http.Request) {
+			requestedURL = r.URL.String()
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			w.Write([]byte(referrersIndex))
+		})
+		defer server.Close()
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		referrers, err := subject.Referrers(imgRef, "application/spdx+json")
+		require.NoError(t, err)
+		assert.Equal(t, "/v2/repo/referrers/"+imageDigest+"?artifactType=application%2Fspdx%2Bjson", requestedURL)
+		assert.Equal(t, registry.Referrers{Manifests: []registry.Referrer{{
+			MediaType:    "application/vnd.oci.image.manifest.v1+json",
+			Digest:       "sha256:2222222222222222222222222222222222222222222222222222222222222222",
+			Size:         20,
+			ArtifactType: "application/spdx+json",
+			Annotations:  map[string]string{"org.opencontainers.image.created": "2022-01-01T00:00:00Z"},
+		}}}, referrers)
+	})
+
+	t.Run("when the registry applied the artifactType filter it does not filter the referrers again", func(t *testing.T) {
+		server, imgRef := createReferrersServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("OCI-Filters-Applied", "artifactType")
+			w.Write([]byte(referrersIndex))
+		})
+		defer server.Close()
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		referrers, err := subject.Referrers(imgRef, "application/spdx+json")
+		require.NoError(t, err)
+		assert.Len(t, referrers.Manifests, 2)
+	})
+
+	t.Run("when the registry does not support the referrers API it uses the tag schema fallback", func(t *testing.T) {
+		fallbackDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(referrersIndex)))
+		server, imgRef := createReferrersServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v2/repo/manifests/sha256-477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			w.Write([]byte(referrersIndex))
+		})
+		defer server.Close()
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		referrers, err := subject.Referrers(imgRef, "")
+		require.NoError(t, err)
+		assert.Len(t, referrers.Manifests, 2)
+		assert.Equal(t, "sha256-477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65", referrers.FallbackTag)
+		assert.Equal(t, fallbackDigest, referrers.FallbackDigest)
+	})
+
+	t.Run("when the registry has no referrers API and no fallback tag it returns no referrers", func(t *testing.T) {
+		server, imgRef := createReferrersServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		defer server.Close()
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		referrers, err := subject.Referrers(imgRef, "")
+		require.NoError(t, err)
+		assert.Equal(t, registry.Referrers{TagSchema: true}, referrers)
+	})
+}
+
+func TestRegistry_ClientCertificates(t *testing.T) {
+	clientCertPEM, clientKeyPEM := generateClientCert(t)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(clientCertPEM))
+
+	expectedDigest := "sha256:477c34d98f9e090a4441cf82d2f1f03e64c8eb730e8c1ef39a8595e685d4df65"
+	createTLSServer := func(clientAuth tls.ClientAuthType, receivedClientCert *bool) *httptest.Server {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) > 0 {
+				*receivedClientCert = true
+			}
+			w.Header().Set("Content-Type", string(types.DockerManifestSchema2))
+			w.Header().Set("Docker-Content-Digest", expectedDigest)
+		}))
+		server.TLS = &tls.Config{ClientAuth: clientAuth, ClientCAs: clientCAs}
+		server.StartTLS()
+		return server
+	}
+
+	mtlsReceivedCert := false
+	mtlsServer := createTLSServer(tls.RequireAndVerifyClientCert, &mtlsReceivedCert)
+	defer mtlsServer.Close()
+	mtlsURL, err := url.Parse(mtlsServer.URL)
+	require.NoError(t, err)
+
+	publicReceivedCert := false
+	publicServer := createTLSServer(tls.RequestClientCert, &publicReceivedCert)
+	defer publicServer.Close()
+	publicURL, err := url.Parse(publicServer.URL)
+	require.NoError(t, err)
+
+	writeFile := func(path string, contents []byte) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, contents, 0600))
+	}
+	serverCAPEM := func(server *httptest.Server) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	}
+
+	t.Run("presents the client certificate in the certs dir only to its registry host", func(t *testing.T) {
+		certsDir := t.TempDir()
+		writeFile(filepath.Join(certsDir, mtlsURL.Host, "ca.crt"), serverCAPEM(mtlsServer))
+		writeFile(filepath.Join(certsDir, mtlsURL.Host, "client.cert"), clientCertPEM)
+		writeFile(filepath.Join(certsDir, mtlsURL.Host, "client.key"), clientKeyPEM)
+		publicCA := filepath.Join(t.TempDir(), "public-ca.crt")
+		writeFile(publicCA, serverCAPEM(publicServer))
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{VerifyCerts: true, CACertPaths: []string{publicCA}, CertsDir: certsDir})
+		require.NoError(t, err)
+
+		mtlsRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", mtlsURL.Host))
+		require.NoError(t, err)
+		_, err = subject.Digest(mtlsRef)
+		require.NoError(t, err)
+		assert.True(t, mtlsReceivedCert)
+
+		publicRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", publicURL.Host))
+		require.NoError(t, err)
+		_, err = subject.Digest(publicRef)
+		require.NoError(t, err)
+		assert.False(t, publicReceivedCert)
+	})
+
+	t.Run("presents the client certificate provided in the options to all registries", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "client.cert")
+		keyPath := filepath.Join(t.TempDir(), "client.key")
+		writeFile(certPath, clientCertPEM)
+		writeFile(keyPath, clientKeyPEM)
+
+		subject, err := registry.NewSimpleRegistry(registry.Opts{ClientCertPath: certPath, ClientKeyPath: keyPath})
+		require.NoError(t, err)
+
+		mtlsRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", mtlsURL.Host))
+		require.NoError(t, err)
+		_, err = subject.Digest(mtlsRef)
+		require.NoError(t, err)
+	})
+
+	t.Run("when registry requires a client certificate and none is configured it fails", func(t *testing.T) {
+		subject, err := registry.NewSimpleRegistry(registry.Opts{})
+		require.NoError(t, err)
+
+		mtlsRef, err := name.ParseReference(fmt.Sprintf("%s/repo:latest", mtlsURL.Host))
+		require.NoError(t, err)
+		_, err = subject.Digest(mtlsRef)
+		require.Error(t, err)
+	})
+
+	t.Run("when a client certificate in the certs dir has no key it errors", func(t *testing.T) {
+		certsDir := t.TempDir()
+		writeFile(filepath.Join(certsDir, "registry.corp.com:5000", "client.cert"), clientCertPEM)
+
+		_, err := registry.NewSimpleRegistry(registry.Opts{CertsDir: certsDir})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Missing key 'client.key' for client certificate")
+	})
+
+	t.Run("when only the client certificate is provided it errors", func(t *testing.T) {
+		_, err := registry.NewSimpleRegistry(registry.Opts{ClientCertPath: "/tmp/client.cert"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected both client certificate and key to be provided")
+	})
+}
+
+func generateClientCert(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "imgpkg-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// bufferLogger keeps the warnings to be asserted
+type bufferLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufferLogger) Warnf(msg string, args ...interface{}) {
+	fmt.Fprintf(&l.Buffer, msg, args...)
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+)
+
+// retryAfterTransport retries requests rejected with 429 or 503 following the retry policy,
+// waiting for the time requested by the registry in the Retry-After header when present.
+// It is the only place these responses are retried, see util.ThrottlingStatusCodes
+type retryAfterTransport struct {
+	transport   http.RoundTripper
+	retryPolicy util.RetryPolicy
+}
+
+var _ http.RoundTripper = retryAfterTransport{}
+
+// RoundTrip executes the request until it is not rejected or the retry policy limits are reached
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := util.ThrottlingStatusCodes[resp.StatusCode]; !ok {
+			return resp, nil
+		}
+
+		wait, found := t.retryAfter(resp, time.Now())
+		if !found {
+			wait = t.retryPolicy.Backoff(attempt)
+		}
+		if !t.retryPolicy.CanRetry(attempt, time.Since(start)+wait) {
+			return resp, nil
+		}
+
+		retryReq, canRetry := t.rewind(req)
+		if !canRetry {
+			return resp, nil
+		}
+
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		req = retryReq
+	}
+}
+
+// retryAfter parses the Retry-After header, that contains either a number of seconds or a date
+func (t retryAfterTransport) retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// rewind returns a copy of the request that can be sent again, which is only possible when its body can be recreated
+func (t retryAfterTransport) rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+
+	retryReq := req.Clone(req.Context())
+	retryReq.Body = body
+	return retryReq, true
+}
+
+// retryPredicate decides which failed operations go-containerregistry retries with the retry policy.
+// It retries the same network failures go-containerregistry does by default, except for
+// throttling responses that retryAfterTransport already retried
+func retryPredicate(err error) bool {
+	if err == nil || util.IsThrottlingError(err) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	if temporaryErr, ok := err.(interface{ Temporary() bool }); ok {
+		return temporaryErr.Temporary()
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// hostCerts Certificates found in the certificates directory of a registry host
+type hostCerts struct {
+	caCertPaths []string
+	clientCerts []tls.Certificate
+}
+
+// readCertsDir reads a directory with the same layout as docker's certs.d, where each sub directory
+// is named after a registry host (example: registry.corp.com:5000) and contains
+// CA certificates (*.crt) and client certificate and key pairs (*.cert and *.key)
+func readCertsDir(dir string) (map[string]hostCerts, error) {
+	if len(dir) == 0 {
+		return nil, nil
+	}
+
+	hostDirs, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Reading certificates directory '%s': %s", dir, err)
+	}
+
+	certsByHost := map[string]hostCerts{}
+	for _, hostDir := range hostDirs {
+		if !hostDir.IsDir() {
+			continue
+		}
+
+		certs, err := readHostCertsDir(filepath.Join(dir, hostDir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		certsByHost[hostDir.Name()] = certs
+	}
+
+	return certsByHost, nil
+}
+
+func readHostCertsDir(dir string) (hostCerts, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return hostCerts{}, fmt.Errorf("Reading certificates directory '%s': %s", dir, err)
+	}
+
+	fileNames := map[string]struct{}{}
+	for _, file := range files {
+		fileNames[file.Name()] = struct{}{}
+	}
+
+	var certs hostCerts
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+
+		switch filepath.Ext(file.Name()) {
+		case ".crt":
+			certs.caCertPaths = append(certs.caCertPaths, path)
+
+		case ".cert":
+			keyName := strings.TrimSuffix(file.Name(), ".cert") + ".key"
+			if _, found := fileNames[keyName]; !found {
+				return hostCerts{}, fmt.Errorf("Missing key '%s' for client certificate '%s'", keyName, path)
+			}
+			clientCert, err := loadClientCert(path, filepath.Join(dir, keyName))
+			if err != nil {
+				return hostCerts{}, err
+			}
+			certs.clientCerts = append(certs.clientCerts, clientCert)
+
+		case ".key":
+			certName := strings.TrimSuffix(file.Name(), ".key") + ".cert"
+			if _, found := fileNames[certName]; !found {
+				return hostCerts{}, fmt.Errorf("Missing client certificate '%s' for key '%s'", certName, path)
+			}
+		}
+	}
+
+	sort.Strings(certs.caCertPaths)
+
+	return certs, nil
+}
+
+func loadClientCert(certPath, keyPath string) (tls.Certificate, error) {
+	clientCert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("Loading client certificate '%s' and key '%s': %s", certPath, keyPath, err)
+	}
+	return clientCert, nil
+}
+
+func newCertPool(caCertPaths []string) (*x509.CertPool, error) {
+	var pool *x509.CertPool
+
+	// workaround for windows not returning system certs via x509.SystemCertPool() See: https://github.com/golang/go/issues/16736
+	// instead windows lazily fetches ca certificates (over the network) as needed during cert verification time.
+	// to opt-into that tls.Config.RootCAs is set to nil on windows.
+	if runtime.GOOS != "windows" {
+		var err error
+		pool, err = x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if runtime.GOOS == "windows" && len(caCertPaths) > 0 {
+		pool = x509.NewCertPool()
+	}
+
+	for _, path := range caCertPaths {
+		if certs, err := ioutil.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("Reading CA certificates from '%s': %s", path, err)
+		} else if ok := pool.AppendCertsFromPEM(certs); !ok {
+			return nil, fmt.Errorf("Adding CA certificates from '%s': failed", path)
+		}
+	}
+
+	return pool, nil
+}
+
+// newTLSConfigs returns the TLS configuration used for all registries
+// and the TLS configuration of each registry host with certificates in Opts.CertsDir
+func newTLSConfigs(opts Opts) (*tls.Config, map[string]*tls.Config, error) {
+	if (len(opts.ClientCertPath) == 0) != (len(opts.ClientKeyPath) == 0) {
+		return nil, nil, fmt.Errorf("Expected both client certificate and key to be provided")
+	}
+
+	var clientCerts []tls.Certificate
+	if len(opts.ClientCertPath) > 0 {
+		clientCert, err := loadClientCert(opts.ClientCertPath, opts.ClientKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientCerts = append(clientCerts, clientCert)
+	}
+
+	pool, err := newCertPool(opts.CACertPaths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defaultConfig := &tls.Config{
+		RootCAs:            pool,
+		Certificates:       clientCerts,
+		InsecureSkipVerify: opts.VerifyCerts == false,
+	}
+
+	certsByHost, err := readCertsDir(opts.CertsDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostConfigs := map[string]*tls.Config{}
+	for host, certs := range certsByHost {
+		hostConfig := defaultConfig.Clone()
+
+		if len(certs.caCertPaths) > 0 {
+			hostConfig.RootCAs, err = newCertPool(append(append([]string{}, opts.CACertPaths...), certs.caCertPaths...))
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		// Client certificates of the host replace the ones provided for all registries
+		if len(certs.clientCerts) > 0 {
+			hostConfig.Certificates = certs.clientCerts
+		}
+
+		hostConfigs[withoutDefaultPort("https", host)] = hostConfig
+	}
+
+	return defaultConfig, hostConfigs, nil
+}
+
+// perHostTransport sends requests to the transport configured for the request host,
+// falling back to the default transport for any other host
+type perHostTransport struct {
+	defaultTransport http.RoundTripper
+	hostTransports   map[string]http.RoundTripper
+}
+
+var _ http.RoundTripper = perHostTransport{}
+
+// RoundTrip executes the request using the transport of the request host
+func (t perHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if hostTransport, found := t.hostTransports[withoutDefaultPort(req.URL.Scheme, req.URL.Host)]; found {
+		return hostTransport.RoundTrip(req)
+	}
+	return t.defaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namedTransport string
+
+func (t namedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Transport": []string{string(t)}}, Request: req}, nil
+}
+
+func TestPerHostTransport_DefaultPorts(t *testing.T) {
+	subject := perHostTransport{
+		defaultTransport: namedTransport("default"),
+		hostTransports: map[string]http.RoundTripper{
+			withoutDefaultPort("https", "registry.corp.com:443"): namedTransport("corp"),
+			withoutDefaultPort("https", "other.corp.com"):        namedTransport("other"),
+			withoutDefaultPort("https", "local.corp.com:5000"):   namedTransport("local"),
+		},
+	}
+
+	expectedTransports := map[string]string{
+		"https://registry.corp.com/v2/":     "corp",
+		"https://registry.corp.com:443/v2/": "corp",
+		"https://other.corp.com/v2/":        "other",
+		"https://other.corp.com:443/v2/":    "other",
+		"https://local.corp.com:5000/v2/":   "local",
+		"https://local.corp.com/v2/":        "default",
+		"http://registry.corp.com:443/v2/":  "default",
+	}
+	for url, expected := range expectedTransports {
+		t.Run(url, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			resp, err := subject.RoundTrip(req)
+			require.NoError(t, err)
+			assert.Equal(t, expected, resp.Header.Get("Transport"))
+		})
+	}
+}
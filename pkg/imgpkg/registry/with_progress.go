@@ -66,6 +66,11 @@ func (w WithProgress) ListTags(repo regname.Repository) ([]string, error) {
 	return w.delegate.ListTags(repo)
 }
 
+// Referrers Retrieve the manifests that refer to the provided manifest
+func (w WithProgress) Referrers(reference regname.Digest, artifactType string) (Referrers, error) {
+	return w.delegate.Referrers(reference, artifactType)
+}
+
 // CloneWithSingleAuth Clones the provided registry replacing the Keychain with a Keychain that can only authenticate
 // the image provided
 // A Registry need to be provided as the first parameter or the function will panic
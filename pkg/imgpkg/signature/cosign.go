@@ -24,12 +24,17 @@ func NewCosign(reg registry.Registry) *Cosign {
 }
 
 func (c Cosign) Signature(imageRef regname.Digest) (imageset.UnprocessedImageRef, error) {
-	sigTagRef, err := c.signatureTag(imageRef)
+	return c.Artifact(imageRef, SignatureArtifact)
+}
+
+// Artifact Finds the artifact of the provided kind that cosign attached to the image
+func (c Cosign) Artifact(imageRef regname.Digest, kind ArtifactKind) (imageset.UnprocessedImageRef, error) {
+	artifactTagRef, err := c.artifactTag(imageRef, kind)
 	if err != nil {
 		return imageset.UnprocessedImageRef{}, err
 	}
 
-	sigDigest, err := c.registry.Digest(sigTagRef)
+	artifactDigest, err := c.registry.Digest(artifactTagRef)
 	if err != nil {
 		if transportErr, ok := err.(*transport.Error); ok {
 			if transportErr.StatusCode == http.StatusNotFound {
@@ -40,15 +45,15 @@ func (c Cosign) Signature(imageRef regname.Digest) (imageset.UnprocessedImageRef
 	}
 
 	return imageset.UnprocessedImageRef{
-		DigestRef: imageRef.Digest(sigDigest.String()).Name(),
-		Tag:       sigTagRef.TagStr(),
+		DigestRef: imageRef.Digest(artifactDigest.String()).Name(),
+		Tag:       artifactTagRef.TagStr(),
 	}, nil
 }
 
-func (c Cosign) signatureTag(reference regname.Digest) (regname.Tag, error) {
+func (c Cosign) artifactTag(reference regname.Digest, kind ArtifactKind) (regname.Tag, error) {
 	digest, err := v1.NewHash(reference.DigestStr())
 	if err != nil {
 		return regname.Tag{}, fmt.Errorf("Converting to hash: %s", err)
 	}
-	return regname.NewTag(reference.Repository.Name() + ":" + cosign.Munge(v1.Descriptor{Digest: digest}))
+	return regname.NewTag(reference.Repository.Name() + ":" + cosign.AttachedImageTag(v1.Descriptor{Digest: digest}, string(kind)))
 }
@@ -0,0 +1,168 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PEM block types of the private keys generated by cosign generate-key-pair
+const (
+	CosignPrivateKeyPEMType   = "ENCRYPTED COSIGN PRIVATE KEY"
+	SigstorePrivateKeyPEMType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+)
+
+const (
+	scryptKDFName        = "scrypt"
+	secretboxCipherName  = "nacl/secretbox"
+	secretboxKeyLength   = 32
+	secretboxNonceLength = 24
+)
+
+// EncryptedKey Format of the encrypted private keys generated by cosign
+// (key derived from the password with scrypt and encrypted with nacl/secretbox)
+type EncryptedKey struct {
+	KDF        EncryptedKeyKDF    `json:"kdf"`
+	Cipher     EncryptedKeyCipher `json:"cipher"`
+	Ciphertext []byte             `json:"ciphertext"`
+}
+
+type EncryptedKeyKDF struct {
+	Name   string                `json:"name"`
+	Params EncryptedKeyKDFParams `json:"params"`
+	Salt   []byte                `json:"salt"`
+}
+
+type EncryptedKeyKDFParams struct {
+	N int `json:"N"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+type EncryptedKeyCipher struct {
+	Name  string `json:"name"`
+	Nonce []byte `json:"nonce"`
+}
+
+// LoadPrivateKey reads a private key generated by cosign generate-key-pair and decrypts it with the password
+func LoadPrivateKey(path string, password []byte) (*ecdsa.PrivateKey, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Reading private key '%s': %s", path, err)
+	}
+
+	key, err := ParsePrivateKey(contents, password)
+	if err != nil {
+		return nil, fmt.Errorf("Loading private key '%s': %s", path, err)
+	}
+
+	return key, nil
+}
+
+// ParsePrivateKey decrypts a PEM encoded private key generated by cosign generate-key-pair
+func ParsePrivateKey(contents []byte, password []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("Expected PEM encoded private key")
+	}
+	if block.Type != CosignPrivateKeyPEMType && block.Type != SigstorePrivateKeyPEMType {
+		return nil, fmt.Errorf("Expected PEM block of type %s or %s, got '%s'", CosignPrivateKeyPEMType, SigstorePrivateKeyPEMType, block.Type)
+	}
+
+	var encryptedKey EncryptedKey
+	err := json.Unmarshal(block.Bytes, &encryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshaling encrypted key: %s", err)
+	}
+
+	der, err := encryptedKey.Decrypt(password)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing private key: %s", err)
+	}
+
+	ecdsaKey, ok := privateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Expected private key to be an ECDSA key, got %T", privateKey)
+	}
+
+	return ecdsaKey, nil
+}
+
+// Decrypt returns the decrypted contents of the key
+func (k EncryptedKey) Decrypt(password []byte) ([]byte, error) {
+	if k.KDF.Name != scryptKDFName {
+		return nil, fmt.Errorf("Expected key derivation function to be %s, got '%s'", scryptKDFName, k.KDF.Name)
+	}
+	if k.Cipher.Name != secretboxCipherName {
+		return nil, fmt.Errorf("Expected cipher to be %s, got '%s'", secretboxCipherName, k.Cipher.Name)
+	}
+	if len(k.Cipher.Nonce) != secretboxNonceLength {
+		return nil, fmt.Errorf("Expected cipher nonce to have %d bytes, got %d", secretboxNonceLength, len(k.Cipher.Nonce))
+	}
+
+	derivedKey, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, secretboxKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("Deriving key from password: %s", err)
+	}
+
+	var secretKey [secretboxKeyLength]byte
+	var nonce [secretboxNonceLength]byte
+	copy(secretKey[:], derivedKey)
+	copy(nonce[:], k.Cipher.Nonce)
+
+	decrypted, ok := secretbox.Open(nil, k.Ciphertext, &nonce, &secretKey)
+	if !ok {
+		return nil, fmt.Errorf("Decrypting private key: incorrect password")
+	}
+
+	return decrypted, nil
+}
+
+// LoadPublicKey reads a PEM encoded public key, like the cosign.pub generated by cosign generate-key-pair
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Reading public key '%s': %s", path, err)
+	}
+
+	key, err := ParsePublicKey(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Loading public key '%s': %s", path, err)
+	}
+
+	return key, nil
+}
+
+// ParsePublicKey parses a PEM encoded ECDSA public key
+func ParsePublicKey(contents []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("Expected PEM encoded public key")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Parsing public key: %s", err)
+	}
+
+	ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("Expected public key to be an ECDSA key, got %T", publicKey)
+	}
+
+	return ecdsaKey, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cosign_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encryptedPEM := encryptKey(t, key, "some-password", cosign.CosignPrivateKeyPEMType)
+
+	t.Run("it decrypts the key with the password", func(t *testing.T) {
+		parsedKey, err := cosign.ParsePrivateKey(encryptedPEM, []byte("some-password"))
+		require.NoError(t, err)
+		assert.True(t, key.Equal(parsedKey))
+	})
+
+	t.Run("it decrypts keys with the sigstore PEM type", func(t *testing.T) {
+		parsedKey, err := cosign.ParsePrivateKey(encryptKey(t, key, "", cosign.SigstorePrivateKeyPEMType), nil)
+		require.NoError(t, err)
+		assert.True(t, key.Equal(parsedKey))
+	})
+
+	t.Run("when the password is wrong it errors", func(t *testing.T) {
+		_, err := cosign.ParsePrivateKey(encryptedPEM, []byte("wrong-password"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Decrypting private key: incorrect password")
+	})
+
+	t.Run("when the key is not an encrypted cosign key it errors", func(t *testing.T) {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+
+		_, err = cosign.ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected PEM block of type ENCRYPTED COSIGN PRIVATE KEY or ENCRYPTED SIGSTORE PRIVATE KEY, got 'PRIVATE KEY'")
+	})
+}
+
+// encryptKey encrypts the key the same way cosign generate-key-pair does, with cheaper scrypt parameters
+func encryptKey(t *testing.T, key *ecdsa.PrivateKey, password string, pemType string) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	encryptedKey := cosign.EncryptedKey{
+		KDF: cosign.EncryptedKeyKDF{
+			Name:   "scrypt",
+			Params: cosign.EncryptedKeyKDFParams{N: 1024, R: 8, P: 1},
+			Salt:   make([]byte, 32),
+		},
+		Cipher: cosign.EncryptedKeyCipher{Name: "nacl/secretbox", Nonce: make([]byte, 24)},
+	}
+	_, err = rand.Read(encryptedKey.KDF.Salt)
+	require.NoError(t, err)
+	_, err = rand.Read(encryptedKey.Cipher.Nonce)
+	require.NoError(t, err)
+
+	derivedKey, err := scrypt.Key([]byte(password), encryptedKey.KDF.Salt, 1024, 8, 1, 32)
+	require.NoError(t, err)
+
+	var secretKey [32]byte
+	var nonce [24]byte
+	copy(secretKey[:], derivedKey)
+	copy(nonce[:], encryptedKey.Cipher.Nonce)
+	encryptedKey.Ciphertext = secretbox.Seal(nil, der, &nonce, &secretKey)
+
+	contents, err := json.Marshal(encryptedKey)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: contents})
+}
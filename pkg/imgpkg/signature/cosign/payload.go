@@ -0,0 +1,48 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cosign
+
+import (
+	"encoding/json"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+)
+
+// Constants used by cosign for the simple signing payloads stored in signature images
+const (
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	SignatureAnnotationKey = "dev.cosignproject.cosign/signature"
+	SimpleSigningType      = "cosign container image signature"
+)
+
+// SimpleSigning Payload signed by cosign, as defined by the containers/image simple signing format
+type SimpleSigning struct {
+	Critical SimpleSigningCritical  `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+type SimpleSigningCritical struct {
+	Identity SimpleSigningIdentity `json:"identity"`
+	Image    SimpleSigningImage    `json:"image"`
+	Type     string                `json:"type"`
+}
+
+type SimpleSigningIdentity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+type SimpleSigningImage struct {
+	DockerManifestDigest string `json:"docker-manifest-digest"`
+}
+
+// SimpleSigningPayload returns the payload cosign signs for the provided image
+func SimpleSigningPayload(image regname.Digest) ([]byte, error) {
+	return json.Marshal(SimpleSigning{
+		Critical: SimpleSigningCritical{
+			Identity: SimpleSigningIdentity{DockerReference: image.Repository.Name()},
+			Image:    SimpleSigningImage{DockerManifestDigest: image.DigestStr()},
+			Type:     SimpleSigningType,
+		},
+	})
+}
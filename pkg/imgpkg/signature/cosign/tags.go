@@ -0,0 +1,23 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cosign
+
+import (
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Suffixes of the tags cosign uses to attach artifacts to an image
+const (
+	SignatureTagSuffix   = "sig"
+	AttestationTagSuffix = "att"
+	SBOMTagSuffix        = "sbom"
+)
+
+// AttachedImageTag returns the tag cosign uses to attach the artifact with the provided suffix to an image
+// (example: sha256-<hex>.att)
+func AttachedImageTag(desc v1.Descriptor, suffix string) string {
+	return strings.ReplaceAll(desc.Digest.String(), ":", "-") + "." + suffix
+}
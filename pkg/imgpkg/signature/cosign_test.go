@@ -52,3 +52,28 @@ func TestCosign_Signature(t *testing.T) {
 		require.True(t, ok)
 	})
 }
+
+func TestCosign_Artifact(t *testing.T) {
+	t.Run("it returns the artifact with the tag of the provided kind", func(t *testing.T) {
+		logger := &helpers.Logger{}
+		regBuilder := helpers.NewFakeRegistry(t, logger)
+		attImg := regBuilder.WithRandomImage("some-image")
+		attestationTag := fmt.Sprintf("sha256-%s.att", strings.Split(attImg.Digest, ":")[1])
+		attImg.Tag = attestationTag
+		reg := regBuilder.Build()
+		defer regBuilder.CleanUp()
+
+		subject := signature.NewCosign(reg)
+		imgDigest, err := name.NewDigest(attImg.RefDigest)
+		require.NoError(t, err)
+		attestation, err := subject.Artifact(imgDigest, signature.AttestationArtifact)
+		require.NoError(t, err)
+		assert.Equal(t, attImg.RefDigest, attestation.DigestRef)
+		assert.Equal(t, attestationTag, attestation.Tag)
+
+		_, err = subject.Artifact(imgDigest, signature.SBOMArtifact)
+		require.Error(t, err)
+		_, ok := err.(signature.NotFoundErr)
+		require.True(t, ok)
+	})
+}
@@ -8,13 +8,37 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 	"golang.org/x/sync/errgroup"
 )
 
+// ArtifactKind Kind of artifact that cosign attaches to images
+type ArtifactKind string
+
+const (
+	SignatureArtifact   ArtifactKind = cosign.SignatureTagSuffix
+	AttestationArtifact ArtifactKind = cosign.AttestationTagSuffix
+	SBOMArtifact        ArtifactKind = cosign.SBOMTagSuffix
+)
+
+var artifactKindDescriptions = map[ArtifactKind]string{
+	SignatureArtifact:   "signature",
+	AttestationArtifact: "attestation",
+	SBOMArtifact:        "SBOM",
+}
+
+func (k ArtifactKind) String() string {
+	if description, found := artifactKindDescriptions[k]; found {
+		return description
+	}
+	return string(k)
+}
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 . Finder
 type Finder interface {
 	Signature(reference name.Digest) (imageset.UnprocessedImageRef, error)
+	Artifact(reference name.Digest, kind ArtifactKind) (imageset.UnprocessedImageRef, error)
 }
 
 type NotFoundErr struct{}
@@ -25,12 +49,19 @@ func (s NotFoundErr) Error() string {
 
 type Signatures struct {
 	signatureFinder Finder
+	kinds           []ArtifactKind
 	concurrency     int
 }
 
 func NewSignatures(finder Finder, concurrency int) *Signatures {
+	return NewArtifacts(finder, []ArtifactKind{SignatureArtifact}, concurrency)
+}
+
+// NewArtifacts Builds a Signatures that fetches the cosign artifacts of the provided kinds
+func NewArtifacts(finder Finder, kinds []ArtifactKind, concurrency int) *Signatures {
 	return &Signatures{
 		signatureFinder: finder,
+		kinds:           kinds,
 		concurrency:     concurrency,
 	}
 }
@@ -42,27 +73,30 @@ func (s *Signatures) Fetch(images *imageset.UnprocessedImageRefs) (*imageset.Unp
 	var wg errgroup.Group
 
 	for _, ref := range images.All() {
-		ref := ref //copy
-		wg.Go(func() error {
-			imgDigest, err := name.NewDigest(ref.DigestRef)
-			if err != nil {
-				return fmt.Errorf("Parsing '%s': %s", ref.DigestRef, err)
-			}
-
-			throttle.Take()
-			defer throttle.Done()
-
-			signature, err := s.signatureFinder.Signature(imgDigest)
-			if err != nil {
-				if _, ok := err.(NotFoundErr); !ok {
-					return fmt.Errorf("Fetching signature for image '%s': %s", imgDigest.Name(), err)
+		for _, kind := range s.kinds {
+			ref := ref //copy
+			kind := kind
+			wg.Go(func() error {
+				imgDigest, err := name.NewDigest(ref.DigestRef)
+				if err != nil {
+					return fmt.Errorf("Parsing '%s': %s", ref.DigestRef, err)
+				}
+
+				throttle.Take()
+				defer throttle.Done()
+
+				artifact, err := s.find(imgDigest, kind)
+				if err != nil {
+					if _, ok := err.(NotFoundErr); !ok {
+						return fmt.Errorf("Fetching %s for image '%s': %s", kind, imgDigest.Name(), err)
+					}
+					return nil
 				}
-				return nil
-			}
 
-			signatures.Add(signature)
-			return nil
-		})
+				signatures.Add(artifact)
+				return nil
+			})
+		}
 	}
 
 	err := wg.Wait()
@@ -70,6 +104,44 @@ func (s *Signatures) Fetch(images *imageset.UnprocessedImageRefs) (*imageset.Unp
 	return signatures, err
 }
 
+func (s *Signatures) find(imgDigest name.Digest, kind ArtifactKind) (imageset.UnprocessedImageRef, error) {
+	if kind == SignatureArtifact {
+		return s.signatureFinder.Signature(imgDigest)
+	}
+	return s.signatureFinder.Artifact(imgDigest, kind)
+}
+
+// Retriever Finds the artifacts attached to images
+type Retriever interface {
+	Fetch(images *imageset.UnprocessedImageRefs) (*imageset.UnprocessedImageRefs, error)
+}
+
+// Multi Finds the artifacts attached to images using multiple retrievers
+type Multi struct {
+	retrievers []Retriever
+}
+
+// NewMulti Builds a Multi that returns the artifacts found by all the provided retrievers
+func NewMulti(retrievers ...Retriever) *Multi {
+	return &Multi{retrievers: retrievers}
+}
+
+func (m *Multi) Fetch(images *imageset.UnprocessedImageRefs) (*imageset.UnprocessedImageRefs, error) {
+	artifacts := imageset.NewUnprocessedImageRefs()
+
+	for _, retriever := range m.retrievers {
+		found, err := retriever.Fetch(images)
+		if err != nil {
+			return nil, err
+		}
+		for _, artifact := range found.All() {
+			artifacts.Add(artifact)
+		}
+	}
+
+	return artifacts, nil
+}
+
 type Noop struct{}
 
 func NewNoop() *Noop { return &Noop{} }
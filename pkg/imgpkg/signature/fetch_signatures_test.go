@@ -61,4 +61,40 @@ func TestSignatureRetriever_Signatures(t *testing.T) {
 		_, err := subject.Fetch(args)
 		require.Error(t, err)
 	})
+
+	t.Run("it fetches the artifacts of every provided kind", func(t *testing.T) {
+		fakeSignatureFinder := &signaturefakes.FakeFinder{}
+		subject := signature.NewArtifacts(fakeSignatureFinder, []signature.ArtifactKind{signature.SignatureArtifact, signature.AttestationArtifact, signature.SBOMArtifact}, 2)
+		fakeSignatureFinder.SignatureReturns(imageset.UnprocessedImageRef{DigestRef: "registry.io/img@sha256:cf31af331f38d1d7158470e095b132acd126a7180a54f263d386da88eb681d93", Tag: "some-sig-tag"}, nil)
+		fakeSignatureFinder.ArtifactCalls(func(digest regname.Digest, kind signature.ArtifactKind) (imageset.UnprocessedImageRef, error) {
+			if kind == signature.AttestationArtifact {
+				return imageset.UnprocessedImageRef{DigestRef: "registry.io/img@sha256:be154cc2b1211a9f98f4d708f4266650c9129784d0485d4507d9b0fa05d928b6", Tag: "some-att-tag"}, nil
+			}
+			return imageset.UnprocessedImageRef{}, signature.NotFoundErr{}
+		})
+
+		args := imageset.NewUnprocessedImageRefs()
+		args.Add(imageset.UnprocessedImageRef{DigestRef: "registry.io/img@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"})
+		artifacts, err := subject.Fetch(args)
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []imageset.UnprocessedImageRef{
+			{DigestRef: "registry.io/img@sha256:cf31af331f38d1d7158470e095b132acd126a7180a54f263d386da88eb681d93", Tag: "some-sig-tag"},
+			{DigestRef: "registry.io/img@sha256:be154cc2b1211a9f98f4d708f4266650c9129784d0485d4507d9b0fa05d928b6", Tag: "some-att-tag"},
+		}, artifacts.All())
+		assert.Equal(t, 1, fakeSignatureFinder.SignatureCallCount())
+		assert.Equal(t, 2, fakeSignatureFinder.ArtifactCallCount())
+	})
+
+	t.Run("it returns error with the kind of artifact that failed", func(t *testing.T) {
+		fakeSignatureFinder := &signaturefakes.FakeFinder{}
+		subject := signature.NewArtifacts(fakeSignatureFinder, []signature.ArtifactKind{signature.SBOMArtifact}, 2)
+		fakeSignatureFinder.ArtifactReturns(imageset.UnprocessedImageRef{}, fmt.Errorf("some error"))
+
+		args := imageset.NewUnprocessedImageRefs()
+		args.Add(imageset.UnprocessedImageRef{DigestRef: "registry.io/img@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"})
+		_, err := subject.Fetch(args)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Fetching SBOM for image 'registry.io/img@sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0': some error")
+	})
 }
@@ -0,0 +1,167 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	PolicyKind       = "SignaturePolicy"
+	PolicyAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+)
+
+// Policy Declares which images must be signed, and by which keys, before they can be copied
+type Policy struct {
+	APIVersion string       `json:"apiVersion"`
+	Kind       string       `json:"kind"`
+	Rules      []PolicyRule `json:"rules"`
+}
+
+// PolicyRule Requirements for the images in the repositories matching any of the patterns
+// Patterns are globs matched against the registry hostname and the first segments of the repository path
+// (examples: index.docker.io, registry.corp.com/team-*, *.gcr.io/project/app)
+type PolicyRule struct {
+	Repositories     []string          `json:"repositories"`
+	RequireSignature bool              `json:"requireSignature"`
+	PublicKeys       []PolicyPublicKey `json:"publicKeys,omitempty"`
+
+	keys []*ecdsa.PublicKey
+}
+
+// PolicyPublicKey Public key, provided either as a path (relative to the policy file) or as PEM contents
+type PolicyPublicKey struct {
+	Path string `json:"path,omitempty"`
+	PEM  string `json:"pem,omitempty"`
+}
+
+// NewPolicyFromPath Reads the signature policy from a file, loading the public keys it references
+func NewPolicyFromPath(policyPath string) (Policy, error) {
+	bs, err := ioutil.ReadFile(policyPath)
+	if err != nil {
+		return Policy{}, fmt.Errorf("Reading path %s: %s", policyPath, err)
+	}
+
+	return NewPolicyFromBytes(bs, filepath.Dir(policyPath))
+}
+
+// NewPolicyFromBytes Parses and validates the signature policy, public key paths are relative to baseDir
+func NewPolicyFromBytes(data []byte, baseDir string) (Policy, error) {
+	var policy Policy
+
+	err := yaml.UnmarshalStrict(data, &policy)
+	if err != nil {
+		return policy, fmt.Errorf("Unmarshaling signature policy: %s", err)
+	}
+
+	err = policy.Validate()
+	if err != nil {
+		return policy, fmt.Errorf("Validating signature policy: %s", err)
+	}
+
+	for i, rule := range policy.Rules {
+		for _, publicKey := range rule.PublicKeys {
+			key, err := publicKey.load(baseDir)
+			if err != nil {
+				return policy, fmt.Errorf("Loading signature policy public keys: %s", err)
+			}
+			policy.Rules[i].keys = append(policy.Rules[i].keys, key)
+		}
+	}
+
+	return policy, nil
+}
+
+// Validate Checks that every rule has valid repository patterns and public keys
+func (p Policy) Validate() error {
+	if p.APIVersion != PolicyAPIVersion {
+		return fmt.Errorf("Validating apiVersion: Unknown version (known: %s)", PolicyAPIVersion)
+	}
+	if p.Kind != PolicyKind {
+		return fmt.Errorf("Validating kind: Unknown kind (known: %s)", PolicyKind)
+	}
+
+	for i, rule := range p.Rules {
+		if len(rule.Repositories) == 0 {
+			return fmt.Errorf("Expected rule %d to have at least one repository pattern", i)
+		}
+		for _, pattern := range rule.Repositories {
+			if err := validateRepositoryPattern(pattern); err != nil {
+				return fmt.Errorf("Parsing repository pattern '%s': %s", pattern, err)
+			}
+		}
+		if len(rule.PublicKeys) > 0 && !rule.RequireSignature {
+			return fmt.Errorf("Expected rule %d with public keys to require signatures", i)
+		}
+		// Anyone with push access can attach a signature, so only signatures by known keys are meaningful
+		if rule.RequireSignature && len(rule.PublicKeys) == 0 {
+			return fmt.Errorf("Expected rule %d requiring signatures to have at least one public key", i)
+		}
+		for _, publicKey := range rule.PublicKeys {
+			if (len(publicKey.Path) == 0) == (len(publicKey.PEM) == 0) {
+				return fmt.Errorf("Expected public key of rule %d to have either path or pem", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RuleFor Returns the first rule matching the repository (example: index.docker.io/library/nginx)
+func (p Policy) RuleFor(repository string) (PolicyRule, bool) {
+	for _, rule := range p.Rules {
+		for _, pattern := range rule.Repositories {
+			if repositoryPatternMatches(pattern, repository) {
+				return rule, true
+			}
+		}
+	}
+	return PolicyRule{}, false
+}
+
+func (k PolicyPublicKey) load(baseDir string) (*ecdsa.PublicKey, error) {
+	if len(k.PEM) > 0 {
+		return cosign.ParsePublicKey([]byte(k.PEM))
+	}
+
+	keyPath := k.Path
+	if !filepath.IsAbs(keyPath) {
+		keyPath = filepath.Join(baseDir, keyPath)
+	}
+	return cosign.LoadPublicKey(keyPath)
+}
+
+// repositoryPatternMatches checks if every segment of the pattern matches the segment
+// of the repository in the same position
+func repositoryPatternMatches(pattern, repository string) bool {
+	patternSegments := strings.Split(strings.TrimSuffix(pattern, "/"), "/")
+	repositorySegments := strings.Split(repository, "/")
+	if len(patternSegments) > len(repositorySegments) {
+		return false
+	}
+
+	for i, patternSegment := range patternSegments {
+		if matched, _ := path.Match(patternSegment, repositorySegments[i]); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func validateRepositoryPattern(pattern string) error {
+	for _, patternSegment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(patternSegment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
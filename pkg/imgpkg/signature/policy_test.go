@@ -0,0 +1,207 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestNewPolicyFromBytes(t *testing.T) {
+	t.Run("when apiVersion is unknown it errors", func(t *testing.T) {
+		_, err := signature.NewPolicyFromBytes([]byte(`apiVersion: v1
+kind: SignaturePolicy
+`), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Validating apiVersion: Unknown version")
+	})
+
+	t.Run("when a repository pattern is invalid it errors", func(t *testing.T) {
+		_, err := signature.NewPolicyFromBytes([]byte(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: SignaturePolicy
+rules:
+- repositories: ["registry.corp.com/[team"]
+  requireSignature: true
+`), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Parsing repository pattern 'registry.corp.com/[team'")
+	})
+
+	t.Run("when a public key has both path and pem it errors", func(t *testing.T) {
+		_, err := signature.NewPolicyFromBytes([]byte(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: SignaturePolicy
+rules:
+- repositories: ["registry.corp.com"]
+  requireSignature: true
+  publicKeys:
+  - path: cosign.pub
+    pem: some-pem
+`), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected public key of rule 0 to have either path or pem")
+	})
+
+	t.Run("when a public key path cannot be read it errors", func(t *testing.T) {
+		_, err := signature.NewPolicyFromBytes([]byte(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: SignaturePolicy
+rules:
+- repositories: ["registry.corp.com"]
+  requireSignature: true
+  publicKeys:
+  - path: cosign.pub
+`), t.TempDir())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Reading public key")
+	})
+
+	t.Run("when a rule requires signatures without public keys it errors", func(t *testing.T) {
+		_, err := signature.NewPolicyFromBytes([]byte(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: SignaturePolicy
+rules:
+- repositories: ["registry.corp.com"]
+  requireSignature: true
+`), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Expected rule 0 requiring signatures to have at least one public key")
+	})
+}
+
+func TestPolicy_RuleFor(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	policyDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(policyDir, "cosign.pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}), 0600))
+
+	policy, err := signature.NewPolicyFromBytes([]byte(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: SignaturePolicy
+rules:
+- repositories: ["registry.corp.com/internal"]
+  requireSignature: false
+- repositories: ["registry.corp.com", "*.gcr.io/project-*/app"]
+  requireSignature: true
+  publicKeys:
+  - path: cosign.pub
+`), policyDir)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		repository       string
+		found            bool
+		requireSignature bool
+	}{
+		{repository: "registry.corp.com/internal/app", found: true, requireSignature: false},
+		{repository: "registry.corp.com/team/app", found: true, requireSignature: true},
+		{repository: "us.gcr.io/project-a/app/component", found: true, requireSignature: true},
+		{repository: "us.gcr.io/project-a/other-app", found: false},
+		{repository: "index.docker.io/library/nginx", found: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.repository, func(t *testing.T) {
+			rule, found := policy.RuleFor(tc.repository)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.requireSignature, rule.RequireSignature)
+		})
+	}
+}
+
+func TestPolicyVerifier_Verify(t *testing.T) {
+	trustedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	untrustedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	policyDir := t.TempDir()
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&trustedKey.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(policyDir, "cosign.pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}), 0600))
+
+	policyPath := filepath.Join(policyDir, "policy.yml")
+	require.NoError(t, ioutil.WriteFile(policyPath, []byte(`apiVersion: imgpkg.carvel.dev/v1alpha1
+kind: SignaturePolicy
+rules:
+- repositories: ["*/library/third-party"]
+  requireSignature: true
+  publicKeys:
+  - path: cosign.pub
+`), 0600))
+	policy, err := signature.NewPolicyFromPath(policyPath)
+	require.NoError(t, err)
+
+	regBuilder := helpers.NewFakeRegistry(t, &helpers.Logger{})
+	defer regBuilder.CleanUp()
+	trustedImg := regBuilder.WithRandomImage("library/third-party:trusted")
+	untrustedImg := regBuilder.WithRandomImage("library/third-party:untrusted")
+	unsignedImg := regBuilder.WithRandomImage("library/third-party:unsigned")
+	relocatedImg := regBuilder.WithRandomImage("library/relocated")
+	notInPolicyImg := regBuilder.WithRandomImage("library/not-in-policy")
+	reg := regBuilder.Build()
+
+	sign := func(img *helpers.ImageOrImageIndexWithTarPath, key *ecdsa.PrivateKey) {
+		imgDigest, err := name.NewDigest(img.RefDigest)
+		require.NoError(t, err)
+		_, err = signature.NewCosignSigner(reg, key).Sign(imgDigest)
+		require.NoError(t, err)
+	}
+	sign(trustedImg, trustedKey)
+	sign(untrustedImg, untrustedKey)
+
+	images := func(imgs ...*helpers.ImageOrImageIndexWithTarPath) *imageset.UnprocessedImageRefs {
+		refs := imageset.NewUnprocessedImageRefs()
+		for _, img := range imgs {
+			refs.Add(imageset.UnprocessedImageRef{DigestRef: img.RefDigest})
+		}
+		return refs
+	}
+
+	subject := signature.NewPolicyVerifier(policy, signature.NewCosign(reg), reg, 2)
+
+	t.Run("when images comply with the policy it does not error", func(t *testing.T) {
+		err := subject.Verify(images(trustedImg, notInPolicyImg), nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("it reports every image that does not comply with the policy", func(t *testing.T) {
+		err := subject.Verify(images(trustedImg, untrustedImg, unsignedImg, notInPolicyImg), nil)
+		require.Error(t, err)
+
+		expectedViolations := []string{
+			fmt.Sprintf("Image '%s' is not signed by any of the public keys of the policy", untrustedImg.RefDigest),
+			fmt.Sprintf("Image '%s' is not signed", unsignedImg.RefDigest),
+		}
+		assert.True(t, strings.HasPrefix(err.Error(), "Signature policy violations:\n- "), err.Error())
+		for _, violation := range expectedViolations {
+			assert.Contains(t, err.Error(), violation)
+		}
+		assert.NotContains(t, err.Error(), trustedImg.RefDigest)
+	})
+
+	t.Run("it applies the rules of the image in the images lock to the location the image is read from", func(t *testing.T) {
+		relocatedDigest, err := name.NewDigest(relocatedImg.RefDigest)
+		require.NoError(t, err)
+		origImageRef := fmt.Sprintf("%s/library/third-party@%s", relocatedDigest.RegistryStr(), relocatedDigest.DigestStr())
+
+		err = subject.Verify(images(relocatedImg), map[string]string{relocatedImg.RefDigest: origImageRef})
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf("Signature policy violations:\n- Image '%s' is not signed", relocatedImg.RefDigest), err.Error())
+
+		require.NoError(t, subject.Verify(images(relocatedImg), nil))
+	})
+}
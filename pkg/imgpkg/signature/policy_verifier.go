@@ -0,0 +1,225 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+	"golang.org/x/sync/errgroup"
+)
+
+// SignatureReader Interface to read the images that store signatures
+type SignatureReader interface {
+	Image(regname.Reference) (regv1.Image, error)
+}
+
+// PolicyVerifier Checks that images comply with a signature Policy
+type PolicyVerifier struct {
+	policy      Policy
+	signatures  *Signatures
+	registry    SignatureReader
+	concurrency int
+}
+
+// NewPolicyVerifier Builds a PolicyVerifier that finds the cosign signatures of images with the finder
+func NewPolicyVerifier(policy Policy, finder Finder, reg SignatureReader, concurrency int) *PolicyVerifier {
+	return &PolicyVerifier{
+		policy:      policy,
+		signatures:  NewSignatures(finder, concurrency),
+		registry:    reg,
+		concurrency: concurrency,
+	}
+}
+
+// Verify Checks every image against the policy and returns an error listing all the violations found.
+// origImageRefs maps the location an image is read from to the image recorded in the images lock,
+// the rules matching either of them apply
+func (v PolicyVerifier) Verify(images *imageset.UnprocessedImageRefs, origImageRefs map[string]string) error {
+	required := imageset.NewUnprocessedImageRefs()
+	rules := map[string][]PolicyRule{}
+
+	for _, img := range images.All() {
+		imgDigest, err := regname.NewDigest(img.DigestRef)
+		if err != nil {
+			return fmt.Errorf("Parsing '%s': %s", img.DigestRef, err)
+		}
+
+		repositories := []string{imgDigest.Context().Name()}
+		if origImageRef, found := origImageRefs[img.DigestRef]; found {
+			origDigest, err := regname.NewDigest(origImageRef)
+			if err != nil {
+				return fmt.Errorf("Parsing '%s': %s", origImageRef, err)
+			}
+			if origDigest.Context().Name() != imgDigest.Context().Name() {
+				repositories = append(repositories, origDigest.Context().Name())
+			}
+		}
+
+		for _, repository := range repositories {
+			rule, found := v.policy.RuleFor(repository)
+			if found && rule.RequireSignature {
+				rules[imgDigest.Name()] = append(rules[imgDigest.Name()], rule)
+			}
+		}
+		if len(rules[imgDigest.Name()]) > 0 {
+			required.Add(imageset.UnprocessedImageRef{DigestRef: imgDigest.Name()})
+		}
+	}
+
+	signatures, err := v.signatures.Fetch(required)
+	if err != nil {
+		return err
+	}
+
+	signaturesByTag := map[string]imageset.UnprocessedImageRef{}
+	for _, sig := range signatures.All() {
+		sigDigest, err := regname.NewDigest(sig.DigestRef)
+		if err != nil {
+			return fmt.Errorf("Parsing '%s': %s", sig.DigestRef, err)
+		}
+		signaturesByTag[sigDigest.Context().Tag(sig.Tag).Name()] = sig
+	}
+
+	var violations []string
+	violationsLock := &sync.Mutex{}
+	throttle := util.NewThrottle(v.concurrency)
+	var wg errgroup.Group
+
+	for _, img := range required.All() {
+		img := img //copy
+		wg.Go(func() error {
+			imgDigest, err := regname.NewDigest(img.DigestRef)
+			if err != nil {
+				return fmt.Errorf("Parsing '%s': %s", img.DigestRef, err)
+			}
+
+			sigTag, err := Cosign{}.artifactTag(imgDigest, SignatureArtifact)
+			if err != nil {
+				return err
+			}
+
+			var sig *imageset.UnprocessedImageRef
+			if foundSig, found := signaturesByTag[sigTag.Name()]; found {
+				sig = &foundSig
+			}
+
+			throttle.Take()
+			violation, err := v.verifyImage(imgDigest, rules[img.DigestRef], sig)
+			throttle.Done()
+			if err != nil {
+				return fmt.Errorf("Verifying signature of image '%s': %s", imgDigest.Name(), err)
+			}
+
+			if len(violation) > 0 {
+				violationsLock.Lock()
+				violations = append(violations, violation)
+				violationsLock.Unlock()
+			}
+			return nil
+		})
+	}
+
+	err = wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("Signature policy violations:\n- %s", strings.Join(violations, "\n- "))
+	}
+
+	return nil
+}
+
+// verifyImage returns a description of the violation when the image does not comply with every rule
+func (v PolicyVerifier) verifyImage(imgDigest regname.Digest, rules []PolicyRule, sig *imageset.UnprocessedImageRef) (string, error) {
+	if sig == nil {
+		return fmt.Sprintf("Image '%s' is not signed", imgDigest.Name()), nil
+	}
+
+	sigDigest, err := regname.NewDigest(sig.DigestRef)
+	if err != nil {
+		return "", fmt.Errorf("Parsing '%s': %s", sig.DigestRef, err)
+	}
+
+	sigImage, err := v.registry.Image(sigDigest)
+	if err != nil {
+		return "", err
+	}
+
+	manifest, err := sigImage.Manifest()
+	if err != nil {
+		return "", err
+	}
+
+	verified := make([]bool, len(rules))
+	for _, layerDesc := range manifest.Layers {
+		if layerDesc.MediaType != cosign.SimpleSigningMediaType {
+			continue
+		}
+
+		payload, err := v.payload(sigImage, layerDesc.Digest)
+		if err != nil {
+			return "", err
+		}
+
+		var simpleSigning cosign.SimpleSigning
+		if err := json.Unmarshal(payload, &simpleSigning); err != nil {
+			continue
+		}
+		if simpleSigning.Critical.Image.DockerManifestDigest != imgDigest.DigestStr() {
+			continue
+		}
+
+		signature, err := base64.StdEncoding.DecodeString(layerDesc.Annotations[cosign.SignatureAnnotationKey])
+		if err != nil {
+			continue
+		}
+		payloadDigest := sha256.Sum256(payload)
+		for i, rule := range rules {
+			for _, key := range rule.keys {
+				if ecdsa.VerifyASN1(key, payloadDigest[:], signature) {
+					verified[i] = true
+				}
+			}
+		}
+	}
+
+	for _, ruleVerified := range verified {
+		if !ruleVerified {
+			return fmt.Sprintf("Image '%s' is not signed by any of the public keys of the policy", imgDigest.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+func (v PolicyVerifier) payload(sigImage regv1.Image, digest regv1.Hash) ([]byte, error) {
+	layer, err := sigImage.LayerByDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	// cosign stores the payload uncompressed
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"fmt"
+	"sync"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
+	"golang.org/x/sync/errgroup"
+)
+
+// ReferrersReader Interface to list the manifests that refer to a manifest
+type ReferrersReader interface {
+	Referrers(reference regname.Digest, artifactType string) (registry.Referrers, error)
+}
+
+// Referrers Finds the artifacts attached to images through the subject field of their manifests
+// (examples: notation signatures and SBOMs)
+type Referrers struct {
+	reader        ReferrersReader
+	artifactTypes []string
+	concurrency   int
+}
+
+// NewReferrers Builds a Referrers that finds artifacts with any of the provided artifact types,
+// or all artifacts when no artifact type is provided
+func NewReferrers(reader ReferrersReader, artifactTypes []string, concurrency int) *Referrers {
+	return &Referrers{
+		reader:        reader,
+		artifactTypes: artifactTypes,
+		concurrency:   concurrency,
+	}
+}
+
+// Fetch Retrieves the artifacts that refer to the images, and the artifacts that refer to those artifacts
+func (r *Referrers) Fetch(images *imageset.UnprocessedImageRefs) (*imageset.UnprocessedImageRefs, error) {
+	referrers := imageset.NewUnprocessedImageRefs()
+
+	throttle := util.NewThrottle(r.concurrency)
+	visited := map[string]struct{}{}
+	visitedLock := &sync.Mutex{}
+	var wg errgroup.Group
+
+	var fetch func(digestRef string)
+	fetch = func(digestRef string) {
+		visitedLock.Lock()
+		if _, found := visited[digestRef]; found {
+			visitedLock.Unlock()
+			return
+		}
+		visited[digestRef] = struct{}{}
+		visitedLock.Unlock()
+
+		wg.Go(func() error {
+			imgDigest, err := regname.NewDigest(digestRef)
+			if err != nil {
+				return fmt.Errorf("Parsing '%s': %s", digestRef, err)
+			}
+
+			found, err := r.find(imgDigest, throttle)
+			if err != nil {
+				return fmt.Errorf("Fetching referrers for image '%s': %s", imgDigest.Name(), err)
+			}
+
+			for _, referrer := range found {
+				referrers.Add(referrer)
+				if len(referrer.Tag) == 0 {
+					fetch(referrer.DigestRef)
+				}
+			}
+			return nil
+		})
+	}
+
+	for _, ref := range images.All() {
+		fetch(ref.DigestRef)
+	}
+
+	err := wg.Wait()
+
+	return referrers, err
+}
+
+func (r *Referrers) find(imgDigest regname.Digest, throttle util.Throttle) ([]imageset.UnprocessedImageRef, error) {
+	artifactTypes := r.artifactTypes
+	if len(artifactTypes) == 0 {
+		artifactTypes = []string{""}
+	}
+
+	var found []imageset.UnprocessedImageRef
+	for _, artifactType := range artifactTypes {
+		throttle.Take()
+		referrers, err := r.reader.Referrers(imgDigest, artifactType)
+		throttle.Done()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, referrer := range referrers.Manifests {
+			found = append(found, imageset.UnprocessedImageRef{
+				DigestRef: imgDigest.Digest(referrer.Digest).Name(),
+			})
+		}
+
+		// The index of the tag schema is only copied when it is not filtered, otherwise it would also reference
+		// artifacts that were not selected. ReferrersFallback lists the selected ones at the destination instead
+		if len(referrers.FallbackTag) > 0 && len(r.artifactTypes) == 0 {
+			found = append(found, imageset.UnprocessedImageRef{
+				DigestRef: imgDigest.Digest(referrers.FallbackDigest).Name(),
+				Tag:       referrers.FallbackTag,
+			})
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+)
+
+// ReferrersReaderWriter Interface to list the manifests that refer to a manifest and to tag the index listing them
+type ReferrersReaderWriter interface {
+	ReferrersReader
+	WriteTag(regname.Tag, regremote.Taggable) error
+}
+
+// ReferrersFallback Lists the copied artifacts in the index of the referrers tag schema (example: sha256-<hex>)
+// when the destination registry does not support the referrers API.
+// The index of the source is not copied when referrers are filtered by artifact type, or does not exist
+// when the source registry supports the referrers API, so without it the artifacts could not be found
+type ReferrersFallback struct {
+	writer ReferrersReaderWriter
+}
+
+// NewReferrersFallback Builds a ReferrersFallback that updates the indexes in the provided registry
+func NewReferrersFallback(writer ReferrersReaderWriter) *ReferrersFallback {
+	return &ReferrersFallback{writer: writer}
+}
+
+type referrerManifest struct {
+	ArtifactType string `json:"artifactType"`
+	Config       *struct {
+		MediaType string `json:"mediaType"`
+	} `json:"config"`
+	Subject *struct {
+		Digest string `json:"digest"`
+	} `json:"subject"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Write Adds the copied artifacts, that have a subject, to the index of their subject,
+// keeping the referrers already listed in the index
+func (r *ReferrersFallback) Write(images *imageset.ProcessedImages) error {
+	copiedTags := map[string]struct{}{}
+	referrersBySubject := map[string][]registry.Referrer{}
+
+	for _, image := range images.All() {
+		imgDigest, err := regname.NewDigest(image.DigestRef)
+		if err != nil {
+			return fmt.Errorf("Parsing '%s': %s", image.DigestRef, err)
+		}
+		if len(image.Tag) > 0 {
+			copiedTags[imgDigest.Context().Tag(image.Tag).Name()] = struct{}{}
+		}
+
+		referrer, subjectDigest, found, err := r.referrer(image)
+		if err != nil {
+			return fmt.Errorf("Reading subject of '%s': %s", image.DigestRef, err)
+		}
+		if found {
+			subjectRef := imgDigest.Context().Digest(subjectDigest).Name()
+			referrersBySubject[subjectRef] = append(referrersBySubject[subjectRef], referrer)
+		}
+	}
+
+	var subjectRefs []string
+	for subjectRef := range referrersBySubject {
+		subjectRefs = append(subjectRefs, subjectRef)
+	}
+	sort.Strings(subjectRefs)
+
+	for _, subjectRef := range subjectRefs {
+		subjectDigest, err := regname.NewDigest(subjectRef)
+		if err != nil {
+			return fmt.Errorf("Parsing '%s': %s", subjectRef, err)
+		}
+		hash, err := regv1.NewHash(subjectDigest.DigestStr())
+		if err != nil {
+			return fmt.Errorf("Converting to hash: %s", err)
+		}
+
+		fallbackTag := subjectDigest.Context().Tag(hash.Algorithm + "-" + hash.Hex)
+		if _, found := copiedTags[fallbackTag.Name()]; found {
+			// The index of the source, that already lists the copied artifacts, was copied
+			continue
+		}
+
+		err = r.writeIndex(subjectDigest, fallbackTag, referrersBySubject[subjectRef])
+		if err != nil {
+			return fmt.Errorf("Updating referrers index '%s': %s", fallbackTag.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ReferrersFallback) writeIndex(subjectDigest regname.Digest, fallbackTag regname.Tag, referrers []registry.Referrer) error {
+	existing, err := r.writer.Referrers(subjectDigest, "")
+	if err != nil {
+		return err
+	}
+	if !existing.TagSchema {
+		return nil
+	}
+
+	manifests := existing.Manifests
+	listed := map[string]struct{}{}
+	for _, referrer := range manifests {
+		listed[referrer.Digest] = struct{}{}
+	}
+
+	sort.Slice(referrers, func(i, j int) bool { return referrers[i].Digest < referrers[j].Digest })
+	for _, referrer := range referrers {
+		if _, found := listed[referrer.Digest]; found {
+			continue
+		}
+		listed[referrer.Digest] = struct{}{}
+		manifests = append(manifests, referrer)
+	}
+	if len(manifests) == len(existing.Manifests) {
+		return nil
+	}
+
+	rawIndex, err := json.Marshal(struct {
+		SchemaVersion int                 `json:"schemaVersion"`
+		MediaType     types.MediaType     `json:"mediaType"`
+		Manifests     []registry.Referrer `json:"manifests"`
+	}{2, types.OCIImageIndex, manifests})
+	if err != nil {
+		return err
+	}
+
+	return r.writer.WriteTag(fallbackTag, referrersIndex(rawIndex))
+}
+
+// referrer returns the descriptor used to list the image in the index of its subject, when it has a subject
+func (r *ReferrersFallback) referrer(image imageset.ProcessedImage) (registry.Referrer, string, bool, error) {
+	var taggable interface {
+		RawManifest() ([]byte, error)
+		MediaType() (types.MediaType, error)
+		Digest() (regv1.Hash, error)
+	}
+	switch {
+	case image.Image != nil:
+		taggable = image.Image
+	case image.ImageIndex != nil:
+		taggable = image.ImageIndex
+	default:
+		return registry.Referrer{}, "", false, nil
+	}
+
+	rawManifest, err := taggable.RawManifest()
+	if err != nil {
+		return registry.Referrer{}, "", false, err
+	}
+
+	var manifest referrerManifest
+	err = json.Unmarshal(rawManifest, &manifest)
+	if err != nil {
+		return registry.Referrer{}, "", false, fmt.Errorf("Unmarshaling manifest: %s", err)
+	}
+	if manifest.Subject == nil {
+		return registry.Referrer{}, "", false, nil
+	}
+
+	mediaType, err := taggable.MediaType()
+	if err != nil {
+		return registry.Referrer{}, "", false, err
+	}
+	digest, err := taggable.Digest()
+	if err != nil {
+		return registry.Referrer{}, "", false, err
+	}
+
+	// Image manifests without an artifactType use the media type of their config
+	artifactType := manifest.ArtifactType
+	if len(artifactType) == 0 && manifest.Config != nil {
+		artifactType = manifest.Config.MediaType
+	}
+
+	return registry.Referrer{
+		MediaType:    string(mediaType),
+		Digest:       digest.String(),
+		Size:         int64(len(rawManifest)),
+		ArtifactType: artifactType,
+		Annotations:  manifest.Annotations,
+	}, manifest.Subject.Digest, true, nil
+}
+
+// referrersIndex Index of the referrers tag schema
+type referrersIndex []byte
+
+// RawManifest returns the index
+func (i referrersIndex) RawManifest() ([]byte, error) { return i, nil }
+
+// MediaType returns the media type of the index
+func (i referrersIndex) MediaType() (types.MediaType, error) { return types.OCIImageIndex, nil }
@@ -0,0 +1,128 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	regremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+)
+
+type fakeReferrersReaderWriter struct {
+	fakeReferrersReader
+	written map[string][]byte
+}
+
+func (f *fakeReferrersReaderWriter) WriteTag(tag regname.Tag, taggable regremote.Taggable) error {
+	rawManifest, err := taggable.RawManifest()
+	if err != nil {
+		return err
+	}
+	f.written[tag.Name()] = rawManifest
+	return nil
+}
+
+// artifactImage Image with the subject and artifactType fields added to its manifest
+type artifactImage struct {
+	regv1.Image
+	rawManifest []byte
+}
+
+func newArtifactImage(t *testing.T, subjectDigest string, artifactType string) artifactImage {
+	img, err := random.Image(100, 1)
+	require.NoError(t, err)
+
+	rawManifest, err := img.RawManifest()
+	require.NoError(t, err)
+	manifest := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rawManifest, &manifest))
+	manifest["subject"] = map[string]interface{}{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": subjectDigest, "size": 100}
+	manifest["artifactType"] = artifactType
+
+	rawManifest, err = json.Marshal(manifest)
+	require.NoError(t, err)
+	return artifactImage{Image: img, rawManifest: rawManifest}
+}
+
+func (i artifactImage) RawManifest() ([]byte, error) { return i.rawManifest, nil }
+
+func (i artifactImage) Digest() (regv1.Hash, error) {
+	hash, _, err := regv1.SHA256(bytes.NewReader(i.rawManifest))
+	return hash, err
+}
+
+func TestReferrersFallback_Write(t *testing.T) {
+	fallbackTag := "registry.io/img:sha256-4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"
+	sbom := newArtifactImage(t, imageDigest, "application/spdx+json")
+	copiedDigest, err := sbom.Digest()
+	require.NoError(t, err)
+
+	fallbackIndex, err := random.Index(100, 1, 1)
+	require.NoError(t, err)
+
+	images := func(fallbackIndexCopied bool) *imageset.ProcessedImages {
+		processedImages := imageset.NewProcessedImages()
+		processedImages.Add(imageset.ProcessedImage{
+			UnprocessedImageRef: imageset.UnprocessedImageRef{DigestRef: "registry.io/src@" + copiedDigest.String()},
+			DigestRef:           "registry.io/img@" + copiedDigest.String(),
+			Image:               sbom,
+		})
+		if fallbackIndexCopied {
+			processedImages.Add(imageset.ProcessedImage{
+				UnprocessedImageRef: imageset.UnprocessedImageRef{DigestRef: "registry.io/src@" + fallbackDigest, Tag: "sha256-4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"},
+				DigestRef:           "registry.io/img@" + fallbackDigest,
+				ImageIndex:          fallbackIndex,
+			})
+		}
+		return processedImages
+	}
+	writer := func(referrers registry.Referrers) *fakeReferrersReaderWriter {
+		return &fakeReferrersReaderWriter{
+			fakeReferrersReader: fakeReferrersReader{referrers: map[string]registry.Referrers{imageDigest: referrers}},
+			written:             map[string][]byte{},
+		}
+	}
+
+	t.Run("when the registry uses the tag schema it adds the copied artifacts to the index", func(t *testing.T) {
+		fakeWriter := writer(registry.Referrers{
+			Manifests: []registry.Referrer{{MediaType: "application/vnd.oci.image.manifest.v1+json", Digest: signatureDigest, Size: 10, ArtifactType: "application/vnd.cncf.notary.signature"}},
+			TagSchema: true,
+		})
+
+		require.NoError(t, signature.NewReferrersFallback(fakeWriter).Write(images(false)))
+
+		require.Contains(t, fakeWriter.written, fallbackTag)
+		index, err := regv1.ParseIndexManifest(bytes.NewReader(fakeWriter.written[fallbackTag]))
+		require.NoError(t, err)
+		require.Len(t, index.Manifests, 2)
+		assert.Equal(t, signatureDigest, index.Manifests[0].Digest.String())
+		assert.Equal(t, copiedDigest.String(), index.Manifests[1].Digest.String())
+		assert.Contains(t, string(fakeWriter.written[fallbackTag]), `"artifactType":"application/spdx+json"`)
+	})
+
+	t.Run("when the registry supports the referrers API it does not write an index", func(t *testing.T) {
+		fakeWriter := writer(registry.Referrers{})
+
+		require.NoError(t, signature.NewReferrersFallback(fakeWriter).Write(images(false)))
+		assert.Empty(t, fakeWriter.written)
+	})
+
+	t.Run("when the index of the source was copied it does not write an index", func(t *testing.T) {
+		fakeWriter := writer(registry.Referrers{TagSchema: true})
+
+		require.NoError(t, signature.NewReferrersFallback(fakeWriter).Write(images(true)))
+		assert.Empty(t, fakeWriter.written)
+		assert.Empty(t, fakeWriter.calls)
+	})
+}
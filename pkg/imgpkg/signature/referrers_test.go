@@ -0,0 +1,107 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/imageset"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+)
+
+const (
+	imageDigest     = "sha256:4c8b96d4fffdfae29258d94a22ae4ad1fe36139d47288b8960d9958d1e63a9d0"
+	signatureDigest = "sha256:cf31af331f38d1d7158470e095b132acd126a7180a54f263d386da88eb681d93"
+	sbomDigest      = "sha256:be154cc2b1211a9f98f4d708f4266650c9129784d0485d4507d9b0fa05d928b6"
+	sbomSigDigest   = "sha256:6716afd7a68262a37d3f67681ed9dedf3b882938ad777f268f44d68894531f7a"
+	fallbackDigest  = "sha256:56cb33b3b4bc45509c5ff7513ddc6ed78764f9ad5165cc32826e04da49d5462b"
+)
+
+type fakeReferrersReader struct {
+	referrers map[string]registry.Referrers
+	err       error
+
+	calls []string
+	lock  sync.Mutex
+}
+
+func (f *fakeReferrersReader) Referrers(reference regname.Digest, artifactType string) (registry.Referrers, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, reference.DigestStr()+"/"+artifactType)
+
+	if f.err != nil {
+		return registry.Referrers{}, f.err
+	}
+
+	referrers := f.referrers[reference.DigestStr()]
+	var filtered []registry.Referrer
+	for _, referrer := range referrers.Manifests {
+		if artifactType == "" || referrer.ArtifactType == artifactType {
+			filtered = append(filtered, referrer)
+		}
+	}
+	referrers.Manifests = filtered
+	return referrers, nil
+}
+
+func TestReferrers_Fetch(t *testing.T) {
+	reader := func() *fakeReferrersReader {
+		return &fakeReferrersReader{referrers: map[string]registry.Referrers{
+			imageDigest: {Manifests: []registry.Referrer{
+				{Digest: signatureDigest, ArtifactType: "application/vnd.cncf.notary.signature"},
+				{Digest: sbomDigest, ArtifactType: "application/spdx+json"},
+			}},
+			sbomDigest: {
+				Manifests:      []registry.Referrer{{Digest: sbomSigDigest, ArtifactType: "application/vnd.cncf.notary.signature"}},
+				FallbackTag:    "sha256-be154cc2b1211a9f98f4d708f4266650c9129784d0485d4507d9b0fa05d928b6",
+				FallbackDigest: fallbackDigest,
+			},
+		}}
+	}
+	images := func() *imageset.UnprocessedImageRefs {
+		refs := imageset.NewUnprocessedImageRefs()
+		refs.Add(imageset.UnprocessedImageRef{DigestRef: "registry.io/img@" + imageDigest, Tag: "some-tag"})
+		return refs
+	}
+
+	t.Run("it returns the referrers of the images and of the referrers", func(t *testing.T) {
+		subject := signature.NewReferrers(reader(), nil, 2)
+
+		referrers, err := subject.Fetch(images())
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []imageset.UnprocessedImageRef{
+			{DigestRef: "registry.io/img@" + signatureDigest},
+			{DigestRef: "registry.io/img@" + sbomDigest},
+			{DigestRef: "registry.io/img@" + sbomSigDigest},
+			{DigestRef: "registry.io/img@" + fallbackDigest, Tag: "sha256-be154cc2b1211a9f98f4d708f4266650c9129784d0485d4507d9b0fa05d928b6"},
+		}, referrers.All())
+	})
+
+	t.Run("when artifact types are provided it only returns referrers with those artifact types", func(t *testing.T) {
+		fakeReader := reader()
+		subject := signature.NewReferrers(fakeReader, []string{"application/spdx+json"}, 2)
+
+		referrers, err := subject.Fetch(images())
+		require.NoError(t, err)
+
+		assert.Equal(t, []imageset.UnprocessedImageRef{{DigestRef: "registry.io/img@" + sbomDigest}}, referrers.All())
+		assert.ElementsMatch(t, []string{imageDigest + "/application/spdx+json", sbomDigest + "/application/spdx+json"}, fakeReader.calls)
+	})
+
+	t.Run("when listing referrers fails it returns an error", func(t *testing.T) {
+		subject := signature.NewReferrers(&fakeReferrersReader{err: fmt.Errorf("some error")}, nil, 2)
+
+		_, err := subject.Fetch(images())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Fetching referrers for image 'registry.io/img@"+imageDigest+"': some error")
+	})
+}
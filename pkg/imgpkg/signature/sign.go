@@ -0,0 +1,165 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	regname "github.com/google/go-containerregistry/pkg/name"
+	regv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
+)
+
+// SignatureWriter Interface to read and write the images that store signatures
+type SignatureWriter interface {
+	Image(regname.Reference) (regv1.Image, error)
+	WriteImage(regname.Reference, regv1.Image) error
+}
+
+// CosignSigner Signs images the same way cosign sign does, storing a simple signing payload and its signature
+// in the image tagged sha256-<hex>.sig in the repository of the signed image
+type CosignSigner struct {
+	registry SignatureWriter
+	key      *ecdsa.PrivateKey
+}
+
+// NewCosignSigner Builds a CosignSigner that signs with the provided key
+func NewCosignSigner(reg SignatureWriter, key *ecdsa.PrivateKey) *CosignSigner {
+	return &CosignSigner{registry: reg, key: key}
+}
+
+// Sign Signs the image and returns the reference of the signature image
+// Signatures already attached to the image are kept, and when the image was already signed with the same key
+// the existing signature is reused
+func (s CosignSigner) Sign(imageRef regname.Digest) (regname.Digest, error) {
+	payload, err := cosign.SimpleSigningPayload(imageRef)
+	if err != nil {
+		return regname.Digest{}, fmt.Errorf("Creating signature payload: %s", err)
+	}
+
+	sigTag, err := Cosign{}.artifactTag(imageRef, SignatureArtifact)
+	if err != nil {
+		return regname.Digest{}, err
+	}
+
+	sigImage, err := s.signatureImage(sigTag)
+	if err != nil {
+		return regname.Digest{}, err
+	}
+
+	layer := payloadLayer{payload: payload}
+	found, err := s.containsSignature(sigImage, layer)
+	if err != nil {
+		return regname.Digest{}, err
+	}
+
+	if !found {
+		payloadDigest := sha256.Sum256(payload)
+		signature, err := ecdsa.SignASN1(rand.Reader, s.key, payloadDigest[:])
+		if err != nil {
+			return regname.Digest{}, fmt.Errorf("Signing payload: %s", err)
+		}
+		encodedSignature := base64.StdEncoding.EncodeToString(signature)
+
+		sigImage, err = mutate.Append(sigImage, mutate.Addendum{
+			Layer:       layer,
+			Annotations: map[string]string{cosign.SignatureAnnotationKey: encodedSignature},
+		})
+		if err != nil {
+			return regname.Digest{}, fmt.Errorf("Adding signature: %s", err)
+		}
+
+		err = s.registry.WriteImage(sigTag, sigImage)
+		if err != nil {
+			return regname.Digest{}, fmt.Errorf("Writing signature '%s': %s", sigTag.Name(), err)
+		}
+	}
+
+	sigDigest, err := sigImage.Digest()
+	if err != nil {
+		return regname.Digest{}, err
+	}
+
+	return imageRef.Context().Digest(sigDigest.String()), nil
+}
+
+// signatureImage returns the signature image attached to the image, or an empty image when there is none
+func (s CosignSigner) signatureImage(sigTag regname.Tag) (regv1.Image, error) {
+	sigImage, err := s.registry.Image(sigTag)
+	if err != nil {
+		if transportErr, ok := err.(*transport.Error); ok && transportErr.StatusCode == http.StatusNotFound {
+			return empty.Image, nil
+		}
+		return nil, fmt.Errorf("Fetching signature '%s': %s", sigTag.Name(), err)
+	}
+	return sigImage, nil
+}
+
+// containsSignature checks if the signature image already contains the payload signed with the key,
+// so that signing the same image again does not add duplicated layers
+func (s CosignSigner) containsSignature(sigImage regv1.Image, layer payloadLayer) (bool, error) {
+	manifest, err := sigImage.Manifest()
+	if err != nil {
+		return false, err
+	}
+
+	layerDigest, err := layer.Digest()
+	if err != nil {
+		return false, err
+	}
+
+	payloadDigest := sha256.Sum256(layer.payload)
+
+	for _, existingLayer := range manifest.Layers {
+		if existingLayer.Digest != layerDigest {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(existingLayer.Annotations[cosign.SignatureAnnotationKey])
+		if err != nil {
+			continue
+		}
+		if ecdsa.VerifyASN1(&s.key.PublicKey, payloadDigest[:], signature) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// payloadLayer Layer with the simple signing payload, which cosign stores uncompressed
+type payloadLayer struct {
+	payload []byte
+}
+
+var _ regv1.Layer = payloadLayer{}
+
+func (l payloadLayer) Digest() (regv1.Hash, error) {
+	hash, _, err := regv1.SHA256(bytes.NewReader(l.payload))
+	return hash, err
+}
+
+func (l payloadLayer) DiffID() (regv1.Hash, error) { return l.Digest() }
+
+func (l payloadLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.payload)), nil
+}
+
+func (l payloadLayer) Uncompressed() (io.ReadCloser, error) { return l.Compressed() }
+
+func (l payloadLayer) Size() (int64, error) { return int64(len(l.payload)), nil }
+
+func (l payloadLayer) MediaType() (types.MediaType, error) {
+	return cosign.SimpleSigningMediaType, nil
+}
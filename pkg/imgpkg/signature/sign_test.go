@@ -0,0 +1,107 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package signature_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/signature/cosign"
+	"github.com/vmware-tanzu/carvel-imgpkg/test/helpers"
+)
+
+func TestCosignSigner_Sign(t *testing.T) {
+	setup := func(t *testing.T) (*helpers.FakeTestRegistryBuilder, name.Digest, *ecdsa.PrivateKey) {
+		regBuilder := helpers.NewFakeRegistry(t, &helpers.Logger{})
+		img := regBuilder.WithRandomImage("some-image")
+		imgDigest, err := name.NewDigest(img.RefDigest)
+		require.NoError(t, err)
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return regBuilder, imgDigest, key
+	}
+
+	t.Run("it writes a signature that can be found as a cosign signature", func(t *testing.T) {
+		regBuilder, imgDigest, key := setup(t)
+		reg := regBuilder.Build()
+		defer regBuilder.CleanUp()
+
+		sigRef, err := signature.NewCosignSigner(reg, key).Sign(imgDigest)
+		require.NoError(t, err)
+
+		found, err := signature.NewCosign(reg).Signature(imgDigest)
+		require.NoError(t, err)
+		assert.Equal(t, sigRef.Name(), found.DigestRef)
+
+		sigImage, err := reg.Image(sigRef)
+		require.NoError(t, err)
+		manifest, err := sigImage.Manifest()
+		require.NoError(t, err)
+		require.Len(t, manifest.Layers, 1)
+		assert.Equal(t, cosign.SimpleSigningMediaType, string(manifest.Layers[0].MediaType))
+
+		layers, err := sigImage.Layers()
+		require.NoError(t, err)
+		// cosign stores the payload uncompressed, so it is read as is
+		rc, err := layers[0].Compressed()
+		require.NoError(t, err)
+		defer rc.Close()
+		payload, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+
+		var simpleSigning cosign.SimpleSigning
+		require.NoError(t, json.Unmarshal(payload, &simpleSigning))
+		assert.Equal(t, imgDigest.Repository.Name(), simpleSigning.Critical.Identity.DockerReference)
+		assert.Equal(t, imgDigest.DigestStr(), simpleSigning.Critical.Image.DockerManifestDigest)
+		assert.Equal(t, cosign.SimpleSigningType, simpleSigning.Critical.Type)
+
+		sig, err := base64.StdEncoding.DecodeString(manifest.Layers[0].Annotations[cosign.SignatureAnnotationKey])
+		require.NoError(t, err)
+		payloadDigest := sha256.Sum256(payload)
+		assert.True(t, ecdsa.VerifyASN1(&key.PublicKey, payloadDigest[:], sig), "expected signature to be valid")
+	})
+
+	t.Run("when the image is signed again with the same key it keeps the existing signature", func(t *testing.T) {
+		regBuilder, imgDigest, key := setup(t)
+		reg := regBuilder.Build()
+		defer regBuilder.CleanUp()
+
+		firstSigRef, err := signature.NewCosignSigner(reg, key).Sign(imgDigest)
+		require.NoError(t, err)
+		secondSigRef, err := signature.NewCosignSigner(reg, key).Sign(imgDigest)
+		require.NoError(t, err)
+		assert.Equal(t, firstSigRef, secondSigRef)
+	})
+
+	t.Run("when the image is signed with another key it adds the signature", func(t *testing.T) {
+		regBuilder, imgDigest, key := setup(t)
+		reg := regBuilder.Build()
+		defer regBuilder.CleanUp()
+
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		_, err = signature.NewCosignSigner(reg, key).Sign(imgDigest)
+		require.NoError(t, err)
+		sigRef, err := signature.NewCosignSigner(reg, otherKey).Sign(imgDigest)
+		require.NoError(t, err)
+
+		sigImage, err := reg.Image(sigRef)
+		require.NoError(t, err)
+		manifest, err := sigImage.Manifest()
+		require.NoError(t, err)
+		assert.Len(t, manifest.Layers, 2)
+	})
+}
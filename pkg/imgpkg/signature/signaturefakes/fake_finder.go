@@ -10,6 +10,20 @@ import (
 )
 
 type FakeFinder struct {
+	ArtifactStub        func(name.Digest, signature.ArtifactKind) (imageset.UnprocessedImageRef, error)
+	artifactMutex       sync.RWMutex
+	artifactArgsForCall []struct {
+		arg1 name.Digest
+		arg2 signature.ArtifactKind
+	}
+	artifactReturns struct {
+		result1 imageset.UnprocessedImageRef
+		result2 error
+	}
+	artifactReturnsOnCall map[int]struct {
+		result1 imageset.UnprocessedImageRef
+		result2 error
+	}
 	SignatureStub        func(name.Digest) (imageset.UnprocessedImageRef, error)
 	signatureMutex       sync.RWMutex
 	signatureArgsForCall []struct {
@@ -27,6 +41,71 @@ type FakeFinder struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeFinder) Artifact(arg1 name.Digest, arg2 signature.ArtifactKind) (imageset.UnprocessedImageRef, error) {
+	fake.artifactMutex.Lock()
+	ret, specificReturn := fake.artifactReturnsOnCall[len(fake.artifactArgsForCall)]
+	fake.artifactArgsForCall = append(fake.artifactArgsForCall, struct {
+		arg1 name.Digest
+		arg2 signature.ArtifactKind
+	}{arg1, arg2})
+	stub := fake.ArtifactStub
+	fakeReturns := fake.artifactReturns
+	fake.recordInvocation("Artifact", []interface{}{arg1, arg2})
+	fake.artifactMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeFinder) ArtifactCallCount() int {
+	fake.artifactMutex.RLock()
+	defer fake.artifactMutex.RUnlock()
+	return len(fake.artifactArgsForCall)
+}
+
+func (fake *FakeFinder) ArtifactCalls(stub func(name.Digest, signature.ArtifactKind) (imageset.UnprocessedImageRef, error)) {
+	fake.artifactMutex.Lock()
+	defer fake.artifactMutex.Unlock()
+	fake.ArtifactStub = stub
+}
+
+func (fake *FakeFinder) ArtifactArgsForCall(i int) (name.Digest, signature.ArtifactKind) {
+	fake.artifactMutex.RLock()
+	defer fake.artifactMutex.RUnlock()
+	argsForCall := fake.artifactArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeFinder) ArtifactReturns(result1 imageset.UnprocessedImageRef, result2 error) {
+	fake.artifactMutex.Lock()
+	defer fake.artifactMutex.Unlock()
+	fake.ArtifactStub = nil
+	fake.artifactReturns = struct {
+		result1 imageset.UnprocessedImageRef
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFinder) ArtifactReturnsOnCall(i int, result1 imageset.UnprocessedImageRef, result2 error) {
+	fake.artifactMutex.Lock()
+	defer fake.artifactMutex.Unlock()
+	fake.ArtifactStub = nil
+	if fake.artifactReturnsOnCall == nil {
+		fake.artifactReturnsOnCall = make(map[int]struct {
+			result1 imageset.UnprocessedImageRef
+			result2 error
+		})
+	}
+	fake.artifactReturnsOnCall[i] = struct {
+		result1 imageset.UnprocessedImageRef
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeFinder) Signature(arg1 name.Digest) (imageset.UnprocessedImageRef, error) {
 	fake.signatureMutex.Lock()
 	ret, specificReturn := fake.signatureReturnsOnCall[len(fake.signatureArgsForCall)]
@@ -94,6 +173,8 @@ func (fake *FakeFinder) SignatureReturnsOnCall(i int, result1 imageset.Unprocess
 func (fake *FakeFinder) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.artifactMutex.RLock()
+	defer fake.artifactMutex.RUnlock()
 	fake.signatureMutex.RLock()
 	defer fake.signatureMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -0,0 +1,104 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"fmt"
+	"sync"
+
+	goui "github.com/cppforlife/go-cli-ui/ui"
+)
+
+type bufferedMessageKind int
+
+const (
+	errorLineMessage bufferedMessageKind = iota
+	printLineMessage
+	beginLineMessage
+	endLineMessage
+	blockMessage
+	errorBlockMessage
+)
+
+type bufferedMessage struct {
+	kind bufferedMessageKind
+	msg  string
+}
+
+// BufferedUI records every message written to it, so that it can be written to another ui.UI at a later time
+type BufferedUI struct {
+	goui.UI
+
+	messages []bufferedMessage
+	lock     sync.Mutex
+}
+
+var _ goui.UI = &BufferedUI{}
+
+// NewBufferedUI constructor for BufferedUI
+func NewBufferedUI() *BufferedUI {
+	return &BufferedUI{UI: goui.NewNoopUI()}
+}
+
+// ErrorLinef records an error line
+func (b *BufferedUI) ErrorLinef(pattern string, args ...interface{}) {
+	b.add(errorLineMessage, fmt.Sprintf(pattern, args...))
+}
+
+// PrintLinef records a line
+func (b *BufferedUI) PrintLinef(pattern string, args ...interface{}) {
+	b.add(printLineMessage, fmt.Sprintf(pattern, args...))
+}
+
+// BeginLinef records the beginning of a line
+func (b *BufferedUI) BeginLinef(pattern string, args ...interface{}) {
+	b.add(beginLineMessage, fmt.Sprintf(pattern, args...))
+}
+
+// EndLinef records the end of a line
+func (b *BufferedUI) EndLinef(pattern string, args ...interface{}) {
+	b.add(endLineMessage, fmt.Sprintf(pattern, args...))
+}
+
+// PrintBlock records a block of text
+func (b *BufferedUI) PrintBlock(block []byte) {
+	b.add(blockMessage, string(block))
+}
+
+// PrintErrorBlock records a block of text for an error
+func (b *BufferedUI) PrintErrorBlock(block string) {
+	b.add(errorBlockMessage, block)
+}
+
+// Replay writes all the recorded messages, in order, to the provided ui.UI
+func (b *BufferedUI) Replay(ui goui.UI) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, message := range b.messages {
+		switch message.kind {
+		case errorLineMessage:
+			ui.ErrorLinef("%s", message.msg)
+		case printLineMessage:
+			ui.PrintLinef("%s", message.msg)
+		case beginLineMessage:
+			ui.BeginLinef("%s", message.msg)
+		case endLineMessage:
+			ui.EndLinef("%s", message.msg)
+		case blockMessage:
+			ui.PrintBlock([]byte(message.msg))
+		case errorBlockMessage:
+			ui.PrintErrorBlock(message.msg)
+		default:
+			panic(fmt.Sprintf("Internal inconsistency: unknown message kind %d", message.kind))
+		}
+	}
+}
+
+func (b *BufferedUI) add(kind bufferedMessageKind, msg string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.messages = append(b.messages, bufferedMessage{kind: kind, msg: msg})
+}
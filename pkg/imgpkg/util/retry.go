@@ -4,7 +4,11 @@
 package util
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
@@ -18,10 +22,67 @@ func (n NonRetryableError) Error() string {
 	return n.Message
 }
 
-func Retry(doFunc func() error) error {
+// RetryPolicy Configures how failed operations are retried.
+// The wait between attempts starts at InitialInterval and is multiplied by Multiplier after every attempt,
+// up to MaxInterval, and is randomized by +/- Jitter (a fraction of the wait).
+// Operations are attempted at most MaxAttempts times and stop being retried once MaxElapsedTime would be exceeded.
+// The zero value RetryPolicy uses the values of DefaultRetryPolicy.
+type RetryPolicy struct {
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxElapsedTime  time.Duration
+	Multiplier      float64
+	Jitter          float64
+}
+
+// DefaultRetryPolicy Policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     5,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     30 * time.Second,
+		MaxElapsedTime:  5 * time.Minute,
+		Multiplier:      2,
+		Jitter:          0.2,
+	}
+}
+
+// ThrottlingStatusCodes are the responses registries use to ask clients to slow down.
+// Requests rejected with them are already retried by the registry transport, that honours the Retry-After header,
+// so they are not retried again by Retry
+var ThrottlingStatusCodes = map[int]struct{}{
+	http.StatusTooManyRequests:    {},
+	http.StatusServiceUnavailable: {},
+}
+
+// IsThrottlingError checks if the error is a registry response with one of the ThrottlingStatusCodes
+func IsThrottlingError(err error) bool {
+	var tranErr *transport.Error
+	if !errors.As(err, &tranErr) {
+		return false
+	}
+	_, found := ThrottlingStatusCodes[tranErr.StatusCode]
+	return found
+}
+
+// OrDefault returns DefaultRetryPolicy when the policy is the zero value
+func (p RetryPolicy) OrDefault() RetryPolicy {
+	if p == (RetryPolicy{}) {
+		return DefaultRetryPolicy()
+	}
+	return p
+}
+
+// Retry executes doFunc until it succeeds, returns a non-retryable error or the policy limits are reached
+func (p RetryPolicy) Retry(doFunc func() error) error {
+	p = p.OrDefault()
+	start := time.Now()
+
 	var lastErr error
+	attempt := 1
 
-	for i := 0; i < 5; i++ {
+	for ; ; attempt++ {
 		lastErr = doFunc()
 		if lastErr == nil {
 			return nil
@@ -37,8 +98,50 @@ func Retry(doFunc func() error) error {
 		if nonRetryableError, ok := lastErr.(NonRetryableError); ok {
 			return nonRetryableError
 		}
+		if IsThrottlingError(lastErr) {
+			break
+		}
 
-		time.Sleep(1 * time.Second)
+		wait := p.Backoff(attempt)
+		if !p.CanRetry(attempt, time.Since(start)+wait) {
+			break
+		}
+
+		time.Sleep(wait)
+	}
+
+	if attempt == 1 {
+		return lastErr
 	}
-	return fmt.Errorf("Retried 5 times: %s", lastErr)
+	return fmt.Errorf("Retried %d times: %s", attempt, lastErr)
+}
+
+// CanRetry checks if another attempt is allowed after the provided number of attempts,
+// where elapsed is the time spent since the first attempt including the wait before the next one
+func (p RetryPolicy) CanRetry(attempt int, elapsed time.Duration) bool {
+	p = p.OrDefault()
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	return p.MaxElapsedTime <= 0 || elapsed <= p.MaxElapsedTime
+}
+
+// Backoff returns the time to wait after the provided number of failed attempts
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	p = p.OrDefault()
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	interval := float64(p.InitialInterval) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxInterval > 0 && interval > float64(p.MaxInterval) {
+		interval = float64(p.MaxInterval)
+	}
+
+	if p.Jitter > 0 {
+		interval += interval * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(interval)
 }
@@ -5,29 +5,62 @@ package util
 
 import (
 	"errors"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 )
 
+var quickRetryPolicy = RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 1 * time.Millisecond,
+	MaxInterval:     5 * time.Millisecond,
+	Multiplier:      2,
+}
+
 func TestRetry(t *testing.T) {
 	numOfRetries := 0
 
-	Retry(func() error {
+	err := quickRetryPolicy.Retry(func() error {
 		numOfRetries++
-		return errors.New("")
+		return errors.New("some error")
 	})
 
 	if numOfRetries != 5 {
 		t.Fatalf("Expected to retry 5 times, but ran %d", numOfRetries)
 	}
+
+	expectedError := "Retried 5 times: some error"
+	if err == nil || err.Error() != expectedError {
+		t.Fatalf("Expected error message to be %s, but got: %s", expectedError, err)
+	}
+}
+
+func TestRetryStopsWhenMaxElapsedTimeIsReached(t *testing.T) {
+	numOfRetries := 0
+
+	policy := quickRetryPolicy
+	policy.MaxAttempts = 100
+	policy.InitialInterval = 20 * time.Millisecond
+	policy.MaxInterval = 20 * time.Millisecond
+	policy.MaxElapsedTime = 50 * time.Millisecond
+
+	policy.Retry(func() error {
+		numOfRetries++
+		return errors.New("")
+	})
+
+	if numOfRetries != 3 {
+		t.Fatalf("Expected to retry 3 times, but ran %d", numOfRetries)
+	}
 }
 
 func TestNonRetryableTransportErrorDoesNotRetry(t *testing.T) {
 	numOfRetries := 0
 
-	Retry(func() error {
+	quickRetryPolicy.Retry(func() error {
 		numOfRetries++
 		err := &transport.Error{
 			Errors:     []transport.Diagnostic{{Code: transport.UnauthorizedErrorCode}},
@@ -41,10 +74,42 @@ func TestNonRetryableTransportErrorDoesNotRetry(t *testing.T) {
 	}
 }
 
+func TestThrottlingErrorDoesNotRetry(t *testing.T) {
+	numOfRetries := 0
+
+	err := quickRetryPolicy.Retry(func() error {
+		numOfRetries++
+		return &transport.Error{StatusCode: http.StatusTooManyRequests}
+	})
+
+	if numOfRetries != 1 {
+		t.Fatalf("Expected to retry 1 times, but ran %d", numOfRetries)
+	}
+
+	expectedError := "unexpected status code 429"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("Expected error message to contain %s, but got: %s", expectedError, err)
+	}
+}
+
+func TestZeroValueRetryPolicyUsesDefaults(t *testing.T) {
+	policy := RetryPolicy{}
+
+	if !policy.CanRetry(4, time.Minute) {
+		t.Fatalf("Expected the zero value policy to allow a 5th attempt")
+	}
+	if policy.CanRetry(5, time.Minute) {
+		t.Fatalf("Expected the zero value policy to not allow a 6th attempt")
+	}
+	if backoff := policy.Backoff(1); backoff < 800*time.Millisecond || backoff > 1200*time.Millisecond {
+		t.Fatalf("Expected the zero value policy to wait around 1s after the first attempt, but got %s", backoff)
+	}
+}
+
 func TestNonRetryableErrorDoesNotRetry(t *testing.T) {
 	numOfRetries := 0
 
-	err := Retry(func() error {
+	err := quickRetryPolicy.Retry(func() error {
 		numOfRetries++
 		return NonRetryableError{
 			"An error occurred",
@@ -60,3 +125,21 @@ func TestNonRetryableErrorDoesNotRetry(t *testing.T) {
 		t.Fatalf("Expected error message to contain %s, but got: %s", expectedError, err)
 	}
 }
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialInterval: 1 * time.Second, MaxInterval: 10 * time.Second, Multiplier: 2}
+
+	expectedBackoffs := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, expected := range expectedBackoffs {
+		if backoff := policy.Backoff(i + 1); backoff != expected {
+			t.Fatalf("Expected backoff after attempt %d to be %s, but got %s", i+1, expected, backoff)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if backoff := policy.Backoff(2); backoff < 1*time.Second || backoff > 3*time.Second {
+			t.Fatalf("Expected backoff with jitter to be between 1s and 3s, but got %s", backoff)
+		}
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/image"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/lockconfig"
 	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/registry"
+	"github.com/vmware-tanzu/carvel-imgpkg/pkg/imgpkg/util"
 )
 
 type FakeTestRegistryBuilder struct {
@@ -55,7 +56,7 @@ func NewFakeRegistry(t *testing.T, logger *Logger) *FakeTestRegistryBuilder {
 func (r *FakeTestRegistryBuilder) Build() registry.Registry {
 	return r.BuildWithRegistryOpts(registry.Opts{
 		EnvironFunc: os.Environ,
-		RetryCount:  3,
+		RetryPolicy: util.RetryPolicy{MaxAttempts: 3},
 	})
 }
 